@@ -1,63 +1,59 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/kosttiik/pvz-service/internal/routes"
-	"github.com/kosttiik/pvz-service/internal/utils"
-	"github.com/kosttiik/pvz-service/pkg/database"
+	"github.com/kosttiik/pvz-service/internal/app"
+	"github.com/kosttiik/pvz-service/internal/config"
 	"github.com/kosttiik/pvz-service/pkg/logger"
-	"github.com/kosttiik/pvz-service/pkg/redis"
+	"github.com/kosttiik/pvz-service/pkg/tracing"
 	"go.uber.org/zap"
 )
 
 func main() {
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
 	if err := logger.Init(); err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 	defer logger.Close()
 
-	log := logger.Log
+	if err := logger.SetLevel(cfg.Log.Level); err != nil {
+		log.Fatalf("Failed to set log level: %v", err)
+	}
 
-	errChan := make(chan error, 2)
+	log := logger.Log
 
-	go func() {
-		if err := database.Connect(); err != nil {
-			errChan <- fmt.Errorf("database connection failed: %w", err)
-			return
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing.Exporter)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error("Failed to shutdown tracing", zap.Error(err))
 		}
-		log.Info("Successfully connected to database")
-		errChan <- nil
 	}()
 
-	go func() {
-		if err := redis.Connect(); err != nil {
-			errChan <- fmt.Errorf("redis connection failed: %w", err)
-			return
-		}
-		log.Info("Successfully connected to redis")
-		errChan <- nil
-	}()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	for range 2 {
-		if err := <-errChan; err != nil {
-			log.Fatal("Failed to initialize services", zap.Error(err))
-		}
+	application, err := app.New(ctx, *cfg, log)
+	if err != nil {
+		log.Fatal("Failed to initialize services", zap.Error(err))
 	}
 
-	defer redis.Close()
-
-	utils.Migrate()
-	log.Info("Database migration completed")
-
-	routes.SetupRoutes()
-	log.Info("Routes initialized")
+	if err := application.Run(ctx); err != nil {
+		log.Error("Server stopped with error", zap.Error(err))
+	}
 
-	addr := ":8080"
-	log.Info("Starting server", zap.String("address", addr))
-	if err := http.ListenAndServe(addr, nil); err != nil {
-		log.Fatal("Server failed", zap.Error(err))
+	if err := application.Close(); err != nil {
+		log.Error("Failed to close connections", zap.Error(err))
 	}
 }
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kosttiik/pvz-service/internal/config"
+	"github.com/kosttiik/pvz-service/internal/dashboard"
+	"github.com/kosttiik/pvz-service/internal/handlers"
+	"github.com/kosttiik/pvz-service/internal/idempotency"
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/middleware"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/outbox"
+	"github.com/kosttiik/pvz-service/internal/pvzcache"
+	"github.com/kosttiik/pvz-service/internal/ratelimit"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/routes"
+	"github.com/kosttiik/pvz-service/internal/server"
+	"github.com/kosttiik/pvz-service/internal/service"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/internal/webhook"
+	"github.com/kosttiik/pvz-service/pkg/cache"
+	"github.com/kosttiik/pvz-service/pkg/database"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"github.com/kosttiik/pvz-service/pkg/mailer"
+	pkgredis "github.com/kosttiik/pvz-service/pkg/redis"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const openReceptionsInterval = 30 * time.Second
+
+// App владеет соединениями, репозиториями, воркерами и HTTP сервером одного экземпляра сервиса
+type App struct {
+	config config.Config
+	log    *zap.Logger
+	db     *pgxpool.Pool
+	redis  *redis.Client
+
+	handler http.Handler
+	workers []func(context.Context)
+}
+
+// New подключается к Postgres и Redis, применяет миграции и собирает приложение
+func New(ctx context.Context, cfg config.Config, log *zap.Logger) (*App, error) {
+	db, err := database.Open(cfg.Postgres)
+	if err != nil {
+		return nil, fmt.Errorf("database connection failed: %w", err)
+	}
+	log.Info("Successfully connected to database")
+
+	rdb, err := pkgredis.Open(cfg.Redis)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("redis connection failed: %w", err)
+	}
+	log.Info("Successfully connected to redis")
+
+	if err := utils.Migrate(ctx, db); err != nil {
+		db.Close()
+		rdb.Close()
+		return nil, err
+	}
+
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		db.Close()
+		rdb.Close()
+		return nil, err
+	}
+
+	return build(cfg, log, db, rdb, mail), nil
+}
+
+// build связывает зависимости. Соединения не используются до первого запроса
+func build(cfg config.Config, log *zap.Logger, db *pgxpool.Pool, rdb *redis.Client, mail mailer.Mailer) *App {
+	pvzRepo := repository.NewPVZRepository(db)
+	receptionRepo := repository.NewReceptionRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	tokenCache := cache.NewTokenCache(rdb)
+	jwt := utils.NewJWT(cfg.Auth.JWTSecret, cfg.Auth.TokenTTL)
+	limiter := ratelimit.NewSlidingWindow(rdb)
+	limits := cfg.RateLimit
+
+	sinks := outbox.MultiSink{
+		outbox.NewRedisStreamSink(rdb, outbox.EventsStream),
+		outbox.BestEffort("pubsub", outbox.NewRedisPubSubSink(rdb)),
+		outbox.BestEffort("dashboard", dashboard.NewSink(rdb, pvzRepo)),
+		webhook.NewSink(webhookRepo),
+	}
+
+	// Список ПВЗ читается через кэш, если он включен
+	var pvzStore handlers.PVZStore = pvzRepo
+	if cfg.PVZCache.TTL > 0 {
+		listCache := cache.NewPVZListCache(rdb, cfg.PVZCache)
+		pvzStore = pvzcache.NewRepository(pvzRepo, listCache)
+		sinks = append(sinks, pvzcache.NewSink(listCache, pvzRepo))
+	}
+
+	relay := outbox.NewRelay(
+		repository.NewOutboxRepository(db),
+		sinks,
+		outbox.DefaultConfig(),
+	).WithDeadLetter(outbox.NewRedisStreamSink(rdb, outbox.DeadLetterStream))
+
+	dispatcher := webhook.NewDispatcher(webhookRepo, cfg.Webhook)
+	// Письма уходят в фоне, чтобы время ответа не выдавало, есть ли адрес
+	mailQueue := mailer.NewQueue(mail, mailer.DefaultQueueSize)
+
+	cities := slices.Sorted(maps.Keys(models.AllowedCities))
+	openReceptions := metrics.NewOpenReceptionsRefresher(receptionRepo, cities, openReceptionsInterval)
+
+	mailConfig := service.MailConfig{
+		VerifyURL:       cfg.Auth.VerifyURL,
+		ResetURL:        cfg.Auth.ResetURL,
+		VerificationTTL: cfg.Auth.VerificationTTL,
+		ResetTTL:        cfg.Auth.ResetTTL,
+	}
+
+	h := handlers.New(handlers.Deps{
+		PVZ:           pvzStore,
+		Receptions:    receptionRepo,
+		Products:      repository.NewProductRepository(db),
+		Users:         repository.NewUserRepository(db),
+		Webhooks:      webhookRepo,
+		Analytics:     repository.NewAnalyticsRepository(db),
+		Export:        repository.NewExportRepository(db),
+		Tokens:        tokenCache,
+		JWT:           jwt,
+		LoginGuard:    ratelimit.NewLockout(rdb, limits.Lockout),
+		Mailer:        mailQueue,
+		Mail:          mailConfig,
+		WebhookTester: dispatcher,
+		WebhookConfig: cfg.Webhook,
+		PubSub:        rdb,
+		Readiness: []handlers.DependencyCheck{
+			handlers.PostgresCheck(db),
+			handlers.RedisCheck(rdb),
+		},
+		MigrationVersion: func(ctx context.Context) (int, error) {
+			return utils.MigrationVersion(ctx, db)
+		},
+	})
+
+	mux := routes.New(h, routes.Middlewares{
+		Auth: middleware.AuthMiddleware(jwt, tokenCache),
+		LoginLimit: middleware.RateLimit(limiter,
+			middleware.RateLimitPolicy{Name: metrics.RateLimitLoginIP, Limit: limits.LoginPerIP, Window: limits.Window, Key: middleware.ClientIP},
+			middleware.RateLimitPolicy{Name: metrics.RateLimitLoginEmail, Limit: limits.LoginPerEmail, Window: limits.Window, Key: middleware.EmailFromBody},
+		),
+		RegisterLimit: middleware.RateLimit(limiter,
+			middleware.RateLimitPolicy{Name: metrics.RateLimitRegisterIP, Limit: limits.RegisterPerIP, Window: limits.Window, Key: middleware.ClientIP},
+		),
+		PasswordResetLimit: middleware.RateLimit(limiter,
+			middleware.RateLimitPolicy{Name: metrics.RateLimitResetIP, Limit: limits.PasswordResetPerIP, Window: limits.Window, Key: middleware.ClientIP},
+			middleware.RateLimitPolicy{Name: metrics.RateLimitResetEmail, Limit: limits.PasswordResetPerEmail, Window: limits.Window, Key: middleware.EmailFromBody},
+		),
+		Idempotency: middleware.Idempotency(idempotency.NewStore(rdb, cfg.Idempotency), cfg.Idempotency),
+		Quota: middleware.Quota(
+			ratelimit.NewFallback(ratelimit.NewTokenBucket(rdb), ratelimit.NewLocalBucket()),
+			limits.Quota,
+		),
+	})
+
+	return &App{
+		config: cfg,
+		log:    log,
+		db:     db,
+		redis:  rdb,
+		// Tracing снаружи, чтобы RequestID уже видел спан запроса
+		handler: middleware.Tracing(mux)(middleware.RequestID(log)(mux)),
+		workers: []func(context.Context){relay.Run, dispatcher.Run, openReceptions.Run, mailQueue.Run},
+	}
+}
+
+// Handler возвращает корневой HTTP хендлер приложения
+func (a *App) Handler() http.Handler {
+	return a.handler
+}
+
+// Run запускает воркеры и сервер до отмены ctx. После остановки сервера
+// дожидается воркеров, соединения при этом остаются открытыми до Close
+func (a *App) Run(ctx context.Context) error {
+	ctx = logger.WithContext(ctx, a.log)
+
+	// Воркеры останавливаются отдельно от сервера: сначала дожидаемся запросов, потом воркеров
+	workersCtx, stopWorkers := context.WithCancel(logger.WithContext(context.Background(), a.log))
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+	for _, run := range a.workers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
+	}
+
+	err := server.New(a.handler, a.config.HTTP).Run(ctx)
+
+	stopWorkers()
+	workers.Wait()
+	a.log.Info("Background workers stopped")
+
+	return err
+}
+
+// Close закрывает пул и Redis. Вызывается после Run, воркеры до конца пишут в них
+func (a *App) Close() error {
+	a.db.Close()
+	if err := a.redis.Close(); err != nil {
+		return fmt.Errorf("failed to close redis client: %w", err)
+	}
+	a.log.Info("Connections closed")
+	return nil
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/idempotency"
+	"github.com/kosttiik/pvz-service/internal/ratelimit"
+	"github.com/kosttiik/pvz-service/internal/server"
+	"github.com/kosttiik/pvz-service/internal/webhook"
+	"github.com/kosttiik/pvz-service/pkg/cache"
+	"github.com/kosttiik/pvz-service/pkg/database"
+	"github.com/kosttiik/pvz-service/pkg/mailer"
+	"github.com/kosttiik/pvz-service/pkg/redis"
+	"github.com/kosttiik/pvz-service/pkg/tracing"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// Config - вся конфигурация сервиса. Значения применяются по порядку:
+// значения по умолчанию, YAML файл, переменные окружения, флаги командной строки
+type Config struct {
+	HTTP     server.Config   `yaml:"http"`
+	Postgres database.Config `yaml:"postgres"`
+	Redis    redis.Config    `yaml:"redis"`
+	Auth     AuthConfig      `yaml:"auth"`
+	Log      LogConfig       `yaml:"log"`
+	Tracing  TracingConfig   `yaml:"tracing"`
+	Webhook  webhook.Config  `yaml:"webhook"`
+	Mail     mailer.Config   `yaml:"mail"`
+
+	RateLimit   ratelimit.Config    `yaml:"rateLimit"`
+	Idempotency idempotency.Config  `yaml:"idempotency"`
+	PVZCache    cache.PVZListConfig `yaml:"pvzCache"`
+}
+
+type AuthConfig struct {
+	JWTSecret string        `yaml:"jwtSecret"`
+	TokenTTL  time.Duration `yaml:"tokenTTL"`
+
+	// VerifyURL и ResetURL - страницы для ссылок из писем, токен добавляется параметром token.
+	// Пустой URL - в письмо попадает только токен
+	VerifyURL string `yaml:"verifyUrl"`
+	ResetURL  string `yaml:"resetUrl"`
+	// VerificationTTL и ResetTTL - сколько действуют ссылки из писем
+	VerificationTTL time.Duration `yaml:"verificationTTL"`
+	ResetTTL        time.Duration `yaml:"resetTTL"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"`
+}
+
+type TracingConfig struct {
+	// Exporter - otlp, stdout или none
+	Exporter string `yaml:"exporter"`
+}
+
+func Default() Config {
+	return Config{
+		HTTP:     server.DefaultConfig(),
+		Postgres: database.DefaultConfig(),
+		Redis:    redis.DefaultConfig(),
+		Auth: AuthConfig{
+			TokenTTL:        24 * time.Hour,
+			VerificationTTL: 48 * time.Hour,
+			ResetTTL:        time.Hour,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Tracing: TracingConfig{
+			Exporter: tracing.ExporterNone,
+		},
+		Webhook:     webhook.DefaultConfig(),
+		Mail:        mailer.DefaultConfig(),
+		RateLimit:   ratelimit.DefaultConfig(),
+		Idempotency: idempotency.DefaultConfig(),
+		PVZCache:    cache.DefaultPVZListConfig(),
+	}
+}
+
+// Load собирает конфигурацию из всех источников и проверяет ее.
+// args - аргументы командной строки без имени программы
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	fs, path := newFlagSet(&cfg)
+	// Флаги разбираем дважды: сначала чтобы узнать путь к файлу, потом поверх файла и окружения
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if *path == "" {
+		*path = os.Getenv("CONFIG_FILE")
+	}
+
+	cfg = Default()
+	if *path != "" {
+		if err := cfg.loadFile(*path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	fs, _ = newFlagSet(&cfg)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate возвращает все ошибки конфигурации сразу, а не первую
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr is required")
+	check(c.HTTP.ReadTimeout > 0, "http.readTimeout must be positive")
+	check(c.HTTP.ReadHeaderTimeout > 0, "http.readHeaderTimeout must be positive")
+	check(c.HTTP.WriteTimeout > 0, "http.writeTimeout must be positive")
+	check(c.HTTP.IdleTimeout > 0, "http.idleTimeout must be positive")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdownTimeout must be positive")
+
+	check(c.Postgres.Host != "", "postgres.host is required")
+	check(validPort(c.Postgres.Port), "postgres.port must be between 1 and 65535, got %d", c.Postgres.Port)
+	check(c.Postgres.User != "", "postgres.user is required")
+	check(c.Postgres.Name != "", "postgres.name is required")
+	check(slices.Contains([]string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}, c.Postgres.SSLMode),
+		"postgres.sslMode %q is not supported", c.Postgres.SSLMode)
+	check(c.Postgres.MaxConns >= 0, "postgres.maxConns must not be negative")
+
+	check(c.Redis.Host != "", "redis.host is required")
+	check(validPort(c.Redis.Port), "redis.port must be between 1 and 65535, got %d", c.Redis.Port)
+	check(c.Redis.DB >= 0, "redis.db must not be negative")
+
+	check(c.Auth.JWTSecret != "", "auth.jwtSecret is required (JWT_SECRET)")
+	check(c.Auth.TokenTTL > 0, "auth.tokenTTL must be positive")
+	check(c.Auth.VerificationTTL > 0, "auth.verificationTTL must be positive")
+	check(c.Auth.ResetTTL > 0, "auth.resetTTL must be positive")
+	check(validLinkURL(c.Auth.VerifyURL), "auth.verifyUrl %q must be an absolute http(s) URL", c.Auth.VerifyURL)
+	check(validLinkURL(c.Auth.ResetURL), "auth.resetUrl %q must be an absolute http(s) URL", c.Auth.ResetURL)
+
+	_, err := zapcore.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level %q is not a valid level", c.Log.Level)
+
+	check(slices.Contains([]string{tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout}, c.Tracing.Exporter),
+		"tracing.exporter %q is not supported, use otlp, stdout or none", c.Tracing.Exporter)
+
+	check(c.Webhook.PollInterval > 0, "webhook.pollInterval must be positive")
+	check(c.Webhook.BatchSize > 0, "webhook.batchSize must be positive")
+	check(c.Webhook.MaxAttempts > 0, "webhook.maxAttempts must be positive")
+	check(c.Webhook.Timeout > 0, "webhook.timeout must be positive")
+
+	check(slices.Contains([]string{mailer.TransportSMTP, mailer.TransportFile, mailer.TransportLog}, c.Mail.Transport),
+		"mail.transport %q is not supported, use smtp, file or log", c.Mail.Transport)
+	_, err = mail.ParseAddress(c.Mail.From)
+	check(err == nil, "mail.from %q is not a valid address", c.Mail.From)
+	if c.Mail.Transport == mailer.TransportSMTP {
+		check(c.Mail.SMTP.Host != "", "mail.smtp.host is required for smtp transport")
+		check(validPort(c.Mail.SMTP.Port), "mail.smtp.port must be between 1 and 65535, got %d", c.Mail.SMTP.Port)
+		check(c.Mail.SMTP.Timeout > 0, "mail.smtp.timeout must be positive")
+	}
+	check(c.Mail.Transport != mailer.TransportFile || c.Mail.File != "", "mail.file is required for file transport")
+
+	check(c.RateLimit.Window > 0, "rateLimit.window must be positive")
+	check(c.RateLimit.LoginPerIP >= 0 && c.RateLimit.LoginPerEmail >= 0 && c.RateLimit.RegisterPerIP >= 0 &&
+		c.RateLimit.PasswordResetPerIP >= 0 && c.RateLimit.PasswordResetPerEmail >= 0,
+		"rateLimit limits must not be negative, 0 disables a limit")
+	if c.RateLimit.Lockout.Threshold > 0 {
+		check(c.RateLimit.Lockout.FailureWindow > 0, "rateLimit.lockout.failureWindow must be positive")
+		check(c.RateLimit.Lockout.BaseDelay > 0, "rateLimit.lockout.baseDelay must be positive")
+		check(c.RateLimit.Lockout.MaxDelay >= c.RateLimit.Lockout.BaseDelay, "rateLimit.lockout.maxDelay must not be less than baseDelay")
+	}
+	for _, role := range []string{"employee", "moderator"} {
+		bucket := c.RateLimit.Quota.For(role)
+		check(bucket.PerMinute >= 0, "rateLimit.quota.%s.perMinute must not be negative, 0 disables the quota", role)
+		check(!bucket.Enabled() || bucket.Burst > 0, "rateLimit.quota.%s.burst must be positive", role)
+	}
+
+	check(c.Idempotency.TTL > 0, "idempotency.ttl must be positive")
+	check(c.Idempotency.LockTimeout > 0, "idempotency.lockTimeout must be positive")
+	check(c.Idempotency.WaitTimeout >= 0, "idempotency.waitTimeout must not be negative")
+
+	check(c.PVZCache.TTL >= 0, "pvzCache.ttl must not be negative, 0 disables the cache")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// validLinkURL разрешает пустой URL: тогда в письма попадает только токен
+func validLinkURL(link string) bool {
+	if link == "" {
+		return true
+	}
+	u, err := url.Parse(link)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
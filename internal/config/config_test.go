@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+http:
+  addr: ":9000"
+  writeTimeout: 1m
+postgres:
+  host: file-db
+  port: 6543
+auth:
+  jwtSecret: from-file
+log:
+  level: warn
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("DB_HOST", "env-db")
+	t.Setenv("JWT_SECRET", "from-env")
+	t.Setenv("LOG_LEVEL", "")
+
+	cfg, err := Load([]string{"-config", path, "-db-host", "flag-db", "-http-addr", ":7000"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.HTTP.Addr != ":7000" {
+		t.Errorf("flag should override file, got addr %q", cfg.HTTP.Addr)
+	}
+	if cfg.HTTP.WriteTimeout != time.Minute {
+		t.Errorf("expected writeTimeout from file, got %v", cfg.HTTP.WriteTimeout)
+	}
+	if cfg.HTTP.ReadTimeout != Default().HTTP.ReadTimeout {
+		t.Errorf("expected default readTimeout, got %v", cfg.HTTP.ReadTimeout)
+	}
+	if cfg.Postgres.Host != "flag-db" {
+		t.Errorf("flag should override env, got host %q", cfg.Postgres.Host)
+	}
+	if cfg.Postgres.Port != 6543 {
+		t.Errorf("expected port from file, got %d", cfg.Postgres.Port)
+	}
+	if cfg.Auth.JWTSecret != "from-env" {
+		t.Errorf("env should override file, got secret %q", cfg.Auth.JWTSecret)
+	}
+	if cfg.Log.Level != "warn" {
+		t.Errorf("empty env should not override file, got level %q", cfg.Log.Level)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr []string
+	}{
+		{
+			name:    "Missing JWT secret",
+			env:     map[string]string{"JWT_SECRET": ""},
+			wantErr: []string{"auth.jwtSecret is required"},
+		},
+		{
+			name:    "Malformed env values",
+			env:     map[string]string{"JWT_SECRET": "secret", "DB_PORT": "abc", "HTTP_WRITE_TIMEOUT": "soon"},
+			wantErr: []string{"DB_PORT", "HTTP_WRITE_TIMEOUT"},
+		},
+		{
+			name:    "All errors reported at once",
+			env:     map[string]string{"JWT_SECRET": "", "LOG_LEVEL": "loud", "OTEL_TRACES_EXPORTER": "zipkin"},
+			args:    []string{"-redis-port", "70000"},
+			wantErr: []string{"auth.jwtSecret", "log.level", "tracing.exporter", "redis.port"},
+		},
+		{
+			name:    "Quota without burst",
+			env:     map[string]string{"JWT_SECRET": "secret", "QUOTA_EMPLOYEE_BURST": "0"},
+			wantErr: []string{"rateLimit.quota.employee.burst"},
+		},
+		{
+			name: "SMTP without host and relative link",
+			env: map[string]string{
+				"JWT_SECRET":      "secret",
+				"MAIL_TRANSPORT":  "smtp",
+				"AUTH_RESET_URL":  "/reset",
+				"AUTH_VERIFY_URL": "https://pvz.example/verify",
+			},
+			wantErr: []string{"mail.smtp.host", "auth.resetUrl"},
+		},
+		{
+			name:    "Unknown flag",
+			env:     map[string]string{"JWT_SECRET": "secret"},
+			args:    []string{"-unknown"},
+			wantErr: []string{"unknown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := Load(tt.args)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to mention %q, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.HTTP.Addr != ":8080" || cfg.Postgres.Port != 5432 || cfg.Redis.Port != 6379 {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestExampleConfigIsValid(t *testing.T) {
+	cfg := Default()
+	if err := cfg.loadFile("../../config/config.example.yaml"); err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("example config is invalid: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// applyEnv переопределяет значения из переменных окружения. Пустая переменная игнорируется
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	e := envReader{lookup: lookup}
+
+	e.string("HTTP_ADDR", &c.HTTP.Addr)
+	e.duration("HTTP_READ_TIMEOUT", &c.HTTP.ReadTimeout)
+	e.duration("HTTP_READ_HEADER_TIMEOUT", &c.HTTP.ReadHeaderTimeout)
+	e.duration("HTTP_WRITE_TIMEOUT", &c.HTTP.WriteTimeout)
+	e.duration("HTTP_IDLE_TIMEOUT", &c.HTTP.IdleTimeout)
+	e.duration("HTTP_SHUTDOWN_TIMEOUT", &c.HTTP.ShutdownTimeout)
+
+	e.string("DB_HOST", &c.Postgres.Host)
+	e.int("DB_PORT", &c.Postgres.Port)
+	e.string("DB_USER", &c.Postgres.User)
+	e.string("DB_PASSWORD", &c.Postgres.Password)
+	e.string("DB_NAME", &c.Postgres.Name)
+	e.string("DB_SSLMODE", &c.Postgres.SSLMode)
+	e.int32("DB_MAX_CONNS", &c.Postgres.MaxConns)
+
+	e.string("REDIS_HOST", &c.Redis.Host)
+	e.int("REDIS_PORT", &c.Redis.Port)
+	e.string("REDIS_PASSWORD", &c.Redis.Password)
+	e.int("REDIS_DB", &c.Redis.DB)
+
+	e.string("JWT_SECRET", &c.Auth.JWTSecret)
+	e.duration("JWT_TTL", &c.Auth.TokenTTL)
+	e.string("AUTH_VERIFY_URL", &c.Auth.VerifyURL)
+	e.string("AUTH_RESET_URL", &c.Auth.ResetURL)
+
+	e.string("MAIL_TRANSPORT", &c.Mail.Transport)
+	e.string("MAIL_FROM", &c.Mail.From)
+	e.string("MAIL_FILE", &c.Mail.File)
+	e.string("SMTP_HOST", &c.Mail.SMTP.Host)
+	e.int("SMTP_PORT", &c.Mail.SMTP.Port)
+	e.string("SMTP_USERNAME", &c.Mail.SMTP.Username)
+	e.string("SMTP_PASSWORD", &c.Mail.SMTP.Password)
+
+	e.string("LOG_LEVEL", &c.Log.Level)
+
+	e.string("OTEL_TRACES_EXPORTER", &c.Tracing.Exporter)
+
+	e.bool("WEBHOOK_TEST_MODE", &c.Webhook.TestMode)
+
+	e.int("RATE_LIMIT_LOGIN_PER_IP", &c.RateLimit.LoginPerIP)
+	e.int("RATE_LIMIT_LOGIN_PER_EMAIL", &c.RateLimit.LoginPerEmail)
+	e.int("RATE_LIMIT_REGISTER_PER_IP", &c.RateLimit.RegisterPerIP)
+	e.int("RATE_LIMIT_PASSWORD_RESET_PER_IP", &c.RateLimit.PasswordResetPerIP)
+	e.int("RATE_LIMIT_PASSWORD_RESET_PER_EMAIL", &c.RateLimit.PasswordResetPerEmail)
+	e.int("LOGIN_LOCKOUT_THRESHOLD", &c.RateLimit.Lockout.Threshold)
+	e.int("QUOTA_EMPLOYEE_PER_MINUTE", &c.RateLimit.Quota.Employee.PerMinute)
+	e.int("QUOTA_EMPLOYEE_BURST", &c.RateLimit.Quota.Employee.Burst)
+	e.int("QUOTA_MODERATOR_PER_MINUTE", &c.RateLimit.Quota.Moderator.PerMinute)
+	e.int("QUOTA_MODERATOR_BURST", &c.RateLimit.Quota.Moderator.Burst)
+
+	e.duration("IDEMPOTENCY_TTL", &c.Idempotency.TTL)
+	e.duration("PVZ_CACHE_TTL", &c.PVZCache.TTL)
+
+	return errors.Join(e.errs...)
+}
+
+type envReader struct {
+	lookup func(string) (string, bool)
+	errs   []error
+}
+
+func (e *envReader) get(key string) (string, bool) {
+	value, ok := e.lookup(key)
+	return value, ok && value != ""
+}
+
+func (e *envReader) fail(key, value string, err error) {
+	e.errs = append(e.errs, fmt.Errorf("invalid %s=%q: %w", key, value, err))
+}
+
+func (e *envReader) string(key string, dst *string) {
+	if value, ok := e.get(key); ok {
+		*dst = value
+	}
+}
+
+func (e *envReader) int(key string, dst *int) {
+	if value, ok := e.get(key); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			e.fail(key, value, err)
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envReader) int32(key string, dst *int32) {
+	if value, ok := e.get(key); ok {
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			e.fail(key, value, err)
+			return
+		}
+		*dst = int32(n)
+	}
+}
+
+func (e *envReader) duration(key string, dst *time.Duration) {
+	if value, ok := e.get(key); ok {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			e.fail(key, value, err)
+			return
+		}
+		*dst = d
+	}
+}
+
+func (e *envReader) bool(key string, dst *bool) {
+	if value, ok := e.get(key); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			e.fail(key, value, err)
+			return
+		}
+		*dst = b
+	}
+}
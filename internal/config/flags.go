@@ -0,0 +1,23 @@
+package config
+
+import "flag"
+
+// newFlagSet привязывает флаги к полям cfg, текущие значения становятся значениями по умолчанию,
+// поэтому флаг перезаписывает поле, только если его передали явно
+func newFlagSet(cfg *Config) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("pvz-service", flag.ContinueOnError)
+
+	path := fs.String("config", "", "path to YAML config file (or CONFIG_FILE)")
+
+	fs.StringVar(&cfg.HTTP.Addr, "http-addr", cfg.HTTP.Addr, "HTTP listen address")
+	fs.DurationVar(&cfg.HTTP.ShutdownTimeout, "shutdown-timeout", cfg.HTTP.ShutdownTimeout, "graceful shutdown timeout")
+	fs.StringVar(&cfg.Postgres.Host, "db-host", cfg.Postgres.Host, "Postgres host")
+	fs.IntVar(&cfg.Postgres.Port, "db-port", cfg.Postgres.Port, "Postgres port")
+	fs.StringVar(&cfg.Redis.Host, "redis-host", cfg.Redis.Host, "Redis host")
+	fs.IntVar(&cfg.Redis.Port, "redis-port", cfg.Redis.Port, "Redis port")
+	fs.StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, "log level: debug, info, warn, error")
+	fs.StringVar(&cfg.Tracing.Exporter, "tracing-exporter", cfg.Tracing.Exporter, "traces exporter: otlp, stdout, none")
+	fs.BoolVar(&cfg.Webhook.TestMode, "webhook-test-mode", cfg.Webhook.TestMode, "allow http and local webhook URLs")
+
+	return fs, path
+}
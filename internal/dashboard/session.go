@@ -0,0 +1,159 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	writeTimeout   = 10 * time.Second
+	pongTimeout    = 60 * time.Second
+	pingInterval   = 30 * time.Second
+	resyncInterval = time.Second
+	sendBufferSize = 32
+	maxMessageSize = 512
+)
+
+// Session обслуживает одно WebSocket соединение дашборда.
+// Если клиент не успевает читать и буфер переполнен, дельты отбрасываются,
+// а как только буфер освободится, клиенту уходит свежий снапшот
+type Session struct {
+	conn  *websocket.Conn
+	sub   *redis.PubSub
+	state *State
+	send  chan any
+
+	lagging bool
+}
+
+func NewSession(conn *websocket.Conn, sub *redis.PubSub, state *State) *Session {
+	return &Session{
+		conn:  conn,
+		sub:   sub,
+		state: state,
+		send:  make(chan any, sendBufferSize),
+	}
+}
+
+// Run блокируется до закрытия соединения или отмены контекста
+func (s *Session) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	metrics.WSConnections.Inc()
+	defer metrics.WSConnections.Dec()
+
+	s.send <- s.state.Snapshot()
+
+	go s.readLoop(cancel)
+	go s.writeLoop(ctx, cancel)
+
+	resync := time.NewTicker(resyncInterval)
+	defer resync.Stop()
+
+	messages := s.sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-resync.C:
+			if s.lagging {
+				s.trySendSnapshot()
+			}
+
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var event models.Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Warn("Failed to decode city event", zap.Error(err))
+				continue
+			}
+
+			delta, changed := s.state.Apply(event)
+			if !changed {
+				continue
+			}
+
+			// Пока клиент отстает, дельты бессмысленны - он все равно получит снапшот
+			if s.lagging {
+				s.trySendSnapshot()
+				continue
+			}
+
+			select {
+			case s.send <- delta:
+			default:
+				s.lagging = true
+				metrics.WSDroppedMessagesTotal.Inc()
+				log.Warn("Dashboard client is too slow, switching to snapshot resync")
+			}
+		}
+	}
+}
+
+func (s *Session) trySendSnapshot() {
+	select {
+	case s.send <- s.state.Snapshot():
+		s.lagging = false
+		metrics.WSResyncsTotal.Inc()
+	default:
+	}
+}
+
+// readLoop нужен для обработки pong и close фреймов, данные от клиента не ожидаются
+func (s *Session) readLoop(cancel context.CancelFunc) {
+	defer cancel()
+
+	s.conn.SetReadLimit(maxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	for {
+		if _, _, err := s.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Session) writeLoop(ctx context.Context, cancel context.CancelFunc) {
+	defer cancel()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+				time.Now().Add(writeTimeout))
+			return
+
+		case msg := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := s.conn.WriteJSON(msg); err != nil {
+				return
+			}
+
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
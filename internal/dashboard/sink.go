@@ -0,0 +1,76 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+const cityChannelPrefix = "city:live:"
+
+// CityChannel - канал Redis pub/sub с событиями всех ПВЗ города
+func CityChannel(city string) string {
+	return cityChannelPrefix + city
+}
+
+// Sink рассылает события из outbox в канал города, к которому относится ПВЗ
+type Sink struct {
+	redis   *redis.Client
+	pvzRepo *repository.PVZRepository
+	// Город ПВЗ не меняется, поэтому кэшируем его без инвалидации
+	cities sync.Map
+}
+
+func NewSink(redisClient *redis.Client, pvzRepo *repository.PVZRepository) *Sink {
+	if redisClient == nil {
+		panic("redis client cannot be nil")
+	}
+	return &Sink{redis: redisClient, pvzRepo: pvzRepo}
+}
+
+func (s *Sink) Publish(ctx context.Context, event models.Event) error {
+	city, err := s.cityOf(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err := s.redis.Publish(ctx, CityChannel(city), data).Err(); err != nil {
+		return fmt.Errorf("failed to publish event to city channel: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Sink) cityOf(ctx context.Context, event models.Event) (string, error) {
+	if city, ok := s.cities.Load(event.PvzID); ok {
+		return city.(string), nil
+	}
+
+	var city string
+	if event.Type == models.EventPVZCreated {
+		var pvz models.PVZ
+		if err := json.Unmarshal(event.Payload, &pvz); err != nil {
+			return "", fmt.Errorf("failed to decode pvz payload: %w", err)
+		}
+		city = pvz.City
+	} else {
+		pvz, err := s.pvzRepo.GetByID(ctx, event.PvzID)
+		if err != nil {
+			return "", err
+		}
+		city = pvz.City
+	}
+
+	s.cities.Store(event.PvzID, city)
+	return city, nil
+}
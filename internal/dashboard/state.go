@@ -0,0 +1,160 @@
+package dashboard
+
+import (
+	"encoding/json"
+
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+)
+
+// Типы сообщений дашборда
+const (
+	MessageSnapshot = "snapshot"
+	MessageDelta    = "delta"
+)
+
+type Totals struct {
+	PVZCount       int `json:"pvzCount"`
+	OpenReceptions int `json:"openReceptions"`
+	ProductCount   int `json:"productCount"`
+}
+
+// Полное состояние города, отправляется при подключении и после пропуска дельт
+type SnapshotMessage struct {
+	Type   string                   `json:"type"`
+	City   string                   `json:"city"`
+	PVZ    []repository.PVZActivity `json:"pvz"`
+	Totals Totals                   `json:"totals"`
+}
+
+// Изменение одного ПВЗ
+type DeltaMessage struct {
+	Type   string                 `json:"type"`
+	City   string                 `json:"city"`
+	Event  models.EventType       `json:"event"`
+	PVZ    repository.PVZActivity `json:"pvz"`
+	Totals Totals                 `json:"totals"`
+}
+
+// State - состояние ПВЗ города, которое соединение поддерживает поверх снапшота.
+// Не потокобезопасен, принадлежит одной сессии
+type State struct {
+	city  string
+	order []string
+	pvz   map[string]*repository.PVZActivity
+}
+
+func NewState(city string, activity []repository.PVZActivity) *State {
+	s := &State{
+		city: city,
+		pvz:  make(map[string]*repository.PVZActivity, len(activity)),
+	}
+	for i := range activity {
+		a := activity[i]
+		id := a.PVZ.ID.String()
+		s.order = append(s.order, id)
+		s.pvz[id] = &a
+	}
+	return s
+}
+
+// Apply применяет событие и возвращает дельту, если состояние изменилось.
+// Relay доставляет события хотя бы один раз, а подписка открывается до снапшота, поэтому
+// события приемок сверяются с версией ПВЗ, события товаров - с версией приемки:
+// повторы и уже учтенные в снапшоте изменения пропускаются
+func (s *State) Apply(event models.Event) (*DeltaMessage, bool) {
+	if event.Type == models.EventPVZCreated {
+		var pvz models.PVZ
+		if err := json.Unmarshal(event.Payload, &pvz); err != nil || pvz.City != s.city {
+			return nil, false
+		}
+		if _, exists := s.pvz[event.PvzID]; exists {
+			return nil, false
+		}
+		s.order = append(s.order, event.PvzID)
+		s.pvz[event.PvzID] = &repository.PVZActivity{PVZ: pvz}
+		return s.delta(event), true
+	}
+
+	a, ok := s.pvz[event.PvzID]
+	if !ok {
+		return nil, false
+	}
+
+	switch event.Type {
+	case models.EventReceptionCreated, models.EventReceptionClosed:
+		var payload models.ReceptionEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil || payload.PVZVersion <= a.PVZ.Version {
+			return nil, false
+		}
+		a.PVZ.Version = payload.PVZVersion
+		a.ProductCount = 0
+		if event.Type == models.EventReceptionCreated {
+			a.OpenReception = &payload.Reception
+		} else {
+			a.OpenReception = nil
+		}
+
+	case models.EventProductAdded, models.EventProductDeleted:
+		var payload models.ProductEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, false
+		}
+		reception := a.OpenReception
+		if reception == nil || reception.ID.String() != payload.ReceptionID || payload.ReceptionVersion <= reception.Version {
+			return nil, false
+		}
+		reception.Version = payload.ReceptionVersion
+		a.ProductCount = payload.ProductCount
+
+	default:
+		return nil, false
+	}
+
+	return s.delta(event), true
+}
+
+func (s *State) Snapshot() SnapshotMessage {
+	pvz := make([]repository.PVZActivity, 0, len(s.order))
+	for _, id := range s.order {
+		pvz = append(pvz, s.copyOf(id))
+	}
+
+	return SnapshotMessage{
+		Type:   MessageSnapshot,
+		City:   s.city,
+		PVZ:    pvz,
+		Totals: s.totals(),
+	}
+}
+
+func (s *State) delta(event models.Event) *DeltaMessage {
+	return &DeltaMessage{
+		Type:   MessageDelta,
+		City:   s.city,
+		Event:  event.Type,
+		PVZ:    s.copyOf(event.PvzID),
+		Totals: s.totals(),
+	}
+}
+
+// copyOf возвращает копию, чтобы отправляемое сообщение не менялось вместе с состоянием
+func (s *State) copyOf(id string) repository.PVZActivity {
+	a := *s.pvz[id]
+	if a.OpenReception != nil {
+		reception := *a.OpenReception
+		a.OpenReception = &reception
+	}
+	return a
+}
+
+func (s *State) totals() Totals {
+	totals := Totals{PVZCount: len(s.pvz)}
+	for _, a := range s.pvz {
+		if a.OpenReception != nil {
+			totals.OpenReceptions++
+			totals.ProductCount += a.ProductCount
+		}
+	}
+	return totals
+}
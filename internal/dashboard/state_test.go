@@ -0,0 +1,195 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+)
+
+func newEvent(t *testing.T, eventType models.EventType, pvzID string, payload any) models.Event {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Failed to marshal payload: %v", err)
+	}
+	return models.Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		PvzID:     pvzID,
+		Payload:   data,
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestStateApply(t *testing.T) {
+	pvz := models.PVZ{ID: uuid.New(), City: "Москва", RegistrationDate: time.Now(), Version: 1}
+	pvzID := pvz.ID.String()
+	state := NewState("Москва", []repository.PVZActivity{{PVZ: pvz}})
+
+	reception := models.Reception{
+		ID:      uuid.New(),
+		PvzID:   pvzID,
+		Status:  models.StatusInProgress,
+		Version: 1,
+	}
+	opened := models.ReceptionEvent{Reception: reception, PVZVersion: 2}
+	closed := models.ReceptionEvent{Reception: reception, PVZVersion: 3}
+	product := models.Product{ID: uuid.New(), Type: "обувь", ReceptionID: reception.ID.String()}
+	productEvent := func(receptionVersion, count int) models.ProductEvent {
+		return models.ProductEvent{Product: product, ReceptionVersion: receptionVersion, ProductCount: count}
+	}
+
+	steps := []struct {
+		name         string
+		event        models.Event
+		wantChanged  bool
+		wantOpen     bool
+		wantProducts int
+	}{
+		{
+			name:        "Product without open reception is ignored",
+			event:       newEvent(t, models.EventProductAdded, pvzID, productEvent(2, 1)),
+			wantChanged: false,
+		},
+		{
+			name:         "Reception opened",
+			event:        newEvent(t, models.EventReceptionCreated, pvzID, opened),
+			wantChanged:  true,
+			wantOpen:     true,
+			wantProducts: 0,
+		},
+		{
+			name:        "Repeated reception opening is ignored",
+			event:       newEvent(t, models.EventReceptionCreated, pvzID, opened),
+			wantChanged: false,
+		},
+		{
+			name:         "Product added",
+			event:        newEvent(t, models.EventProductAdded, pvzID, productEvent(2, 1)),
+			wantChanged:  true,
+			wantOpen:     true,
+			wantProducts: 1,
+		},
+		{
+			name:        "Repeated product event is ignored",
+			event:       newEvent(t, models.EventProductAdded, pvzID, productEvent(2, 1)),
+			wantChanged: false,
+		},
+		{
+			name:         "Second product added",
+			event:        newEvent(t, models.EventProductAdded, pvzID, productEvent(3, 2)),
+			wantChanged:  true,
+			wantOpen:     true,
+			wantProducts: 2,
+		},
+		{
+			name:         "Product deleted",
+			event:        newEvent(t, models.EventProductDeleted, pvzID, productEvent(4, 1)),
+			wantChanged:  true,
+			wantOpen:     true,
+			wantProducts: 1,
+		},
+		{
+			name:         "Reception closed",
+			event:        newEvent(t, models.EventReceptionClosed, pvzID, closed),
+			wantChanged:  true,
+			wantOpen:     false,
+			wantProducts: 0,
+		},
+		{
+			name:        "Late reception opening is ignored",
+			event:       newEvent(t, models.EventReceptionCreated, pvzID, opened),
+			wantChanged: false,
+		},
+		{
+			name:        "Unknown PVZ is ignored",
+			event:       newEvent(t, models.EventReceptionCreated, uuid.New().String(), opened),
+			wantChanged: false,
+		},
+	}
+
+	for _, step := range steps {
+		delta, changed := state.Apply(step.event)
+		if changed != step.wantChanged {
+			t.Fatalf("%s: changed = %v, want %v", step.name, changed, step.wantChanged)
+		}
+		if !changed {
+			continue
+		}
+
+		if (delta.PVZ.OpenReception != nil) != step.wantOpen {
+			t.Errorf("%s: open reception = %v, want %v", step.name, delta.PVZ.OpenReception != nil, step.wantOpen)
+		}
+		if delta.PVZ.ProductCount != step.wantProducts {
+			t.Errorf("%s: product count = %d, want %d", step.name, delta.PVZ.ProductCount, step.wantProducts)
+		}
+		if delta.Totals.ProductCount != step.wantProducts {
+			t.Errorf("%s: total products = %d, want %d", step.name, delta.Totals.ProductCount, step.wantProducts)
+		}
+	}
+}
+
+// Подписка открывается до снапшота, поэтому события, уже учтенные в нем, приходят повторно
+func TestStateSkipsEventsInSnapshot(t *testing.T) {
+	pvz := models.PVZ{ID: uuid.New(), City: "Москва", Version: 2}
+	reception := models.Reception{ID: uuid.New(), PvzID: pvz.ID.String(), Status: models.StatusInProgress, Version: 3}
+	state := NewState("Москва", []repository.PVZActivity{{PVZ: pvz, OpenReception: &reception, ProductCount: 2}})
+
+	product := models.Product{ID: uuid.New(), Type: "обувь", ReceptionID: reception.ID.String()}
+	events := []models.Event{
+		newEvent(t, models.EventReceptionCreated, pvz.ID.String(), models.ReceptionEvent{Reception: reception, PVZVersion: 2}),
+		newEvent(t, models.EventProductAdded, pvz.ID.String(), models.ProductEvent{Product: product, ReceptionVersion: 3, ProductCount: 2}),
+	}
+	for _, event := range events {
+		if _, changed := state.Apply(event); changed {
+			t.Errorf("%s already in snapshot should be ignored", event.Type)
+		}
+	}
+
+	next := models.ProductEvent{Product: product, ReceptionVersion: 4, ProductCount: 3}
+	delta, changed := state.Apply(newEvent(t, models.EventProductAdded, pvz.ID.String(), next))
+	if !changed || delta.PVZ.ProductCount != 3 {
+		t.Errorf("newer product event: changed = %v, delta = %+v, want 3 products", changed, delta)
+	}
+}
+
+func TestStatePVZCreated(t *testing.T) {
+	state := NewState("Казань", nil)
+
+	other := models.PVZ{ID: uuid.New(), City: "Москва"}
+	if _, changed := state.Apply(newEvent(t, models.EventPVZCreated, other.ID.String(), other)); changed {
+		t.Error("PVZ from another city should be ignored")
+	}
+
+	pvz := models.PVZ{ID: uuid.New(), City: "Казань"}
+	delta, changed := state.Apply(newEvent(t, models.EventPVZCreated, pvz.ID.String(), pvz))
+	if !changed {
+		t.Fatal("PVZ from the same city should be added")
+	}
+	if delta.Totals.PVZCount != 1 {
+		t.Errorf("PVZ count = %d, want 1", delta.Totals.PVZCount)
+	}
+
+	snapshot := state.Snapshot()
+	if snapshot.Type != MessageSnapshot || len(snapshot.PVZ) != 1 {
+		t.Errorf("Unexpected snapshot: %+v", snapshot)
+	}
+}
+
+func TestSnapshotIsCopy(t *testing.T) {
+	pvz := models.PVZ{ID: uuid.New(), City: "Москва"}
+	state := NewState("Москва", []repository.PVZActivity{{PVZ: pvz}})
+
+	reception := models.Reception{ID: uuid.New(), PvzID: pvz.ID.String()}
+	state.Apply(newEvent(t, models.EventReceptionCreated, pvz.ID.String(), models.ReceptionEvent{Reception: reception, PVZVersion: 1}))
+
+	snapshot := state.Snapshot()
+	state.Apply(newEvent(t, models.EventReceptionClosed, pvz.ID.String(), models.ReceptionEvent{Reception: reception, PVZVersion: 2}))
+
+	if snapshot.PVZ[0].OpenReception == nil {
+		t.Error("Snapshot must not change after state is updated")
+	}
+}
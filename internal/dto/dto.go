@@ -1,16 +1,46 @@
 package dto
 
-type ErrorResponse struct {
-	Message string `json:"message"`
-}
-
 type RegisterRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     string `json:"role"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,password"`
+	Role     string `json:"role" validate:"required,enum=role"`
 }
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required,max=128"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required,max=128"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+type DummyLoginRequest struct {
+	Role string `json:"role" validate:"required,enum=role"`
+}
+
+type TokenResponse struct {
+	Token string `json:"token"`
+}
+
+type CreatePVZRequest struct {
+	City string `json:"city" validate:"required,enum=city"`
+}
+
+type CreateReceptionRequest struct {
+	PvzID string `json:"pvzId" validate:"required,uuid"`
+}
+
+type AddProductRequest struct {
+	Type  string `json:"type" validate:"required,enum=productType"`
+	PvzID string `json:"pvzId" validate:"required,uuid"`
 }
@@ -0,0 +1,126 @@
+package dto
+
+import "net/http"
+
+// ErrorCode - стабильный машиночитаемый код ошибки. Клиенты ветвятся и локализуют
+// сообщения по нему, поэтому существующие коды нельзя переименовывать
+type ErrorCode string
+
+const (
+	CodeInvalidRequest       ErrorCode = "invalid_request"
+	CodeValidationFailed     ErrorCode = "validation_failed"
+	CodeUnsupportedMediaType ErrorCode = "unsupported_media_type"
+	CodeNotAcceptable        ErrorCode = "not_acceptable"
+	CodePayloadTooLarge      ErrorCode = "payload_too_large"
+	CodeUnauthorized         ErrorCode = "unauthorized"
+	CodeInvalidToken         ErrorCode = "invalid_token"
+	CodeTokenRevoked         ErrorCode = "token_revoked"
+	CodeInvalidCredentials   ErrorCode = "invalid_credentials"
+	CodeEmailNotVerified     ErrorCode = "email_not_verified"
+	CodeInvalidEmailToken    ErrorCode = "invalid_email_token"
+	CodeForbidden            ErrorCode = "forbidden"
+	CodeEmailTaken           ErrorCode = "email_already_registered"
+	CodePVZNotFound          ErrorCode = "pvz_not_found"
+	CodeReceptionAlreadyOpen ErrorCode = "reception_already_open"
+	CodeNoOpenReception      ErrorCode = "no_open_reception"
+	CodeWebhookNotFound      ErrorCode = "webhook_not_found"
+	CodeMethodNotAllowed     ErrorCode = "method_not_allowed"
+	CodeTooManyRequests      ErrorCode = "too_many_requests"
+	CodeAccountLocked        ErrorCode = "account_locked"
+	CodePreconditionFailed   ErrorCode = "precondition_failed"
+	CodeIdempotencyConflict  ErrorCode = "idempotency_conflict"
+	CodeIdempotencyMismatch  ErrorCode = "idempotency_key_reused"
+	CodeRequestTimeout       ErrorCode = "request_timeout"
+	CodeInternal             ErrorCode = "internal_error"
+)
+
+// Коды ошибок отдельных полей в FieldError
+const (
+	FieldRequired      = "required"
+	FieldInvalid       = "invalid"
+	FieldInvalidFormat = "invalid_format"
+	FieldNotAllowed    = "not_allowed"
+	FieldTooShort      = "too_short"
+	FieldTooLong       = "too_long"
+	FieldWeakPassword  = "weak_password"
+	FieldOutOfRange    = "out_of_range"
+)
+
+type problemInfo struct {
+	Status int
+	Title  string
+}
+
+var problems = map[ErrorCode]problemInfo{
+	CodeInvalidRequest:       {http.StatusBadRequest, "Invalid request"},
+	CodeValidationFailed:     {http.StatusBadRequest, "Validation failed"},
+	CodeUnsupportedMediaType: {http.StatusUnsupportedMediaType, "Unsupported media type"},
+	CodeNotAcceptable:        {http.StatusNotAcceptable, "Not acceptable"},
+	CodePayloadTooLarge:      {http.StatusRequestEntityTooLarge, "Payload too large"},
+	CodeUnauthorized:         {http.StatusUnauthorized, "Unauthorized"},
+	CodeInvalidToken:         {http.StatusUnauthorized, "Invalid token"},
+	CodeTokenRevoked:         {http.StatusUnauthorized, "Token revoked or expired"},
+	CodeInvalidCredentials:   {http.StatusUnauthorized, "Invalid credentials"},
+	CodeEmailNotVerified:     {http.StatusForbidden, "Email not verified"},
+	CodeInvalidEmailToken:    {http.StatusBadRequest, "Invalid or expired email token"},
+	CodeForbidden:            {http.StatusForbidden, "Forbidden"},
+	CodeEmailTaken:           {http.StatusBadRequest, "Email already registered"},
+	CodePVZNotFound:          {http.StatusNotFound, "PVZ not found"},
+	CodeReceptionAlreadyOpen: {http.StatusBadRequest, "Reception already open"},
+	CodeNoOpenReception:      {http.StatusBadRequest, "No open reception"},
+	CodeWebhookNotFound:      {http.StatusNotFound, "Webhook not found"},
+	CodeMethodNotAllowed:     {http.StatusMethodNotAllowed, "Method not allowed"},
+	CodeTooManyRequests:      {http.StatusTooManyRequests, "Too many requests"},
+	CodeAccountLocked:        {http.StatusTooManyRequests, "Account temporarily locked"},
+	CodePreconditionFailed:   {http.StatusPreconditionFailed, "Precondition failed"},
+	CodeIdempotencyConflict:  {http.StatusConflict, "Request with this idempotency key is in progress"},
+	CodeIdempotencyMismatch:  {http.StatusUnprocessableEntity, "Idempotency key reused with a different request"},
+	CodeRequestTimeout:       {http.StatusGatewayTimeout, "Request timeout"},
+	CodeInternal:             {http.StatusInternalServerError, "Internal server error"},
+}
+
+// Status возвращает HTTP статус кода, неизвестные коды считаются внутренней ошибкой
+func (c ErrorCode) Status() int {
+	if info, ok := problems[c]; ok {
+		return info.Status
+	}
+	return http.StatusInternalServerError
+}
+
+func (c ErrorCode) Title() string {
+	if info, ok := problems[c]; ok {
+		return info.Title
+	}
+	return problems[CodeInternal].Title
+}
+
+// Problem - тело ошибки по RFC 7807 (application/problem+json)
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     ErrorCode    `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// Message дублирует detail для клиентов, написанных под прежний формат {"message": ...}
+	Message string `json:"message"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewProblem(code ErrorCode, detail string, fieldErrors ...FieldError) Problem {
+	return Problem{
+		Type:    "urn:pvz-service:problem:" + string(code),
+		Title:   code.Title(),
+		Status:  code.Status(),
+		Detail:  detail,
+		Code:    code,
+		Errors:  fieldErrors,
+		Message: detail,
+	}
+}
@@ -0,0 +1,66 @@
+package dto
+
+import (
+	pvz_v1 "github.com/kosttiik/pvz-service/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// Разбор тел запросов в application/x-protobuf
+
+func (r *RegisterRequest) UnmarshalProto(data []byte) error {
+	var msg pvz_v1.RegisterRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*r = RegisterRequest{Email: msg.GetEmail(), Password: msg.GetPassword(), Role: msg.GetRole()}
+	return nil
+}
+
+func (r *LoginRequest) UnmarshalProto(data []byte) error {
+	var msg pvz_v1.LoginRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*r = LoginRequest{Email: msg.GetEmail(), Password: msg.GetPassword()}
+	return nil
+}
+
+func (r *DummyLoginRequest) UnmarshalProto(data []byte) error {
+	var msg pvz_v1.DummyLoginRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*r = DummyLoginRequest{Role: msg.GetRole()}
+	return nil
+}
+
+func (r *CreatePVZRequest) UnmarshalProto(data []byte) error {
+	var msg pvz_v1.CreatePVZRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*r = CreatePVZRequest{City: msg.GetCity()}
+	return nil
+}
+
+func (r *CreateReceptionRequest) UnmarshalProto(data []byte) error {
+	var msg pvz_v1.CreateReceptionRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*r = CreateReceptionRequest{PvzID: msg.GetPvzId()}
+	return nil
+}
+
+func (r *AddProductRequest) UnmarshalProto(data []byte) error {
+	var msg pvz_v1.AddProductRequest
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*r = AddProductRequest{Type: msg.GetType(), PvzID: msg.GetPvzId()}
+	return nil
+}
+
+func (r TokenResponse) MarshalProto() ([]byte, error) {
+	return proto.Marshal(&pvz_v1.Token{Token: r.Token})
+}
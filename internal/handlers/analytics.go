@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAnalyticsRange = 30 * 24 * time.Hour
+	maxAnalyticsRange     = 366 * 24 * time.Hour
+)
+
+type ThroughputResponse struct {
+	GroupBy string                     `json:"groupBy"`
+	Period  string                     `json:"period"`
+	From    time.Time                  `json:"from"`
+	To      time.Time                  `json:"to"`
+	Buckets []repository.ThroughputRow `json:"buckets"`
+}
+
+func (h *Handler) ThroughputHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	query := r.URL.Query()
+
+	filter := repository.ThroughputFilter{
+		GroupBy: "city",
+		Period:  "day",
+		To:      time.Now().UTC(),
+	}
+
+	if groupBy := query.Get("groupBy"); groupBy != "" {
+		if !repository.ThroughputGroups[groupBy] {
+			utils.WriteFieldError(w, r, "groupBy", dto.FieldNotAllowed, "Invalid groupBy, expected pvz, city or type")
+			return
+		}
+		filter.GroupBy = groupBy
+	}
+
+	if period := query.Get("period"); period != "" {
+		if !repository.ThroughputPeriods[period] {
+			utils.WriteFieldError(w, r, "period", dto.FieldNotAllowed, "Invalid period, expected day, week or month")
+			return
+		}
+		filter.Period = period
+	}
+
+	if to := query.Get("to"); to != "" {
+		parsedTime, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			utils.WriteFieldError(w, r, "to", dto.FieldInvalidFormat, "Invalid format of to date")
+			return
+		}
+		filter.To = parsedTime.UTC()
+	}
+
+	filter.From = filter.To.Add(-defaultAnalyticsRange)
+	if from := query.Get("from"); from != "" {
+		parsedTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			utils.WriteFieldError(w, r, "from", dto.FieldInvalidFormat, "Invalid format of from date")
+			return
+		}
+		filter.From = parsedTime.UTC()
+	}
+
+	if !filter.From.Before(filter.To) {
+		utils.WriteFieldError(w, r, "from", dto.FieldOutOfRange, "From date must be before to date")
+		return
+	}
+	if filter.To.Sub(filter.From) > maxAnalyticsRange {
+		utils.WriteFieldError(w, r, "from", dto.FieldOutOfRange, "Date range cannot exceed 366 days")
+		return
+	}
+
+	rows, err := h.analyticsRepo.Throughput(r.Context(), filter)
+	if err != nil {
+		log.Error("Failed to get throughput",
+			zap.Error(err),
+			zap.Any("filter", filter))
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to get throughput")
+		return
+	}
+
+	utils.Write(w, r, ThroughputResponse{
+		GroupBy: filter.GroupBy,
+		Period:  filter.Period,
+		From:    filter.From,
+		To:      filter.To,
+		Buckets: rows,
+	}, http.StatusOK)
+}
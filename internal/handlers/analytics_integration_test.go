@@ -0,0 +1,57 @@
+//go:build integration
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	handlertest "github.com/kosttiik/pvz-service/internal/handlers/internal/test"
+)
+
+func init() {
+	handlertest.Init()
+}
+
+func TestIntegrationThroughputHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{"Defaults", "", http.StatusOK},
+		{"Group by type weekly", "?groupBy=type&period=week", http.StatusOK},
+		{"Group by pvz monthly", "?groupBy=pvz&period=month&from=2025-01-01T00:00:00Z&to=2025-06-01T00:00:00Z", http.StatusOK},
+		{"Invalid group", "?groupBy=planet", http.StatusBadRequest},
+		{"Invalid period", "?period=hour", http.StatusBadRequest},
+		{"Invalid date", "?from=yesterday", http.StatusBadRequest},
+		{"From after to", "?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", http.StatusBadRequest},
+		{"Range too long", "?from=2020-01-01T00:00:00Z&to=2025-01-01T00:00:00Z", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/analytics/throughput"+tt.query, nil)
+			req = getDBTestToken(t, "moderator", req)
+			w := httptest.NewRecorder()
+
+			dbTestHandler().ThroughputHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ThroughputHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			if w.Code == http.StatusOK {
+				var resp ThroughputResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Errorf("Failed to decode response: %v", err)
+				}
+				if resp.Buckets == nil {
+					t.Error("Expected buckets array in response")
+				}
+			}
+		})
+	}
+}
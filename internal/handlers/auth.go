@@ -1,197 +1,200 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 
-	"github.com/google/uuid"
 	"github.com/kosttiik/pvz-service/internal/dto"
-	"github.com/kosttiik/pvz-service/internal/models"
-	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/middleware"
+	"github.com/kosttiik/pvz-service/internal/service"
 	"github.com/kosttiik/pvz-service/internal/utils"
-	"github.com/kosttiik/pvz-service/pkg/cache"
-	"github.com/kosttiik/pvz-service/pkg/database"
+	"github.com/kosttiik/pvz-service/internal/validation"
 	"github.com/kosttiik/pvz-service/pkg/logger"
-	"github.com/kosttiik/pvz-service/pkg/redis"
 	"go.uber.org/zap"
 )
 
-type DummyLoginRequest struct {
-	Role string `json:"role"`
-}
-
-type DummyLoginResponse struct {
-	Token string `json:"token"`
-}
-
 var dummyTokens = map[string]string{
 	"moderator": "moderator-token",
 	"employee":  "employee-token",
 }
 
-func DummyLoginHandler(w http.ResponseWriter, r *http.Request) {
-	var req DummyLoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.WriteError(w, "Invalid request", http.StatusBadRequest)
+func (h *Handler) DummyLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req dto.DummyLoginRequest
+	if err := utils.Decode(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	if !models.ValidRoles[req.Role] {
-		utils.WriteError(w, "Invalid role", http.StatusBadRequest)
+	if err := validation.Struct(req); err != nil {
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	dummyUserID := uuid.New().String()
-	token, err := utils.GenerateJWT(dummyUserID, req.Role)
+	token, err := h.auth.DummyLogin(r.Context(), req.Role)
 	if err != nil {
-		utils.WriteError(w, "Failed to generate token", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to manage session")
 		return
 	}
 
-	tokenCache := cache.NewTokenCache(redis.Client)
-	if err := tokenCache.Set(r.Context(), dummyUserID, token); err != nil {
-		utils.WriteError(w, "Failed to manage session", http.StatusInternalServerError)
-		return
-	}
-
-	resp := DummyLoginResponse{Token: token}
-	utils.WriteJSON(w, resp, http.StatusOK)
+	resp := dto.TokenResponse{Token: token}
+	utils.Write(w, r, resp, http.StatusOK)
 }
 
-func RegisterHandler(w http.ResponseWriter, r *http.Request) {
-	log := logger.Log
+func (h *Handler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
 	ctx := r.Context()
 
-	userRepo := repository.NewUserRepository(database.DB)
-
 	var req dto.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := utils.Decode(r, &req); err != nil {
 		log.Warn("Failed to decode register request", zap.Error(err))
-		utils.WriteError(w, "Invalid request", http.StatusBadRequest)
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	if req.Email == "" || req.Password == "" {
-		utils.WriteError(w, "Email and password are required", http.StatusBadRequest)
+	if err := validation.Struct(req); err != nil {
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	// Проверяем есть ли уже такой пользователь с эмейлом
-	var count int
-	err := database.DB.QueryRow(ctx,
-		"SELECT COUNT(*) FROM users WHERE email = $1",
-		req.Email).Scan(&count)
+	user, err := h.auth.Register(ctx, req.Email, req.Password, req.Role)
 	if err != nil {
-		utils.WriteError(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	if count > 0 {
-		utils.WriteError(w, "Email already registered", http.StatusBadRequest)
+		writeServiceError(w, r, err, "Internal server error")
 		return
 	}
 
-	if !models.ValidRoles[req.Role] {
-		utils.WriteError(w, "Invalid role", http.StatusBadRequest)
-		return
-	}
+	log.Info("User registered successfully",
+		zap.String("userID", user.ID.String()),
+		zap.String("email", user.Email),
+		zap.String("role", user.Role))
 
-	hashedPassword, err := utils.HashPassword(req.Password)
-	if err != nil {
-		utils.WriteError(w, "Internal server error", http.StatusInternalServerError)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	ctx := r.Context()
+
+	var req dto.LoginRequest
+	if err := utils.Decode(r, &req); err != nil {
+		log.Warn("Failed to decode login request", zap.Error(err))
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	// Создаем нового пользователя
-	user := models.User{
-		ID:       uuid.New(),
-		Email:    req.Email,
-		Password: hashedPassword,
-		Role:     req.Role,
+	if err := validation.Struct(req); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
 	}
 
-	if err := userRepo.Create(ctx, &user); err != nil {
-		utils.WriteError(w, "Internal server error", http.StatusInternalServerError)
+	user, token, err := h.auth.Login(ctx, req.Email, req.Password, middleware.ClientIP(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownUser):
+			log.Info("Login failed - user not found", zap.String("email", req.Email))
+			metrics.AuthFailuresTotal.WithLabelValues(metrics.AuthReasonUnknownUser).Inc()
+		case errors.Is(err, service.ErrWrongPassword):
+			metrics.AuthFailuresTotal.WithLabelValues(metrics.AuthReasonWrongPassword).Inc()
+		}
+		writeServiceError(w, r, err, "Failed to manage session")
 		return
 	}
 
-	log.Info("User registered successfully",
+	log.Info("User logged in successfully",
 		zap.String("userID", user.ID.String()),
 		zap.String("email", user.Email),
 		zap.String("role", user.Role))
 
-	w.WriteHeader(http.StatusCreated)
+	utils.Write(w, r, dto.TokenResponse{Token: token}, http.StatusOK)
 }
 
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
-	log := logger.Log
+func (h *Handler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
 	ctx := r.Context()
-	userRepo := repository.NewUserRepository(database.DB)
-	tokenCache := cache.NewTokenCache(redis.Client)
+	claims := utils.GetUserFromContext(ctx)
 
-	var req dto.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Warn("Failed to decode login request", zap.Error(err))
-		utils.WriteError(w, "Invalid request", http.StatusBadRequest)
+	if err := h.auth.Logout(ctx, claims); err != nil {
+		writeServiceError(w, r, err, "Failed to logout")
 		return
 	}
 
-	user, err := userRepo.GetByEmail(ctx, req.Email)
-	if err != nil {
-		log.Info("Login failed - user not found",
-			zap.String("email", req.Email),
-			zap.Error(err))
-		utils.WriteError(w, "Invalid credentials", http.StatusUnauthorized)
+	log.Info("User logged out successfully",
+		zap.String("userID", claims.UserID))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req dto.VerifyEmailRequest
+	if err := utils.Decode(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	if err := utils.CheckPassword(req.Password, user.Password); err != nil {
-		utils.WriteError(w, "Invalid credentials", http.StatusUnauthorized)
+	if err := validation.Struct(req); err != nil {
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	// Инвалидим все токены юзера
-	if err := tokenCache.Invalidate(ctx, user.ID.String()); err != nil {
-		utils.WriteError(w, "Failed to manage session", http.StatusInternalServerError)
+	user, err := h.auth.VerifyEmail(r.Context(), req.Token)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to verify email")
 		return
 	}
 
-	token, err := utils.GenerateJWT(user.ID.String(), user.Role)
-	if err != nil {
-		utils.WriteError(w, "Failed to generate token", http.StatusInternalServerError)
+	log.Info("Email verified",
+		zap.String("userID", user.ID.String()),
+		zap.String("email", user.Email))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPasswordHandler отвечает 202 независимо от того, зарегистрирован ли адрес
+func (h *Handler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req dto.ForgotPasswordRequest
+	if err := utils.Decode(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	// Кэшируем токен юзера
-	if err := tokenCache.Set(ctx, user.ID.String(), token); err != nil {
-		utils.WriteError(w, "Failed to manage session", http.StatusInternalServerError)
+	if err := validation.Struct(req); err != nil {
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	log.Info("User logged in successfully",
-		zap.String("userID", user.ID.String()),
-		zap.String("email", user.Email),
-		zap.String("role", user.Role))
+	if err := h.auth.ForgotPassword(r.Context(), req.Email); err != nil {
+		writeServiceError(w, r, err, "Failed to send password reset email")
+		return
+	}
 
-	utils.WriteJSON(w, map[string]string{"token": token}, http.StatusOK)
+	w.WriteHeader(http.StatusAccepted)
 }
 
-func LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	log := logger.Log
-	ctx := r.Context()
-	claims := utils.GetUserFromContext(ctx)
-	if claims == nil {
-		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
+func (h *Handler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+
+	var req dto.ResetPasswordRequest
+	if err := utils.Decode(r, &req); err != nil {
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	tokenCache := cache.NewTokenCache(redis.Client)
-	if err := tokenCache.Invalidate(ctx, claims.UserID); err != nil {
-		utils.WriteError(w, "Failed to logout", http.StatusInternalServerError)
+	if err := validation.Struct(req); err != nil {
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	log.Info("User logged out successfully",
-		zap.String("userID", claims.UserID))
+	user, err := h.auth.ResetPassword(r.Context(), req.Token, req.Password)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to reset password")
+		return
+	}
+
+	log.Info("Password reset",
+		zap.String("userID", user.ID.String()),
+		zap.String("email", user.Email))
 
 	w.WriteHeader(http.StatusNoContent)
 }
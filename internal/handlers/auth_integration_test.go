@@ -0,0 +1,362 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/dto"
+	handlertest "github.com/kosttiik/pvz-service/internal/handlers/internal/test"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/service"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/internal/webhook"
+	"github.com/kosttiik/pvz-service/pkg/database"
+	"github.com/kosttiik/pvz-service/pkg/mailer"
+)
+
+func init() {
+	handlertest.Init()
+}
+
+func TestIntegrationDummyLoginHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		wantStatus int
+	}{
+		{"Valid employee", "employee", http.StatusOK},
+		{"Valid moderator", "moderator", http.StatusOK},
+		{"Invalid role", "invalid", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := map[string]string{"role": tt.role}
+			jsonBody, _ := json.Marshal(body)
+			req := httptest.NewRequest(http.MethodPost, "/dummyLogin", bytes.NewBuffer(jsonBody))
+			w := httptest.NewRecorder()
+
+			dbTestHandler().DummyLoginHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if tt.wantStatus == http.StatusOK {
+				var response struct {
+					Token string `json:"token"`
+				}
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Errorf("failed to decode response: %v", err)
+				}
+				if response.Token == "" {
+					t.Error("token is empty")
+				}
+			}
+		})
+	}
+}
+
+func TestIntegrationRegisterHandler(t *testing.T) {
+	duplicateUser := &models.User{
+		ID:       uuid.New(),
+		Email:    "test@example.com",
+		Password: "somepassword",
+		Role:     "employee",
+	}
+
+	tests := []struct {
+		name       string
+		input      dto.RegisterRequest
+		setupUser  bool
+		wantStatus int
+	}{
+		{
+			name: "Valid registration",
+			input: dto.RegisterRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+				Role:     "employee",
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "Invalid role",
+			input: dto.RegisterRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+				Role:     "invalid",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Empty email",
+			input: dto.RegisterRequest{
+				Email:    "",
+				Password: "password123",
+				Role:     "employee",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Empty password",
+			input: dto.RegisterRequest{
+				Email:    "test@example.com",
+				Password: "",
+				Role:     "employee",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Duplicate email",
+			input: dto.RegisterRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+				Role:     "employee",
+			},
+			setupUser:  true,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := database.DB.Exec(context.Background(), "TRUNCATE users CASCADE"); err != nil {
+				t.Fatalf("Failed to cleanup users table: %v", err)
+			}
+
+			if tt.setupUser {
+				_, err := database.DB.Exec(context.Background(),
+					"INSERT INTO users (id, email, password, role) VALUES ($1, $2, $3, $4)",
+					duplicateUser.ID, duplicateUser.Email, duplicateUser.Password, duplicateUser.Role)
+				if err != nil {
+					t.Fatalf("Failed to setup test user: %v", err)
+				}
+			}
+
+			jsonBody, _ := json.Marshal(tt.input)
+			req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(jsonBody))
+			w := httptest.NewRecorder()
+
+			dbTestHandler().RegisterHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("RegisterHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestIntegrationLoginHandler(t *testing.T) {
+	password := "testpass123"
+	hashedPassword, _ := utils.HashPassword(password)
+	testUser := models.User{
+		ID:       uuid.New(),
+		Email:    "test@example.com",
+		Password: hashedPassword,
+		Role:     "employee",
+	}
+
+	ctx := context.Background()
+	database.DB.Exec(ctx, "TRUNCATE users CASCADE")
+
+	_, err := database.DB.Exec(ctx, `INSERT INTO users (id, email, password, role, email_verified_at) 
+		VALUES ($1, $2, $3, $4, now())`, testUser.ID, testUser.Email, testUser.Password, testUser.Role)
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	_, err = database.DB.Exec(ctx, `INSERT INTO users (id, email, password, role) 
+		VALUES ($1, $2, $3, $4)`, uuid.New(), "unverified@example.com", testUser.Password, testUser.Role)
+	if err != nil {
+		t.Fatalf("Failed to create unverified user: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		input      dto.LoginRequest
+		wantStatus int
+	}{
+		{
+			name: "Valid login",
+			input: dto.LoginRequest{
+				Email:    testUser.Email,
+				Password: password,
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Wrong password",
+			input: dto.LoginRequest{
+				Email:    testUser.Email,
+				Password: "wrongpass",
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Non-existent user",
+			input: dto.LoginRequest{
+				Email:    "nonexistent@example.com",
+				Password: password,
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Unverified email",
+			input: dto.LoginRequest{
+				Email:    "unverified@example.com",
+				Password: password,
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonBody, _ := json.Marshal(tt.input)
+			req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonBody))
+			w := httptest.NewRecorder()
+
+			dbTestHandler().LoginHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("LoginHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			if tt.wantStatus == http.StatusOK {
+				var response struct {
+					Token string `json:"token"`
+				}
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Errorf("Failed to decode response: %v", err)
+				}
+				if response.Token == "" {
+					t.Error("Expected non-empty token")
+				}
+			}
+		})
+	}
+}
+
+func TestIntegrationLogoutHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupAuth  bool
+		wantStatus int
+	}{
+		{
+			name:       "Valid logout",
+			setupAuth:  true,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "Unauthorized",
+			setupAuth:  false,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+			if tt.setupAuth {
+				req = getDBTestToken(t, "employee", req)
+			}
+			w := httptest.NewRecorder()
+
+			dbTestHandler().LogoutHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("LogoutHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestIntegrationEmailVerificationAndPasswordReset(t *testing.T) {
+	if _, err := database.DB.Exec(context.Background(), "TRUNCATE users CASCADE"); err != nil {
+		t.Fatalf("Failed to cleanup users table: %v", err)
+	}
+
+	mail := &captureMailer{}
+	deps := dbTestDeps(webhook.DefaultConfig())
+	deps.Mailer = mail
+	deps.Mail = service.MailConfig{
+		VerifyURL:       "https://pvz.example/verify",
+		ResetURL:        "https://pvz.example/reset",
+		VerificationTTL: time.Hour,
+		ResetTTL:        time.Hour,
+	}
+	h := New(deps)
+
+	call := func(handler http.HandlerFunc, path string, body any) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(jsonBody)))
+		return w
+	}
+
+	credentials := dto.LoginRequest{Email: "new@example.com", Password: "password123"}
+	w := call(h.RegisterHandler, "/register", dto.RegisterRequest{Email: credentials.Email, Password: credentials.Password, Role: "employee"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Register status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if mail.last.To != credentials.Email {
+		t.Fatalf("Verification mail sent to %q, want %q", mail.last.To, credentials.Email)
+	}
+
+	if w := call(h.LoginHandler, "/login", credentials); w.Code != http.StatusForbidden {
+		t.Errorf("Login before verification status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	verifyToken := mail.token(t)
+	if w := call(h.VerifyEmailHandler, "/email/verify", dto.VerifyEmailRequest{Token: verifyToken}); w.Code != http.StatusNoContent {
+		t.Fatalf("Verify status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w := call(h.VerifyEmailHandler, "/email/verify", dto.VerifyEmailRequest{Token: verifyToken}); w.Code != http.StatusBadRequest {
+		t.Errorf("Reused verify token status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := call(h.LoginHandler, "/login", credentials); w.Code != http.StatusOK {
+		t.Errorf("Login after verification status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Для неизвестного адреса ответ такой же
+	mail.last = mailer.Message{}
+	if w := call(h.ForgotPasswordHandler, "/password/forgot", dto.ForgotPasswordRequest{Email: "nobody@example.com"}); w.Code != http.StatusAccepted {
+		t.Errorf("Forgot for unknown email status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if mail.last.To != "" {
+		t.Errorf("Unexpected mail to %q", mail.last.To)
+	}
+
+	if w := call(h.ForgotPasswordHandler, "/password/forgot", dto.ForgotPasswordRequest{Email: credentials.Email}); w.Code != http.StatusAccepted {
+		t.Fatalf("Forgot status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	resetToken := mail.token(t)
+
+	if w := call(h.ResetPasswordHandler, "/password/reset", dto.ResetPasswordRequest{Token: resetToken, Password: "short"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Reset with weak password status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := call(h.ResetPasswordHandler, "/password/reset", dto.ResetPasswordRequest{Token: resetToken, Password: "newpassword123"}); w.Code != http.StatusNoContent {
+		t.Fatalf("Reset status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w := call(h.ResetPasswordHandler, "/password/reset", dto.ResetPasswordRequest{Token: resetToken, Password: "newpassword123"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Reused reset token status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	if w := call(h.LoginHandler, "/login", credentials); w.Code != http.StatusUnauthorized {
+		t.Errorf("Login with old password status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	credentials.Password = "newpassword123"
+	if w := call(h.LoginHandler, "/login", credentials); w.Code != http.StatusOK {
+		t.Errorf("Login with new password status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -6,20 +6,21 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kosttiik/pvz-service/internal/dto"
-	handlertest "github.com/kosttiik/pvz-service/internal/handlers/internal/test"
 	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository/memory"
+	"github.com/kosttiik/pvz-service/internal/service"
 	"github.com/kosttiik/pvz-service/internal/utils"
-	"github.com/kosttiik/pvz-service/pkg/database"
+	"github.com/kosttiik/pvz-service/internal/webhook"
+	"github.com/kosttiik/pvz-service/pkg/mailer"
 )
 
-func init() {
-	handlertest.Init()
-}
-
 func TestDummyLoginHandler(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -38,7 +39,7 @@ func TestDummyLoginHandler(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/dummyLogin", bytes.NewBuffer(jsonBody))
 			w := httptest.NewRecorder()
 
-			DummyLoginHandler(w, req)
+			testHandler().DummyLoginHandler(w, req)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("got status %d, want %d", w.Code, tt.wantStatus)
@@ -123,15 +124,9 @@ func TestRegisterHandler(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if _, err := database.DB.Exec(context.Background(), "TRUNCATE users CASCADE"); err != nil {
-				t.Fatalf("Failed to cleanup users table: %v", err)
-			}
-
+			store := memory.NewStore()
 			if tt.setupUser {
-				_, err := database.DB.Exec(context.Background(),
-					"INSERT INTO users (id, email, password, role) VALUES ($1, $2, $3, $4)",
-					duplicateUser.ID, duplicateUser.Email, duplicateUser.Password, duplicateUser.Role)
-				if err != nil {
+				if err := store.Users().Create(context.Background(), duplicateUser); err != nil {
 					t.Fatalf("Failed to setup test user: %v", err)
 				}
 			}
@@ -140,7 +135,7 @@ func TestRegisterHandler(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(jsonBody))
 			w := httptest.NewRecorder()
 
-			RegisterHandler(w, req)
+			newTestHandler(store, webhook.DefaultConfig()).RegisterHandler(w, req)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("RegisterHandler() status = %v, want %v", w.Code, tt.wantStatus)
@@ -152,21 +147,28 @@ func TestRegisterHandler(t *testing.T) {
 func TestLoginHandler(t *testing.T) {
 	password := "testpass123"
 	hashedPassword, _ := utils.HashPassword(password)
+	verifiedAt := time.Now()
 	testUser := models.User{
+		ID:              uuid.New(),
+		Email:           "test@example.com",
+		Password:        hashedPassword,
+		Role:            "employee",
+		EmailVerifiedAt: &verifiedAt,
+	}
+	unverifiedUser := models.User{
 		ID:       uuid.New(),
-		Email:    "test@example.com",
+		Email:    "unverified@example.com",
 		Password: hashedPassword,
 		Role:     "employee",
 	}
 
-	ctx := context.Background()
-	database.DB.Exec(ctx, "TRUNCATE users CASCADE")
-
-	_, err := database.DB.Exec(ctx, `INSERT INTO users (id, email, password, role) 
-		VALUES ($1, $2, $3, $4)`, testUser.ID, testUser.Email, testUser.Password, testUser.Role)
-	if err != nil {
-		t.Fatalf("Failed to create test user: %v", err)
+	store := memory.NewStore()
+	for _, user := range []models.User{testUser, unverifiedUser} {
+		if err := store.Users().Create(context.Background(), &user); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
 	}
+	h := newTestHandler(store, webhook.DefaultConfig())
 
 	tests := []struct {
 		name       string
@@ -197,6 +199,14 @@ func TestLoginHandler(t *testing.T) {
 			},
 			wantStatus: http.StatusUnauthorized,
 		},
+		{
+			name: "Unverified email",
+			input: dto.LoginRequest{
+				Email:    "unverified@example.com",
+				Password: password,
+			},
+			wantStatus: http.StatusForbidden,
+		},
 	}
 
 	for _, tt := range tests {
@@ -205,7 +215,7 @@ func TestLoginHandler(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonBody))
 			w := httptest.NewRecorder()
 
-			LoginHandler(w, req)
+			h.LoginHandler(w, req)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("LoginHandler() status = %v, want %v", w.Code, tt.wantStatus)
@@ -252,7 +262,7 @@ func TestLogoutHandler(t *testing.T) {
 			}
 			w := httptest.NewRecorder()
 
-			LogoutHandler(w, req)
+			testHandler().LogoutHandler(w, req)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("LogoutHandler() status = %v, want %v", w.Code, tt.wantStatus)
@@ -260,3 +270,101 @@ func TestLogoutHandler(t *testing.T) {
 		})
 	}
 }
+
+// captureMailer запоминает последнее письмо
+type captureMailer struct {
+	last mailer.Message
+}
+
+func (m *captureMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.last = msg
+	return nil
+}
+
+// token достает токен из ссылки в последнем письме
+func (m *captureMailer) token(t *testing.T) string {
+	t.Helper()
+	for _, field := range strings.Fields(m.last.Body) {
+		if u, err := url.Parse(field); err == nil && u.Query().Get("token") != "" {
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatalf("No token link in mail %q", m.last.Body)
+	return ""
+}
+
+func TestEmailVerificationAndPasswordReset(t *testing.T) {
+	mail := &captureMailer{}
+	deps := testDeps(memory.NewStore(), webhook.DefaultConfig())
+	deps.Mailer = mail
+	deps.Mail = service.MailConfig{
+		VerifyURL:       "https://pvz.example/verify",
+		ResetURL:        "https://pvz.example/reset",
+		VerificationTTL: time.Hour,
+		ResetTTL:        time.Hour,
+	}
+	h := New(deps)
+
+	call := func(handler http.HandlerFunc, path string, body any) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(jsonBody)))
+		return w
+	}
+
+	credentials := dto.LoginRequest{Email: "new@example.com", Password: "password123"}
+	w := call(h.RegisterHandler, "/register", dto.RegisterRequest{Email: credentials.Email, Password: credentials.Password, Role: "employee"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Register status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if mail.last.To != credentials.Email {
+		t.Fatalf("Verification mail sent to %q, want %q", mail.last.To, credentials.Email)
+	}
+
+	if w := call(h.LoginHandler, "/login", credentials); w.Code != http.StatusForbidden {
+		t.Errorf("Login before verification status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	verifyToken := mail.token(t)
+	if w := call(h.VerifyEmailHandler, "/email/verify", dto.VerifyEmailRequest{Token: verifyToken}); w.Code != http.StatusNoContent {
+		t.Fatalf("Verify status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w := call(h.VerifyEmailHandler, "/email/verify", dto.VerifyEmailRequest{Token: verifyToken}); w.Code != http.StatusBadRequest {
+		t.Errorf("Reused verify token status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := call(h.LoginHandler, "/login", credentials); w.Code != http.StatusOK {
+		t.Errorf("Login after verification status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Для неизвестного адреса ответ такой же
+	mail.last = mailer.Message{}
+	if w := call(h.ForgotPasswordHandler, "/password/forgot", dto.ForgotPasswordRequest{Email: "nobody@example.com"}); w.Code != http.StatusAccepted {
+		t.Errorf("Forgot for unknown email status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if mail.last.To != "" {
+		t.Errorf("Unexpected mail to %q", mail.last.To)
+	}
+
+	if w := call(h.ForgotPasswordHandler, "/password/forgot", dto.ForgotPasswordRequest{Email: credentials.Email}); w.Code != http.StatusAccepted {
+		t.Fatalf("Forgot status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	resetToken := mail.token(t)
+
+	if w := call(h.ResetPasswordHandler, "/password/reset", dto.ResetPasswordRequest{Token: resetToken, Password: "short"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Reset with weak password status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := call(h.ResetPasswordHandler, "/password/reset", dto.ResetPasswordRequest{Token: resetToken, Password: "newpassword123"}); w.Code != http.StatusNoContent {
+		t.Fatalf("Reset status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w := call(h.ResetPasswordHandler, "/password/reset", dto.ResetPasswordRequest{Token: resetToken, Password: "newpassword123"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Reused reset token status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	if w := call(h.LoginHandler, "/login", credentials); w.Code != http.StatusUnauthorized {
+		t.Errorf("Login with old password status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	credentials.Password = "newpassword123"
+	if w := call(h.LoginHandler, "/login", credentials); w.Code != http.StatusOK {
+		t.Errorf("Login with new password status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/kosttiik/pvz-service/internal/dashboard"
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/internal/validation"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Авторизация идет по заголовку, а не по cookie, поэтому проверка Origin не защищает ни от чего
+var dashboardUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+func (h *Handler) DashboardHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	ctx, cancel := utils.StreamContext(r)
+	defer cancel()
+
+	claims := utils.GetUserFromContext(ctx)
+	if claims == nil {
+		utils.WriteProblem(w, r, dto.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	city := r.URL.Query().Get("city")
+	if err := validation.Var("city", city, "required,enum=city"); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	// Подписываемся до построения снапшота, чтобы не пропустить изменения между ними
+	sub := h.pubsub.Subscribe(ctx, dashboard.CityChannel(city))
+	defer sub.Close()
+
+	if _, err := sub.Receive(ctx); err != nil {
+		log.Error("Failed to subscribe to city events",
+			zap.String("city", city),
+			zap.Error(err))
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to subscribe to events")
+		return
+	}
+
+	activity, err := h.pvzRepo.GetCityActivity(ctx, city)
+	if err != nil {
+		log.Error("Failed to build dashboard snapshot",
+			zap.String("city", city),
+			zap.Error(err))
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to build snapshot")
+		return
+	}
+
+	conn, err := dashboardUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade уже ответил клиенту
+		log.Warn("Failed to upgrade dashboard connection", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	log.Info("Dashboard client connected",
+		zap.String("city", city),
+		zap.String("userID", claims.UserID))
+
+	dashboard.NewSession(conn, sub, dashboard.NewState(city, activity)).Run(ctx)
+
+	log.Info("Dashboard client disconnected",
+		zap.String("city", city),
+		zap.String("userID", claims.UserID))
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/outbox"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/internal/validation"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const sseHeartbeatInterval = 15 * time.Second
+
+// События, которые отдаются в живой поток ПВЗ
+var pvzStreamEvents = map[models.EventType]bool{
+	models.EventReceptionCreated: true,
+	models.EventReceptionClosed:  true,
+	models.EventProductAdded:     true,
+	models.EventProductDeleted:   true,
+}
+
+// PVZEventsHandler отдает живой поток событий ПВЗ. Роль проверяет RoleMiddleware маршрута
+func (h *Handler) PVZEventsHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	ctx, cancel := utils.StreamContext(r)
+	defer cancel()
+
+	claims := utils.GetUserFromContext(ctx)
+	pvzID := r.PathValue("pvzId")
+	if err := validation.Var("pvzId", pvzID, "required,uuid"); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if _, err := h.pvzRepo.GetByID(ctx, pvzID); err != nil {
+		if errors.Is(err, repository.ErrPVZNotFound) {
+			utils.WriteProblem(w, r, dto.CodePVZNotFound, "PVZ not found")
+			return
+		}
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to get PVZ")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.WriteProblem(w, r, dto.CodeInternal, "Streaming unsupported")
+		return
+	}
+
+	// Подписываемся до отправки заголовков, чтобы не потерять события между ответом и подпиской
+	sub := h.pubsub.Subscribe(ctx, outbox.PVZChannel(pvzID))
+	defer sub.Close()
+
+	if _, err := sub.Receive(ctx); err != nil {
+		log.Error("Failed to subscribe to PVZ events",
+			zap.String("pvzId", pvzID),
+			zap.Error(err))
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to subscribe to events")
+		return
+	}
+
+	// Поток живет дольше WriteTimeout сервера, дедлайн на запись снимаем
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", (5 * time.Second).Milliseconds())
+	flusher.Flush()
+
+	metrics.SSEConnections.Inc()
+	defer metrics.SSEConnections.Dec()
+
+	log.Info("SSE client connected",
+		zap.String("pvzId", pvzID),
+		zap.String("userID", claims.UserID))
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("SSE client disconnected",
+				zap.String("pvzId", pvzID),
+				zap.String("userID", claims.UserID))
+			return
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var event models.Event
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Warn("Failed to decode PVZ event", zap.Error(err))
+				continue
+			}
+			if !pvzStreamEvents[event.Type] {
+				continue
+			}
+
+			if err := writeSSEEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSEEvent(w http.ResponseWriter, event models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,115 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	handlertest "github.com/kosttiik/pvz-service/internal/handlers/internal/test"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/outbox"
+	"github.com/kosttiik/pvz-service/pkg/redis"
+)
+
+func init() {
+	handlertest.Init()
+}
+
+func TestIntegrationPVZEventsHandler(t *testing.T) {
+	pvzID := createDBTestPVZ(t)
+
+	tests := []struct {
+		name       string
+		pvzID      string
+		role       string
+		wantStatus int
+	}{
+		{
+			name:       "Invalid PVZ ID",
+			pvzID:      "invalid-uuid",
+			role:       "employee",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown PVZ",
+			pvzID:      uuid.New().String(),
+			role:       "employee",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/pvz/"+tt.pvzID+"/events", nil)
+			req.SetPathValue("pvzId", tt.pvzID)
+			req = getDBTestToken(t, tt.role, req)
+			w := httptest.NewRecorder()
+
+			dbTestHandler().PVZEventsHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("PVZEventsHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	t.Run("Streams events", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.SetPathValue("pvzId", pvzID)
+			dbTestHandler().PVZEventsHandler(w, getDBTestToken(t, "employee", r))
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("Content-Type = %v, want text/event-stream", ct)
+		}
+
+		reader := bufio.NewReader(resp.Body)
+		// Ждем первую строку retry, после нее подписка уже активна
+		if _, err := reader.ReadString('\n'); err != nil {
+			t.Fatalf("Failed to read stream: %v", err)
+		}
+
+		event := models.Event{
+			ID:          uuid.New(),
+			Type:        models.EventProductAdded,
+			AggregateID: uuid.New(),
+			PvzID:       pvzID,
+			Payload:     []byte(`{}`),
+			CreatedAt:   time.Now(),
+		}
+		if err := outbox.NewRedisPubSubSink(redis.Client).Publish(ctx, event); err != nil {
+			t.Fatalf("Failed to publish event: %v", err)
+		}
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Event was not received: %v", err)
+			}
+			if strings.HasPrefix(line, "event: ") {
+				if got := strings.TrimSpace(strings.TrimPrefix(line, "event: ")); got != string(event.Type) {
+					t.Errorf("Got event %q, want %q", got, event.Type)
+				}
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"github.com/kosttiik/pvz-service/pkg/xlsx"
+	"go.uber.org/zap"
+)
+
+const exportFlushEvery = 500
+
+// BOM нужен, чтобы Excel открыл CSV как UTF-8 и не испортил кириллицу
+const utf8BOM = "\xEF\xBB\xBF"
+
+var exportHeader = []string{
+	"pvz_id", "city",
+	"reception_id", "reception_date_time", "reception_status", "reception_closed_at",
+	"product_id", "product_type", "product_date_time",
+}
+
+type rowWriter interface {
+	WriteRow(values []string) error
+	Flush() error
+	Close() error
+}
+
+type csvRowWriter struct {
+	csv *csv.Writer
+}
+
+func newCSVRowWriter(w io.Writer) (*csvRowWriter, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+	return &csvRowWriter{csv: csv.NewWriter(w)}, nil
+}
+
+func (c *csvRowWriter) WriteRow(values []string) error {
+	return c.csv.Write(values)
+}
+
+func (c *csvRowWriter) Flush() error {
+	c.csv.Flush()
+	return c.csv.Error()
+}
+
+func (c *csvRowWriter) Close() error {
+	return c.Flush()
+}
+
+func (h *Handler) ExportReceptionsHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		utils.WriteFieldError(w, r, "format", dto.FieldNotAllowed, "Invalid format, expected csv or xlsx")
+		return
+	}
+
+	to := time.Now().UTC()
+	if toParam := query.Get("to"); toParam != "" {
+		parsedTime, err := time.Parse(time.RFC3339, toParam)
+		if err != nil {
+			utils.WriteFieldError(w, r, "to", dto.FieldInvalidFormat, "Invalid format of to date")
+			return
+		}
+		to = parsedTime.UTC()
+	}
+
+	from := to.Add(-defaultAnalyticsRange)
+	if fromParam := query.Get("from"); fromParam != "" {
+		parsedTime, err := time.Parse(time.RFC3339, fromParam)
+		if err != nil {
+			utils.WriteFieldError(w, r, "from", dto.FieldInvalidFormat, "Invalid format of from date")
+			return
+		}
+		from = parsedTime.UTC()
+	}
+
+	if !from.Before(to) {
+		utils.WriteFieldError(w, r, "from", dto.FieldOutOfRange, "From date must be before to date")
+		return
+	}
+
+	// Большая выгрузка пишется дольше WriteTimeout сервера
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	filename := fmt.Sprintf("receptions_%s_%s.%s", from.Format("20060102"), to.Format("20060102"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	var writer rowWriter
+	var err error
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		writer, err = newCSVRowWriter(w)
+	case "xlsx":
+		w.Header().Set("Content-Type", xlsx.ContentType)
+		writer, err = xlsx.NewWriter(w, "receptions")
+	}
+	if err != nil {
+		log.Error("Failed to start export", zap.Error(err))
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	flush := func() error {
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	if err := writer.WriteRow(exportHeader); err != nil {
+		log.Error("Failed to write export header", zap.Error(err))
+		return
+	}
+
+	count := 0
+	err = h.exportRepo.StreamReceptions(r.Context(), from, to, func(row repository.ExportRow) error {
+		if err := writer.WriteRow(formatExportRow(row)); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// Заголовки уже отправлены, поэтому просто обрываем выгрузку, не закрывая файл
+		log.Error("Failed to export receptions",
+			zap.Error(err),
+			zap.Int("rowsWritten", count))
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		log.Error("Failed to finish export", zap.Error(err))
+		return
+	}
+
+	log.Info("Receptions exported",
+		zap.String("format", format),
+		zap.Int("rows", count),
+		zap.Time("from", from),
+		zap.Time("to", to))
+}
+
+func formatExportRow(row repository.ExportRow) []string {
+	values := []string{
+		row.PvzID.String(),
+		row.City,
+		row.ReceptionID.String(),
+		row.ReceptionDateTime.UTC().Format(time.RFC3339),
+		string(row.ReceptionStatus),
+		formatOptionalTime(row.ReceptionClosedAt),
+		"", "", "",
+	}
+
+	if row.ProductID != nil {
+		values[6] = row.ProductID.String()
+	}
+	if row.ProductType != nil {
+		values[7] = *row.ProductType
+	}
+	values[8] = formatOptionalTime(row.ProductDateTime)
+
+	return values
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
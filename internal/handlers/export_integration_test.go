@@ -0,0 +1,79 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kosttiik/pvz-service/pkg/xlsx"
+)
+
+func TestIntegrationExportReceptionsHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		wantStatus      int
+		wantContentType string
+	}{
+		{"CSV by default", "", http.StatusOK, "text/csv; charset=utf-8"},
+		{"XLSX", "?format=xlsx&from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z", http.StatusOK, xlsx.ContentType},
+		{"Invalid format", "?format=pdf", http.StatusBadRequest, ""},
+		{"Invalid date", "?to=tomorrow", http.StatusBadRequest, ""},
+		{"From after to", "?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/export/receptions"+tt.query, nil)
+			req = getDBTestToken(t, "moderator", req)
+			w := httptest.NewRecorder()
+
+			dbTestHandler().ExportReceptionsHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ExportReceptionsHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if tt.wantContentType == "" {
+				return
+			}
+
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment;") {
+				t.Error("Expected attachment Content-Disposition")
+			}
+		})
+	}
+}
+
+func TestIntegrationExportReceptionsCSVHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/export/receptions?format=csv", nil)
+	req = getDBTestToken(t, "moderator", req)
+	w := httptest.NewRecorder()
+
+	dbTestHandler().ExportReceptionsHandler(w, req)
+
+	body := w.Body.Bytes()
+	if !bytes.HasPrefix(body, []byte(utf8BOM)) {
+		t.Fatal("CSV should start with UTF-8 BOM")
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(body[len(utf8BOM):])).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(exportHeader, ",") {
+		t.Errorf("Unexpected CSV header: %v", records)
+	}
+	for _, record := range records[1:] {
+		if len(record) != len(exportHeader) {
+			t.Errorf("Row has %d columns, want %d", len(record), len(exportHeader))
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/service"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/internal/webhook"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type PVZStore interface {
+	Create(ctx context.Context, pvz *models.PVZ) error
+	GetByID(ctx context.Context, id string) (*models.PVZ, error)
+	GetCityActivity(ctx context.Context, city string) ([]repository.PVZActivity, error)
+	GetPVZ(ctx context.Context, filter repository.GetPVZFilter) ([]repository.PVZandReceptions, error)
+	Import(ctx context.Context, pvzs []models.PVZ, dryRun bool) ([]bool, error)
+}
+
+type WebhookStore interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+}
+
+type AnalyticsStore interface {
+	Throughput(ctx context.Context, filter repository.ThroughputFilter) ([]repository.ThroughputRow, error)
+}
+
+type ExportStore interface {
+	StreamReceptions(ctx context.Context, from, to time.Time, fn func(repository.ExportRow) error) error
+}
+
+// WebhookTester отправляет тестовое событие подписчику, обычно это webhook.Dispatcher
+type WebhookTester interface {
+	SendTest(ctx context.Context, sub models.WebhookSubscription) (*models.WebhookDelivery, error)
+}
+
+// Subscriber дает подписку на каналы Redis для SSE и дашборда
+type Subscriber interface {
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// Deps - зависимости хендлеров. Собираются один раз при старте приложения
+type Deps struct {
+	PVZ        PVZStore
+	Receptions service.ReceptionRepository
+	Products   service.ProductRepository
+	Users      service.UserRepository
+	Webhooks   WebhookStore
+	Analytics  AnalyticsStore
+	Export     ExportStore
+	Tokens     service.TokenStore
+	JWT        *utils.JWT
+	// LoginGuard - блокировка аккаунта после неудачных входов, nil - без блокировки
+	LoginGuard service.LoginGuard
+	// Mailer - письма подтверждения почты и сброса пароля, nil - без писем,
+	// новые пользователи сразу подтверждены, а сброс пароля недоступен
+	Mailer service.Mailer
+	Mail   service.MailConfig
+
+	WebhookTester WebhookTester
+	WebhookConfig webhook.Config
+
+	PubSub Subscriber
+	// Readiness - проверки зависимостей для /readyz
+	Readiness []DependencyCheck
+	// MigrationVersion возвращает примененную версию схемы для /readyz
+	MigrationVersion func(ctx context.Context) (int, error)
+}
+
+// Handler содержит все HTTP хендлеры сервиса. Глобального состояния у хендлеров нет,
+// поэтому в одном процессе может работать несколько независимых экземпляров
+type Handler struct {
+	pvz        *service.PVZService
+	receptions *service.ReceptionService
+	auth       *service.AuthService
+
+	pvzRepo       PVZStore
+	webhookRepo   WebhookStore
+	analyticsRepo AnalyticsStore
+	exportRepo    ExportStore
+
+	webhookTester WebhookTester
+	webhookConfig webhook.Config
+
+	pubsub           Subscriber
+	readiness        []DependencyCheck
+	migrationVersion func(ctx context.Context) (int, error)
+}
+
+func New(deps Deps) *Handler {
+	auth := service.NewAuthService(deps.Users, deps.Tokens, deps.JWT)
+	if deps.LoginGuard != nil {
+		auth.WithLoginGuard(deps.LoginGuard)
+	}
+	if deps.Mailer != nil {
+		auth.WithMailer(deps.Mailer, deps.Mail)
+	}
+
+	return &Handler{
+		pvz:              service.NewPVZService(deps.PVZ),
+		receptions:       service.NewReceptionService(deps.Receptions, deps.Products),
+		auth:             auth,
+		pvzRepo:          deps.PVZ,
+		webhookRepo:      deps.Webhooks,
+		analyticsRepo:    deps.Analytics,
+		exportRepo:       deps.Export,
+		webhookTester:    deps.WebhookTester,
+		webhookConfig:    deps.WebhookConfig,
+		pubsub:           deps.PubSub,
+		readiness:        deps.Readiness,
+		migrationVersion: deps.MigrationVersion,
+	}
+}
+
+// writeServiceError переводит ошибки бизнес-правил в problem details, остальное - 500 с detail
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	var locked *service.LockedError
+	switch {
+	case errors.As(err, &locked):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		metrics.RateLimitedTotal.WithLabelValues(metrics.RateLimitAccountLocked).Inc()
+		utils.WriteProblem(w, r, dto.CodeAccountLocked, "Too many failed login attempts, retry later")
+	case errors.Is(err, service.ErrUnauthorized):
+		utils.WriteProblem(w, r, dto.CodeUnauthorized, "Unauthorized")
+	case errors.Is(err, service.ErrForbidden):
+		utils.WriteProblem(w, r, dto.CodeForbidden, "Forbidden")
+	case errors.Is(err, service.ErrReceptionAlreadyOpen):
+		utils.WriteProblem(w, r, dto.CodeReceptionAlreadyOpen, "PVZ already has an open reception")
+	case errors.Is(err, service.ErrNoOpenReception):
+		utils.WriteProblem(w, r, dto.CodeNoOpenReception, "No open reception found")
+	case errors.Is(err, service.ErrPVZNotFound):
+		utils.WriteProblem(w, r, dto.CodePVZNotFound, "PVZ not found")
+	case errors.Is(err, service.ErrVersionMismatch):
+		utils.WriteProblem(w, r, dto.CodePreconditionFailed, "Resource was modified, fetch it again and retry with the new ETag")
+	case errors.Is(err, service.ErrEmailTaken):
+		utils.WriteProblem(w, r, dto.CodeEmailTaken, "Email already registered")
+	case errors.Is(err, service.ErrInvalidCredentials):
+		utils.WriteProblem(w, r, dto.CodeInvalidCredentials, "Invalid credentials")
+	case errors.Is(err, service.ErrEmailNotVerified):
+		utils.WriteProblem(w, r, dto.CodeEmailNotVerified, "Confirm your email using the link we sent before logging in")
+	case errors.Is(err, service.ErrInvalidToken):
+		utils.WriteProblem(w, r, dto.CodeInvalidEmailToken, "The link is invalid, expired or already used")
+	default:
+		logger.FromContext(r.Context()).Error(detail, zap.Error(err))
+		utils.WriteProblem(w, r, dto.CodeInternal, detail)
+	}
+}
+
+// ifMatchVersion читает ожидаемую версию из If-Match и сам отвечает 412, если тег не может совпасть.
+// Без заголовка возвращается repository.AnyVersion
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	version, err := utils.IfMatchVersion(r)
+	if err != nil {
+		utils.WriteProblem(w, r, dto.CodePreconditionFailed, "If-Match must be a single strong ETag or *")
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,90 @@
+//go:build integration
+
+package handlers
+
+// Интеграционные тесты хендлеров на тестовых Postgres и Redis:
+//
+//	go test -tags integration ./internal/handlers
+//
+// Без тега хендлеры проверяются на in-memory репозиториях (handler_test.go)
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/internal/webhook"
+	"github.com/kosttiik/pvz-service/pkg/cache"
+	"github.com/kosttiik/pvz-service/pkg/database"
+	"github.com/kosttiik/pvz-service/pkg/redis"
+)
+
+// newDBTestHandler собирает хендлеры поверх тестовых Postgres и Redis
+func newDBTestHandler(webhookConfig webhook.Config) *Handler {
+	return New(dbTestDeps(webhookConfig))
+}
+
+func dbTestDeps(webhookConfig webhook.Config) Deps {
+	webhookRepo := repository.NewWebhookRepository(database.DB)
+
+	return Deps{
+		PVZ:           repository.NewPVZRepository(database.DB),
+		Receptions:    repository.NewReceptionRepository(database.DB),
+		Products:      repository.NewProductRepository(database.DB),
+		Users:         repository.NewUserRepository(database.DB),
+		Webhooks:      webhookRepo,
+		Analytics:     repository.NewAnalyticsRepository(database.DB),
+		Export:        repository.NewExportRepository(database.DB),
+		Tokens:        cache.NewTokenCache(redis.Client),
+		JWT:           testJWT,
+		WebhookTester: webhook.NewDispatcher(webhookRepo, webhookConfig),
+		WebhookConfig: webhookConfig,
+		PubSub:        redis.Client,
+	}
+}
+
+func dbTestHandler() *Handler {
+	return newDBTestHandler(webhook.DefaultConfig())
+}
+
+func getDBTestToken(t *testing.T, role string, req *http.Request) *http.Request {
+	userID := uuid.New().String()
+	token, err := testJWT.Generate(userID, role)
+	if err != nil {
+		t.Fatalf("Failed to generate test token: %v", err)
+	}
+
+	// Храним токен в редисе
+	tokenCache := cache.NewTokenCache(redis.Client)
+	ctx := context.Background()
+	if err := tokenCache.Set(ctx, userID, token); err != nil {
+		t.Fatalf("Failed to store token in cache: %v", err)
+	}
+
+	// Добавляем токен в заголовк авторизации
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	// Добавляем контекст с данными пользователя
+	claims := &models.Claims{
+		UserID: userID,
+		Role:   models.Role(role),
+	}
+	return req.WithContext(utils.SetUserContext(req.Context(), claims))
+}
+
+// Функция для создания тестового пвз в бд
+func createDBTestPVZ(t *testing.T) string {
+	pvzID := uuid.New()
+	_, err := database.DB.Exec(context.Background(),
+		"INSERT INTO pvz (id, registration_date, city) VALUES ($1, $2, $3)",
+		pvzID, time.Now(), "Москва")
+	if err != nil {
+		t.Fatalf("Failed to create test PVZ: %v", err)
+	}
+	return pvzID.String()
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository/memory"
+	"github.com/kosttiik/pvz-service/internal/testutils"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/internal/webhook"
+)
+
+var testJWT = utils.NewJWT(testutils.TestJWTSecret, 0)
+
+// testStore - общее in-memory хранилище хендлеров из testHandler. Тесты, которым
+// нужно чистое состояние, собирают хендлер на своем memory.NewStore()
+var testStore = memory.NewStore()
+
+// newTestHandler собирает хендлеры поверх in-memory репозиториев, без Postgres и Redis
+func newTestHandler(store *memory.Store, webhookConfig webhook.Config) *Handler {
+	return New(testDeps(store, webhookConfig))
+}
+
+func testDeps(store *memory.Store, webhookConfig webhook.Config) Deps {
+	webhooks := store.Webhooks()
+
+	return Deps{
+		PVZ:           store.PVZ(),
+		Receptions:    store.Receptions(),
+		Products:      store.Products(),
+		Users:         store.Users(),
+		Webhooks:      webhooks,
+		Tokens:        store.Tokens(),
+		JWT:           testJWT,
+		WebhookTester: webhook.NewDispatcher(webhooks, webhookConfig),
+		WebhookConfig: webhookConfig,
+	}
+}
+
+func testHandler() *Handler {
+	return newTestHandler(testStore, webhook.DefaultConfig())
+}
+
+// getTestToken выпускает токен пользователя с ролью и кладет его в запрос и его контекст
+func getTestToken(t *testing.T, role string, req *http.Request) *http.Request {
+	return getStoreTestToken(t, testStore, role, req)
+}
+
+func getStoreTestToken(t *testing.T, store *memory.Store, role string, req *http.Request) *http.Request {
+	t.Helper()
+	userID := uuid.New().String()
+	token, err := testJWT.Generate(userID, role)
+	if err != nil {
+		t.Fatalf("Failed to generate test token: %v", err)
+	}
+	if err := store.Tokens().Set(context.Background(), userID, token); err != nil {
+		t.Fatalf("Failed to store token: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	claims := &models.Claims{
+		UserID: userID,
+		Role:   models.Role(role),
+	}
+	return req.WithContext(utils.SetUserContext(req.Context(), claims))
+}
+
+// createTestPVZ создает ПВЗ в testStore
+func createTestPVZ(t *testing.T) string {
+	return createStoreTestPVZ(t, testStore)
+}
+
+func createStoreTestPVZ(t *testing.T, store *memory.Store) string {
+	t.Helper()
+	pvz := models.PVZ{
+		ID:               uuid.New(),
+		RegistrationDate: time.Now().UTC(),
+		City:             "Москва",
+		Version:          1,
+	}
+	if err := store.PVZ().Create(context.Background(), &pvz); err != nil {
+		t.Fatalf("Failed to create test PVZ: %v", err)
+	}
+	return pvz.ID.String()
+}
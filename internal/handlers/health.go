@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+
+	readinessCheckTimeout = 2 * time.Second
+)
+
+type DependencyStatus struct {
+	Status    string         `json:"status"`
+	LatencyMs float64        `json:"latencyMs"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status           string                      `json:"status"`
+	MigrationVersion int                         `json:"migrationVersion"`
+	Checks           map[string]DependencyStatus `json:"checks"`
+}
+
+// DependencyCheck проверяет одну зависимость. details попадают в ответ как есть
+type DependencyCheck struct {
+	Name  string
+	Check func(ctx context.Context) (details map[string]any, err error)
+}
+
+// PostgresCheck пингует пул. Ping берет соединение из пула, поэтому при исчерпанном пуле упадет по таймауту
+func PostgresCheck(db *pgxpool.Pool) DependencyCheck {
+	return DependencyCheck{Name: "postgres", Check: func(ctx context.Context) (map[string]any, error) {
+		if err := db.Ping(ctx); err != nil {
+			return nil, err
+		}
+
+		stat := db.Stat()
+		return map[string]any{
+			"acquiredConns": stat.AcquiredConns(),
+			"idleConns":     stat.IdleConns(),
+			"maxConns":      stat.MaxConns(),
+		}, nil
+	}}
+}
+
+func RedisCheck(client *redis.Client) DependencyCheck {
+	return DependencyCheck{Name: "redis", Check: func(ctx context.Context) (map[string]any, error) {
+		return nil, client.Ping(ctx).Err()
+	}}
+}
+
+// HealthzHandler отвечает, пока процесс жив. Зависимости не проверяет, чтобы
+// Kubernetes не перезапускал под из-за недоступной базы
+func (h *Handler) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, map[string]string{"status": HealthStatusOK}, http.StatusOK)
+}
+
+// ReadyzHandler проверяет зависимости и версию схемы и отвечает 503, если что-то недоступно
+func (h *Handler) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	var migrationVersion int
+	checks := h.readiness
+	if h.migrationVersion != nil {
+		checks = append(slices.Clip(checks), DependencyCheck{Name: "migrations", Check: func(ctx context.Context) (map[string]any, error) {
+			version, err := h.migrationVersion(ctx)
+			if err != nil {
+				return nil, err
+			}
+			migrationVersion = version
+			if version < utils.SchemaVersion {
+				return nil, fmt.Errorf("schema version %d is behind expected %d", version, utils.SchemaVersion)
+			}
+			return nil, nil
+		}})
+	}
+
+	resp := runReadinessChecks(r.Context(), checks, readinessCheckTimeout)
+	resp.MigrationVersion = migrationVersion
+
+	status := http.StatusOK
+	if resp.Status != HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJSON(w, resp, status)
+}
+
+// runReadinessChecks запускает проверки параллельно, каждую со своим таймаутом
+func runReadinessChecks(ctx context.Context, checks []DependencyCheck, timeout time.Duration) ReadinessResponse {
+	resp := ReadinessResponse{
+		Status: HealthStatusOK,
+		Checks: make(map[string]DependencyStatus, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := c.Check(checkCtx)
+			result := DependencyStatus{
+				Status:    HealthStatusOK,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+				Details:   details,
+			}
+			if err != nil {
+				result.Status = HealthStatusUnavailable
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Checks[c.Name] = result
+			if err != nil {
+				resp.Status = HealthStatusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	return resp
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthzHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(Deps{}).HealthzHandler(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("HealthzHandler() status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["status"] != HealthStatusOK {
+		t.Errorf("expected status %q, got %q", HealthStatusOK, body["status"])
+	}
+}
+
+func TestRunReadinessChecks(t *testing.T) {
+	ok := DependencyCheck{Name: "postgres", Check: func(context.Context) (map[string]any, error) {
+		return map[string]any{"maxConns": 4}, nil
+	}}
+	failing := DependencyCheck{Name: "redis", Check: func(context.Context) (map[string]any, error) {
+		return nil, errors.New("connection refused")
+	}}
+	hanging := DependencyCheck{Name: "redis", Check: func(ctx context.Context) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+
+	tests := []struct {
+		name       string
+		checks     []DependencyCheck
+		wantStatus string
+		wantRedis  string
+	}{
+		{name: "All dependencies up", checks: []DependencyCheck{ok}, wantStatus: HealthStatusOK},
+		{name: "Redis down", checks: []DependencyCheck{ok, failing}, wantStatus: HealthStatusUnavailable, wantRedis: HealthStatusUnavailable},
+		{name: "Redis hangs until timeout", checks: []DependencyCheck{ok, hanging}, wantStatus: HealthStatusUnavailable, wantRedis: HealthStatusUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			resp := runReadinessChecks(context.Background(), tt.checks, 50*time.Millisecond)
+
+			if time.Since(start) > time.Second {
+				t.Error("checks did not respect timeout")
+			}
+			if resp.Status != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, resp.Status)
+			}
+			if got := resp.Checks["postgres"]; got.Status != HealthStatusOK || got.Details["maxConns"] != 4 {
+				t.Errorf("unexpected postgres check: %+v", got)
+			}
+			if tt.wantRedis != "" {
+				got := resp.Checks["redis"]
+				if got.Status != tt.wantRedis || got.Error == "" {
+					t.Errorf("unexpected redis check: %+v", got)
+				}
+			}
+		})
+	}
+}
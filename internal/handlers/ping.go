@@ -5,6 +5,6 @@ import (
 	"net/http"
 )
 
-func PingHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PingHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, "pong")
 }
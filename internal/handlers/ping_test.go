@@ -10,7 +10,7 @@ func TestPingHandler(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
 	w := httptest.NewRecorder()
 
-	PingHandler(w, req)
+	testHandler().PingHandler(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("PingHandler() status = %v, want %v", w.Code, http.StatusOK)
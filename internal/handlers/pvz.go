@@ -1,20 +1,25 @@
 package handlers
 
 import (
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"net/http"
-	"time"
-
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/dto"
 	"github.com/kosttiik/pvz-service/internal/metrics"
 	"github.com/kosttiik/pvz-service/internal/models"
 	"github.com/kosttiik/pvz-service/internal/repository"
 	"github.com/kosttiik/pvz-service/internal/utils"
-	"github.com/kosttiik/pvz-service/pkg/database"
+	"github.com/kosttiik/pvz-service/internal/validation"
 	"github.com/kosttiik/pvz-service/pkg/logger"
+	pvz_v1 "github.com/kosttiik/pvz-service/proto"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 )
 
 // форматировние ответа согласно API
@@ -28,64 +33,62 @@ type ReceptionResponse struct {
 	Products  []models.Product `json:"products"`
 }
 
-func CreatePVZHandler(w http.ResponseWriter, r *http.Request) {
-	log := logger.Log
-	claims := utils.GetUserFromContext(r.Context())
-	if claims == nil {
-		log.Warn("Unauthorized attempt to create PVZ")
-		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
+type PVZListResponse []GetPVZListResponse
 
-	if string(claims.Role) != "moderator" {
-		log.Warn("Not moderator attempted to create PVZ",
-			zap.String("userID", claims.UserID),
-			zap.String("role", string(claims.Role)))
-		utils.WriteError(w, "Forbidden", http.StatusForbidden)
-		return
+func (l PVZListResponse) MarshalProto() ([]byte, error) {
+	msg := &pvz_v1.PVZWithReceptionsList{}
+	for _, item := range l {
+		pvz := &pvz_v1.PVZWithReceptions{Pvz: item.PVZ.ToProto()}
+		for _, rec := range item.Receptions {
+			reception := &pvz_v1.ReceptionWithProducts{Reception: rec.Reception.ToProto()}
+			for _, product := range rec.Products {
+				reception.Products = append(reception.Products, product.ToProto())
+			}
+			pvz.Receptions = append(pvz.Receptions, reception)
+		}
+		msg.Items = append(msg.Items, pvz)
 	}
+	return proto.Marshal(msg)
+}
 
-	var input struct {
-		City string `json:"city"`
-	}
+func (h *Handler) CreatePVZHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	claims := utils.GetUserFromContext(r.Context())
 
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	var input dto.CreatePVZRequest
+	if err := utils.Decode(r, &input); err != nil {
 		log.Warn("Failed to decode PVZ creation request", zap.Error(err))
-		utils.WriteError(w, "Invalid request", http.StatusBadRequest)
+		utils.WriteDecodeError(w, r, err)
 		return
 	}
 
-	if !models.AllowedCities[input.City] {
-		utils.WriteError(w, "City not allowed", http.StatusBadRequest)
+	if err := validation.Struct(input); err != nil {
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	id := uuid.New()
-	pvz := models.PVZ{
-		ID:               id,
-		City:             input.City,
-		RegistrationDate: time.Now().UTC(),
+	type result struct {
+		pvz *models.PVZ
+		err error
 	}
-
-	errChan := make(chan error, 1)
+	resultChan := make(chan result, 1)
 
 	go func() {
-		_, err := database.DB.Exec(r.Context(),
-			"INSERT INTO pvz (id, registration_date, city) VALUES ($1, $2, $3)",
-			pvz.ID, pvz.RegistrationDate, pvz.City,
-		)
-		errChan <- err
+		pvz, err := h.pvz.Create(r.Context(), claims, input.City)
+		resultChan <- result{pvz: pvz, err: err}
 	}()
 
+	var pvz *models.PVZ
 	select {
-	case err := <-errChan:
-		if err != nil {
-			utils.WriteError(w, "Failed to create pvz", http.StatusInternalServerError)
+	case res := <-resultChan:
+		if res.err != nil {
+			writeServiceError(w, r, res.err, "Failed to create pvz")
 			return
 		}
-		metrics.PvzCreatedTotal.Inc()
+		pvz = res.pvz
+		metrics.PvzCreatedTotal.WithLabelValues(pvz.City).Inc()
 	case <-r.Context().Done():
-		utils.WriteError(w, "Request timeout", http.StatusGatewayTimeout)
+		utils.WriteProblem(w, r, dto.CodeRequestTimeout, "Request timeout")
 		return
 	}
 
@@ -94,22 +97,13 @@ func CreatePVZHandler(w http.ResponseWriter, r *http.Request) {
 		zap.String("city", pvz.City),
 		zap.String("createdBy", claims.UserID))
 
-	utils.WriteJSON(w, pvz, http.StatusCreated)
+	w.Header().Set("ETag", utils.ETag(pvz.Version))
+	utils.Write(w, r, pvz, http.StatusCreated)
 }
 
-func GetPVZListHandler(w http.ResponseWriter, r *http.Request) {
-	log := logger.Log
+func (h *Handler) GetPVZListHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
 	claims := utils.GetUserFromContext(r.Context())
-	if claims == nil {
-		log.Warn("Unauthorized attempt to get PVZ list")
-		utils.WriteError(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	if string(claims.Role) != "employee" && string(claims.Role) != "moderator" {
-		utils.WriteError(w, "Forbidden", http.StatusForbidden)
-		return
-	}
 
 	query := r.URL.Query()
 
@@ -118,7 +112,7 @@ func GetPVZListHandler(w http.ResponseWriter, r *http.Request) {
 	if startDate := query.Get("startDate"); startDate != "" {
 		parsedTime, err := time.Parse(time.RFC3339, startDate)
 		if err != nil {
-			utils.WriteError(w, "Invalid format of start date", http.StatusBadRequest)
+			utils.WriteFieldError(w, r, "startDate", dto.FieldInvalidFormat, "Invalid format of start date")
 			return
 		}
 
@@ -128,7 +122,7 @@ func GetPVZListHandler(w http.ResponseWriter, r *http.Request) {
 	if endDate := query.Get("endDate"); endDate != "" {
 		parsedTime, err := time.Parse(time.RFC3339, endDate)
 		if err != nil {
-			utils.WriteError(w, "Invalid format of end date", http.StatusBadRequest)
+			utils.WriteFieldError(w, r, "endDate", dto.FieldInvalidFormat, "Invalid format of end date")
 			return
 		}
 
@@ -137,7 +131,7 @@ func GetPVZListHandler(w http.ResponseWriter, r *http.Request) {
 
 	if filter.StartDate != nil && filter.EndDate != nil {
 		if filter.EndDate.Before(*filter.StartDate) {
-			utils.WriteError(w, "End date cannot be before start date", http.StatusBadRequest)
+			utils.WriteFieldError(w, r, "endDate", dto.FieldOutOfRange, "End date cannot be before start date")
 			return
 		}
 	}
@@ -146,7 +140,7 @@ func GetPVZListHandler(w http.ResponseWriter, r *http.Request) {
 	if page := query.Get("page"); page != "" {
 		pageNum, err := strconv.Atoi(page)
 		if err != nil || pageNum < 1 {
-			utils.WriteError(w, "Invalid page number", http.StatusBadRequest)
+			utils.WriteFieldError(w, r, "page", dto.FieldOutOfRange, "Invalid page number")
 			return
 		}
 		filter.Page = pageNum
@@ -156,23 +150,15 @@ func GetPVZListHandler(w http.ResponseWriter, r *http.Request) {
 	if limit := query.Get("limit"); limit != "" {
 		limitNum, err := strconv.Atoi(limit)
 		if err != nil || limitNum < 1 || limitNum > 30 {
-			utils.WriteError(w, "Invalid limit", http.StatusBadRequest)
+			utils.WriteFieldError(w, r, "limit", dto.FieldOutOfRange, "Invalid limit")
 			return
 		}
 		filter.Limit = limitNum
 	}
 
-	log.Debug("Getting PVZ list",
-		zap.Any("filter", filter),
-		zap.String("requestedBy", claims.UserID))
-
-	pvzRepo := repository.NewPVZRepository(database.DB)
-	pvzList, err := pvzRepo.GetPVZ(r.Context(), filter)
+	pvzList, err := h.pvz.List(r.Context(), claims, filter)
 	if err != nil {
-		log.Error("Failed to get PVZ list",
-			zap.Error(err),
-			zap.Any("filter", filter))
-		utils.WriteError(w, "Failed to get PVZ list", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to get PVZ list")
 		return
 	}
 
@@ -180,7 +166,7 @@ func GetPVZListHandler(w http.ResponseWriter, r *http.Request) {
 		zap.Int("count", len(pvzList)),
 		zap.String("requestedBy", claims.UserID))
 
-	response := make([]GetPVZListResponse, 0)
+	response := make(PVZListResponse, 0)
 	for _, pvz := range pvzList {
 		pvzResponse := GetPVZListResponse{
 			PVZ:        pvz.PVZ,
@@ -198,5 +184,45 @@ func GetPVZListHandler(w http.ResponseWriter, r *http.Request) {
 		response = append(response, pvzResponse)
 	}
 
-	utils.WriteJSON(w, response, http.StatusOK)
+	// Тег общий для всех форматов, поэтому Vary нужен и у 304, и у 200
+	etag := pvzListETag(filter, response)
+	w.Header().Set("ETag", etag)
+	utils.VaryAccept(w.Header())
+	if utils.NotModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	utils.Write(w, r, response, http.StatusOK)
+}
+
+// pvzListETag строится из фильтра и версий ПВЗ и приемок страницы: любое изменение
+// приемки или ее товаров увеличивает обе версии. Тег слабый, потому что одинаков
+// для всех форматов ответа
+func pvzListETag(filter repository.GetPVZFilter, list PVZListResponse) string {
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s:%d", item.PVZ.ID, item.PVZ.Version)
+		for _, rec := range item.Receptions {
+			fmt.Fprintf(&b, ",%s:%d", rec.Reception.ID, rec.Reception.Version)
+		}
+		items = append(items, b.String())
+	}
+	// Порядок ПВЗ в ответе не гарантирован, на тег он влиять не должен
+	slices.Sort(items)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%v|%v|%d|%d\n", formatTime(filter.StartDate), formatTime(filter.EndDate), filter.Page, filter.Limit)
+	for _, item := range items {
+		fmt.Fprintln(hash, item)
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
 }
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	maxImportSize = utils.MaxBodySize
+	maxImportRows = 10000
+)
+
+const (
+	ImportStatusCreated  = "created"
+	ImportStatusValid    = "valid"
+	ImportStatusRejected = "rejected"
+)
+
+type ImportRowResult struct {
+	// Номер строки в файле, заголовок - строка 1
+	Row    int         `json:"row"`
+	Status string      `json:"status"`
+	PVZ    *models.PVZ `json:"pvz,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	// IgnoredColumns - заполненные в строке колонки, которые сервис не хранит
+	IgnoredColumns []string `json:"ignoredColumns,omitempty"`
+}
+
+type ImportPVZResponse struct {
+	DryRun   bool              `json:"dryRun"`
+	Total    int               `json:"total"`
+	Created  int               `json:"created"`
+	Rejected int               `json:"rejected"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+// importColumns - колонки файла импорта, которые сохраняются в ПВЗ
+var importColumns = map[string]bool{
+	"city":             true,
+	"id":               true,
+	"registrationdate": true,
+}
+
+type importRow struct {
+	line    int
+	pvz     models.PVZ
+	ignored []string
+}
+
+// ImportPVZHandler принимает CSV с колонками city (обязательная), id и registrationDate.
+// Другие колонки сервис не хранит: строки с ними импортируются, а заполненные колонки
+// перечисляются в отчете по строке.
+// Файл передается телом запроса (text/csv) или полем file в multipart/form-data
+func (h *Handler) ImportPVZHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	claims := utils.GetUserFromContext(r.Context())
+	if claims == nil {
+		log.Warn("Unauthorized attempt to import PVZ")
+		utils.WriteProblem(w, r, dto.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.WriteFieldError(w, r, "dryRun", dto.FieldInvalidFormat, "Invalid dryRun value")
+			return
+		}
+		dryRun = parsed
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	body, err := importBody(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.WriteProblem(w, r, dto.CodePayloadTooLarge, "File is too large")
+			return
+		}
+		log.Warn("Failed to read PVZ import file", zap.Error(err))
+		utils.WriteProblem(w, r, dto.CodeInvalidRequest, "Invalid request")
+		return
+	}
+	defer body.Close()
+
+	rows, results, err := parsePVZImport(body, time.Now().UTC())
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.WriteProblem(w, r, dto.CodePayloadTooLarge, "File is too large")
+			return
+		}
+		utils.WriteProblem(w, r, dto.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	pvzs := make([]models.PVZ, len(rows))
+	for i, row := range rows {
+		pvzs[i] = row.pvz
+	}
+
+	created, err := h.pvzRepo.Import(r.Context(), pvzs, dryRun)
+	if err != nil {
+		log.Error("Failed to import PVZ",
+			zap.Error(err),
+			zap.Int("rows", len(pvzs)),
+			zap.Bool("dryRun", dryRun))
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to import pvz")
+		return
+	}
+
+	createdStatus := ImportStatusCreated
+	if dryRun {
+		createdStatus = ImportStatusValid
+	}
+
+	for i, row := range rows {
+		// Import проставил версию созданным ПВЗ
+		pvz := pvzs[i]
+		if created[i] {
+			if !dryRun {
+				metrics.PvzCreatedTotal.WithLabelValues(pvz.City).Inc()
+			}
+			results = append(results, ImportRowResult{Row: row.line, Status: createdStatus, PVZ: &pvz, IgnoredColumns: row.ignored})
+		} else {
+			results = append(results, ImportRowResult{Row: row.line, Status: ImportStatusRejected, PVZ: &pvz, Error: "PVZ already exists"})
+		}
+	}
+
+	resp := buildImportResponse(results, dryRun)
+
+	log.Info("PVZ import finished",
+		zap.Bool("dryRun", dryRun),
+		zap.Int("total", resp.Total),
+		zap.Int("created", resp.Created),
+		zap.Int("rejected", resp.Rejected),
+		zap.String("importedBy", claims.UserID))
+
+	utils.Write(w, r, resp, http.StatusOK)
+}
+
+func importBody(r *http.Request) (io.ReadCloser, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// parsePVZImport разбирает CSV и проверяет каждую строку. Возвращает строки, готовые
+// к записи, и отчет по отклоненным. Ошибка означает, что файл не удалось разобрать целиком
+func parsePVZImport(body io.Reader, now time.Time) ([]importRow, []ImportRowResult, error) {
+	reader := bufio.NewReader(body)
+	// Excel сохраняет CSV в UTF-8 с BOM
+	if bom, err := reader.Peek(len(utf8BOM)); err == nil && bytes.Equal(bom, []byte(utf8BOM)) {
+		reader.Discard(len(utf8BOM))
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	columns := make(map[string]int)
+	unknown := make(map[int]string)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		columns[key] = i
+		if !importColumns[key] && name != "" {
+			unknown[i] = name
+		}
+	}
+	cityColumn, ok := columns["city"]
+	if !ok {
+		return nil, nil, errors.New("missing required column city")
+	}
+	idColumn, hasID := columns["id"]
+	dateColumn, hasDate := columns["registrationdate"]
+
+	field := func(record []string, column int) string {
+		if column >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[column])
+	}
+
+	var rows []importRow
+	var rejected []ImportRowResult
+	seen := make(map[uuid.UUID]int)
+
+	for line := 2; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+			}
+			return nil, nil, err
+		}
+		if line-1 > maxImportRows {
+			return nil, nil, fmt.Errorf("too many rows, maximum is %d", maxImportRows)
+		}
+
+		reject := func(reason string) {
+			rejected = append(rejected, ImportRowResult{Row: line, Status: ImportStatusRejected, Error: reason})
+		}
+
+		pvz := models.PVZ{
+			ID:               uuid.New(),
+			City:             field(record, cityColumn),
+			RegistrationDate: now,
+		}
+
+		if !models.AllowedCities[pvz.City] {
+			reject("City not allowed")
+			continue
+		}
+
+		if hasID {
+			if value := field(record, idColumn); value != "" {
+				id, err := uuid.Parse(value)
+				if err != nil {
+					reject("Invalid id")
+					continue
+				}
+				pvz.ID = id
+			}
+		}
+
+		if hasDate {
+			if value := field(record, dateColumn); value != "" {
+				date, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					reject("Invalid format of registration date")
+					continue
+				}
+				pvz.RegistrationDate = date.UTC()
+			}
+		}
+
+		if first, ok := seen[pvz.ID]; ok {
+			reject(fmt.Sprintf("Duplicate id, already used in row %d", first))
+			continue
+		}
+		seen[pvz.ID] = line
+
+		rows = append(rows, importRow{line: line, pvz: pvz, ignored: filledColumns(record, unknown)})
+	}
+
+	if len(rows) == 0 && len(rejected) == 0 {
+		return nil, nil, errors.New("file has no rows")
+	}
+
+	return rows, rejected, nil
+}
+
+// filledColumns возвращает имена колонок из columns, заполненных в строке, по порядку файла
+func filledColumns(record []string, columns map[int]string) []string {
+	var names []string
+	for i, value := range record {
+		if name, ok := columns[i]; ok && strings.TrimSpace(value) != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func buildImportResponse(results []ImportRowResult, dryRun bool) ImportPVZResponse {
+	// Отчет по порядку строк файла
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Row < results[j].Row
+	})
+
+	resp := ImportPVZResponse{
+		DryRun: dryRun,
+		Total:  len(results),
+		Rows:   results,
+	}
+	for _, result := range results {
+		if result.Status == ImportStatusRejected {
+			resp.Rejected++
+		} else {
+			resp.Created++
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParsePVZImport(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	id := uuid.New()
+
+	csvData := utf8BOM + "City,id,registrationDate,Address,lat\n" +
+		"Москва,,\n" +
+		"Новосибирск,,\n" +
+		"Казань," + id.String() + ",2025-01-02T10:00:00Z\n" +
+		"Казань," + id.String() + ",\n" +
+		"Москва,not-a-uuid,\n" +
+		"Санкт-Петербург,,yesterday\n" +
+		"Москва,,,\"ул. Ленина, 1\",55.75\n" +
+		"Казань,,, ,\n"
+
+	rows, rejected, err := parsePVZImport(strings.NewReader(csvData), now)
+	if err != nil {
+		t.Fatalf("parsePVZImport() error = %v", err)
+	}
+
+	if len(rows) != 4 {
+		t.Fatalf("Got %d valid rows, want 4", len(rows))
+	}
+	if rows[0].line != 2 || rows[0].pvz.City != "Москва" || !rows[0].pvz.RegistrationDate.Equal(now) {
+		t.Errorf("Unexpected first row: %+v", rows[0])
+	}
+	if rows[1].pvz.ID != id || rows[1].pvz.RegistrationDate.Day() != 2 {
+		t.Errorf("Id and registration date should be taken from file: %+v", rows[1])
+	}
+
+	// Заполненные колонки, которых сервис не хранит, не мешают импорту и попадают в отчет
+	if rows[2].line != 8 || strings.Join(rows[2].ignored, ",") != "Address,lat" {
+		t.Errorf("Row 8 ignored columns = %v, want Address and lat", rows[2].ignored)
+	}
+	if rows[3].line != 9 || len(rows[3].ignored) != 0 {
+		t.Errorf("Row 9 ignored columns = %v, want none", rows[3].ignored)
+	}
+
+	wantRejected := map[int]string{
+		3: "City not allowed",
+		5: "Duplicate id, already used in row 4",
+		6: "Invalid id",
+		7: "Invalid format of registration date",
+	}
+	if len(rejected) != len(wantRejected) {
+		t.Fatalf("Got %d rejected rows, want %d", len(rejected), len(wantRejected))
+	}
+	for _, result := range rejected {
+		if want := wantRejected[result.Row]; result.Error != want {
+			t.Errorf("Row %d error = %q, want %q", result.Row, result.Error, want)
+		}
+	}
+}
+
+func TestParsePVZImport_InvalidFile(t *testing.T) {
+	tests := map[string]string{
+		"Empty":          "",
+		"No city column": "id\n" + uuid.NewString() + "\n",
+		"Header only":    "city\n",
+		"Broken quotes":  "city\n\"Москва\n",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := parsePVZImport(strings.NewReader(data), time.Now()); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestImportPVZHandler(t *testing.T) {
+	existingID := createTestPVZ(t)
+
+	tests := []struct {
+		name         string
+		query        string
+		body         string
+		wantStatus   int
+		wantCreated  int
+		wantRejected int
+	}{
+		{"Import", "", "city\nМосква\nКазань\nМинск\n", http.StatusOK, 2, 1},
+		{"Dry run", "?dryRun=true", "city\nМосква\n", http.StatusOK, 1, 0},
+		{"Existing id", "", "city,id\nМосква," + existingID + "\n", http.StatusOK, 0, 1},
+		{"Invalid dryRun", "?dryRun=maybe", "city\nМосква\n", http.StatusBadRequest, 0, 0},
+		{"Missing city column", "", "name\nМосква\n", http.StatusBadRequest, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/pvz/import"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "text/csv")
+			req = getTestToken(t, "moderator", req)
+			w := httptest.NewRecorder()
+
+			testHandler().ImportPVZHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("ImportPVZHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var resp ImportPVZResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Created != tt.wantCreated || resp.Rejected != tt.wantRejected {
+				t.Errorf("Got created = %d, rejected = %d, want %d and %d",
+					resp.Created, resp.Rejected, tt.wantCreated, tt.wantRejected)
+			}
+		})
+	}
+}
+
+func TestImportPVZHandlerReport(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/pvz/import", strings.NewReader("city,address\nМосква,\"ул. Ленина, 1\"\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	req = getTestToken(t, "moderator", req)
+	w := httptest.NewRecorder()
+
+	testHandler().ImportPVZHandler(w, req)
+
+	var resp ImportPVZResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || len(resp.Rows) != 1 {
+		t.Fatalf("status = %v, response = %+v, error = %v", w.Code, resp, err)
+	}
+	row := resp.Rows[0]
+	if row.Status != ImportStatusCreated || row.PVZ == nil || row.PVZ.Version != 1 {
+		t.Errorf("Got row %+v, want created PVZ with version 1", row)
+	}
+	if len(row.IgnoredColumns) != 1 || row.IgnoredColumns[0] != "address" {
+		t.Errorf("Ignored columns = %v, want [address]", row.IgnoredColumns)
+	}
+}
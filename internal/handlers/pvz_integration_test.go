@@ -0,0 +1,216 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	handlertest "github.com/kosttiik/pvz-service/internal/handlers/internal/test"
+	"github.com/kosttiik/pvz-service/internal/models"
+)
+
+func init() {
+	handlertest.Init()
+}
+
+func TestIntegrationCreatePVZHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		city       string
+		role       string
+		wantStatus int
+	}{
+		{
+			name:       "Valid PVZ creation",
+			city:       "Москва",
+			role:       "moderator",
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Invalid role",
+			city:       "Москва",
+			role:       "employee",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Invalid city",
+			city:       "Маскваааааа",
+			role:       "moderator",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "No auth token",
+			city:       "Москва",
+			role:       "",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := map[string]string{"city": tt.city}
+			jsonBody, _ := json.Marshal(body)
+			req := httptest.NewRequest(http.MethodPost, "/pvz", bytes.NewBuffer(jsonBody))
+			if tt.role != "" {
+				req = getDBTestToken(t, tt.role, req)
+			}
+			w := httptest.NewRecorder()
+
+			dbTestHandler().CreatePVZHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreatePVZHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			if w.Code == http.StatusCreated {
+				var pvz models.PVZ
+				if err := json.NewDecoder(w.Body).Decode(&pvz); err != nil {
+					t.Errorf("Failed to decode response: %v", err)
+				}
+				if pvz.City != tt.city {
+					t.Errorf("Got city = %v, want %v", pvz.City, tt.city)
+				}
+			}
+		})
+	}
+}
+
+func TestIntegrationGetPVZListHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		query      string
+		setupData  bool
+		wantStatus int
+	}{
+		{
+			name:       "Valid request employee",
+			role:       "employee",
+			query:      "?page=1&limit=10",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Valid request moderator",
+			role:       "moderator",
+			query:      "?page=1&limit=10",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid page",
+			role:       "employee",
+			query:      "?page=0&limit=10",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid limit",
+			role:       "employee",
+			query:      "?page=1&limit=50",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid date format",
+			role:       "employee",
+			query:      "?startDate=invalid-date",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "End date before start date",
+			role:       "employee",
+			query:      "?startDate=2025-01-02T00:00:00Z&endDate=2024-01-01T00:00:00Z",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "With data",
+			role:       "employee",
+			query:      "?page=1&limit=10",
+			setupData:  true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "No auth",
+			role:       "",
+			query:      "?page=1&limit=10",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Invalid role",
+			role:       "invalid",
+			query:      "?page=1&limit=10",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Zero limit",
+			role:       "employee",
+			query:      "?page=1&limit=0",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setupData {
+				createDBTestPVZ(t)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/pvz"+tt.query, nil)
+			if tt.role != "" {
+				req = getDBTestToken(t, tt.role, req)
+			}
+
+			w := httptest.NewRecorder()
+			dbTestHandler().GetPVZListHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("GetPVZListHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			if w.Code == http.StatusOK {
+				var response []struct {
+					PVZ        models.PVZ `json:"pvz"`
+					Receptions []struct {
+						Reception models.Reception `json:"reception"`
+						Products  []models.Product `json:"products"`
+					} `json:"receptions"`
+				}
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Errorf("Failed to decode response: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestIntegrationGetPVZListConditional(t *testing.T) {
+	pvzID := createDBTestPVZ(t)
+
+	list := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/pvz?page=1&limit=30", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		req = getDBTestToken(t, "moderator", req)
+		w := httptest.NewRecorder()
+		dbTestHandler().GetPVZListHandler(w, req)
+		return w
+	}
+
+	first := list("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first request = %v, ETag %q", first.Code, etag)
+	}
+
+	if w := list(etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("request with current ETag = %v with %d bytes, want empty 304", w.Code, w.Body.Len())
+	}
+
+	// Новая приемка меняет версию ПВЗ, а значит и тег списка
+	createDBTestReception(t, pvzID, false)
+	w := list(etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("request after change = %v, ETag %q, want 200 with new ETag", w.Code, w.Header().Get("ETag"))
+	}
+}
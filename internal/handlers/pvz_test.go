@@ -5,16 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
-	handlertest "github.com/kosttiik/pvz-service/internal/handlers/internal/test"
 	"github.com/kosttiik/pvz-service/internal/models"
 )
 
-func init() {
-	handlertest.Init()
-}
-
 func TestCreatePVZHandler(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -58,7 +54,7 @@ func TestCreatePVZHandler(t *testing.T) {
 			}
 			w := httptest.NewRecorder()
 
-			CreatePVZHandler(w, req)
+			testHandler().CreatePVZHandler(w, req)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("CreatePVZHandler() status = %v, want %v", w.Code, tt.wantStatus)
@@ -159,7 +155,7 @@ func TestGetPVZListHandler(t *testing.T) {
 			}
 
 			w := httptest.NewRecorder()
-			GetPVZListHandler(w, req)
+			testHandler().GetPVZListHandler(w, req)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("GetPVZListHandler() status = %v, want %v", w.Code, tt.wantStatus)
@@ -180,3 +176,43 @@ func TestGetPVZListHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPVZListConditional(t *testing.T) {
+	pvzID := createTestPVZ(t)
+
+	list := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/pvz?page=1&limit=30", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		req = getTestToken(t, "moderator", req)
+		w := httptest.NewRecorder()
+		testHandler().GetPVZListHandler(w, req)
+		return w
+	}
+
+	first := list("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first request = %v, ETag %q", first.Code, etag)
+	}
+
+	notModified := list(etag)
+	if notModified.Code != http.StatusNotModified || notModified.Body.Len() != 0 {
+		t.Fatalf("request with current ETag = %v with %d bytes, want empty 304", notModified.Code, notModified.Body.Len())
+	}
+
+	// Тег слабый и общий для форматов, кеш должен различать ответы по Accept
+	for _, w := range []*httptest.ResponseRecorder{first, notModified} {
+		if vary := w.Header().Values("Vary"); !slices.Equal(vary, []string{"Accept"}) {
+			t.Errorf("%d response Vary = %q, want Accept", w.Code, vary)
+		}
+	}
+
+	// Новая приемка меняет версию ПВЗ, а значит и тег списка
+	createTestReception(t, pvzID, false)
+	w := list(etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("request after change = %v, ETag %q, want 200 with new ETag", w.Code, w.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,358 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	handlertest "github.com/kosttiik/pvz-service/internal/handlers/internal/test"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/pkg/database"
+)
+
+func init() {
+	handlertest.Init()
+}
+
+func createDBTestReception(t *testing.T, pvzID string, expectError bool) string {
+	body := map[string]string{"pvzId": pvzID}
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/receptions", bytes.NewBuffer(jsonBody))
+	req = getDBTestToken(t, "employee", req)
+	w := httptest.NewRecorder()
+
+	dbTestHandler().CreateReceptionHandler(w, req)
+
+	if !expectError && w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create test reception: status = %v", w.Code)
+	}
+
+	if w.Code == http.StatusCreated {
+		var reception models.Reception
+		if err := json.NewDecoder(w.Body).Decode(&reception); err != nil {
+			t.Fatalf("Failed to decode reception response: %v", err)
+		}
+		return reception.ID.String()
+	}
+	return ""
+}
+
+func TestIntegrationCreateReceptionHandler(t *testing.T) {
+	pvzID := createDBTestPVZ(t)
+
+	tests := []struct {
+		name       string
+		pvzID      string
+		role       string
+		hasOpen    bool
+		wantStatus int
+	}{
+		{
+			name:       "Valid reception creation",
+			pvzID:      pvzID,
+			role:       "employee",
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Invalid role",
+			pvzID:      uuid.New().String(),
+			role:       "moderator",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Invalid PVZ ID",
+			pvzID:      "invalid-uuid",
+			role:       "employee",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Already has open reception",
+			pvzID:      pvzID,
+			role:       "employee",
+			hasOpen:    true,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "No auth token",
+			pvzID:      pvzID,
+			role:       "",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Очищаем таблицу приемок перед каждым тестом
+			database.DB.Exec(context.Background(), "TRUNCATE reception CASCADE")
+
+			if tt.hasOpen {
+				// Создаем приемку
+				createDBTestReception(t, tt.pvzID, false)
+			}
+
+			body := map[string]string{"pvzId": tt.pvzID}
+			jsonBody, _ := json.Marshal(body)
+			req := httptest.NewRequest(http.MethodPost, "/receptions", bytes.NewBuffer(jsonBody))
+
+			if tt.role != "" {
+				req = getDBTestToken(t, tt.role, req)
+			}
+
+			w := httptest.NewRecorder()
+			dbTestHandler().CreateReceptionHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateReceptionHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			if w.Code == http.StatusCreated {
+				var reception models.Reception
+				if err := json.NewDecoder(w.Body).Decode(&reception); err != nil {
+					t.Errorf("Failed to decode response: %v", err)
+				}
+				if reception.PvzID != tt.pvzID {
+					t.Errorf("Got pvzID = %v, want %v", reception.PvzID, tt.pvzID)
+				}
+			}
+		})
+	}
+}
+
+func TestIntegrationAddProductHandler(t *testing.T) {
+	pvzID := createDBTestPVZ(t)
+	_ = createDBTestReception(t, pvzID, false) // Сначала создаем приемку
+
+	tests := []struct {
+		name        string
+		productType string
+		pvzID       string
+		role        string
+		wantStatus  int
+	}{
+		{
+			name:        "Valid product",
+			productType: "электроника",
+			pvzID:       pvzID,
+			role:        "employee",
+			wantStatus:  http.StatusCreated,
+		},
+		{
+			name:        "Invalid product type",
+			productType: "invalid",
+			pvzID:       pvzID,
+			role:        "employee",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "Invalid role",
+			productType: "электроника",
+			pvzID:       pvzID,
+			role:        "moderator",
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "No auth token",
+			productType: "электроника",
+			pvzID:       pvzID,
+			role:        "",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "Invalid PVZ ID",
+			productType: "электроника",
+			pvzID:       "invalid-uuid",
+			role:        "employee",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "Not existed PVZ",
+			productType: "электроника",
+			pvzID:       uuid.New().String(),
+			role:        "employee",
+			wantStatus:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := map[string]string{
+				"type":  tt.productType,
+				"pvzId": tt.pvzID,
+			}
+			jsonBody, _ := json.Marshal(body)
+			req := httptest.NewRequest(http.MethodPost, "/products", bytes.NewBuffer(jsonBody))
+			if tt.role != "" {
+				req = getDBTestToken(t, tt.role, req)
+			}
+			w := httptest.NewRecorder()
+
+			dbTestHandler().AddProductHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("AddProductHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestIntegrationDeleteLastProductHandler(t *testing.T) {
+	pvzID := createDBTestPVZ(t)
+	_ = createDBTestReception(t, pvzID, false) // Сначала создаем приемку
+
+	// Создаем тестовый продукт
+	body := map[string]string{
+		"type":  "электроника",
+		"pvzId": pvzID,
+	}
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/products", bytes.NewBuffer(jsonBody))
+	req = getDBTestToken(t, "employee", req)
+	w := httptest.NewRecorder()
+	dbTestHandler().AddProductHandler(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create test product: status = %v", w.Code)
+	}
+
+	tests := []struct {
+		name       string
+		role       string
+		wantStatus int
+	}{
+		{
+			name:       "Valid deletion",
+			role:       "employee",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid role",
+			role:       "moderator",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/pvz/%s/delete_last_product", pvzID), nil)
+			req.SetPathValue("pvzId", pvzID)
+			req = getDBTestToken(t, tt.role, req)
+			w := httptest.NewRecorder()
+
+			dbTestHandler().DeleteLastProductHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("DeleteLastProductHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestIntegrationCloseReceptionHandler(t *testing.T) {
+	pvzID := createDBTestPVZ(t)
+	_ = createDBTestReception(t, pvzID, false)
+
+	tests := []struct {
+		name       string
+		role       string
+		wantStatus int
+	}{
+		{
+			name:       "Valid closure",
+			role:       "employee",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid role",
+			role:       "moderator",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/pvz/%s/close_last_reception", pvzID), nil)
+			req.SetPathValue("pvzId", pvzID)
+			req = getDBTestToken(t, tt.role, req)
+			w := httptest.NewRecorder()
+
+			dbTestHandler().CloseReceptionHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("CloseReceptionHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			if w.Code == http.StatusOK {
+				var reception models.Reception
+				if err := json.NewDecoder(w.Body).Decode(&reception); err != nil {
+					t.Errorf("Failed to decode response: %v", err)
+				}
+				if reception.Status != models.StatusClosed {
+					t.Errorf("Got status = %v, want %v", reception.Status, models.StatusClosed)
+				}
+			}
+		})
+	}
+}
+
+func TestIntegrationReceptionPreconditions(t *testing.T) {
+	pvzID := createDBTestPVZ(t)
+
+	send := func(path, ifMatch string, body any, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(http.MethodPost, path, &buf)
+		req.SetPathValue("pvzId", pvzID)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		req = getDBTestToken(t, "employee", req)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	openReception := func(ifMatch string) *httptest.ResponseRecorder {
+		return send("/receptions", ifMatch, map[string]string{"pvzId": pvzID}, dbTestHandler().CreateReceptionHandler)
+	}
+	addProduct := func(ifMatch string) *httptest.ResponseRecorder {
+		body := map[string]string{"pvzId": pvzID, "type": "электроника"}
+		return send("/products", ifMatch, body, dbTestHandler().AddProductHandler)
+	}
+	closeReception := func(ifMatch string) *httptest.ResponseRecorder {
+		path := fmt.Sprintf("/pvz/%s/close_last_reception", pvzID)
+		return send(path, ifMatch, nil, dbTestHandler().CloseReceptionHandler)
+	}
+
+	// Открытие приемки сверяется с версией ПВЗ, новый ПВЗ создается с версией 1
+	if w := openReception(`"100"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("open with stale If-Match status = %v, want %v", w.Code, http.StatusPreconditionFailed)
+	}
+	if w := openReception(`"1"`); w.Code != http.StatusCreated || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("open with current If-Match = %v %q, want 201 and %q", w.Code, w.Header().Get("ETag"), `"1"`)
+	}
+
+	// Товары и закрытие сверяются с версией приемки, без If-Match версия не проверяется
+	if w := addProduct(""); w.Code != http.StatusCreated || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("add without If-Match = %v %q, want 201 and %q", w.Code, w.Header().Get("ETag"), `"2"`)
+	}
+	if w := addProduct(`"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("add with stale If-Match status = %v, want %v", w.Code, http.StatusPreconditionFailed)
+	}
+	if w := addProduct(`"2"`); w.Code != http.StatusCreated || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("add with current If-Match = %v %q, want 201 and %q", w.Code, w.Header().Get("ETag"), `"3"`)
+	}
+	if w := closeReception(`"2"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("close with stale If-Match status = %v, want %v", w.Code, http.StatusPreconditionFailed)
+	}
+	if w := closeReception(`"3"`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"4"` {
+		t.Fatalf("close with current If-Match = %v %q, want 200 and %q", w.Code, w.Header().Get("ETag"), `"4"`)
+	}
+}
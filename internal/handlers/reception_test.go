@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,15 +9,9 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
-	handlertest "github.com/kosttiik/pvz-service/internal/handlers/internal/test"
 	"github.com/kosttiik/pvz-service/internal/models"
-	"github.com/kosttiik/pvz-service/pkg/database"
 )
 
-func init() {
-	handlertest.Init()
-}
-
 func createTestReception(t *testing.T, pvzID string, expectError bool) string {
 	body := map[string]string{"pvzId": pvzID}
 	jsonBody, _ := json.Marshal(body)
@@ -26,7 +19,7 @@ func createTestReception(t *testing.T, pvzID string, expectError bool) string {
 	req = getTestToken(t, "employee", req)
 	w := httptest.NewRecorder()
 
-	CreateReceptionHandler(w, req)
+	testHandler().CreateReceptionHandler(w, req)
 
 	if !expectError && w.Code != http.StatusCreated {
 		t.Fatalf("Failed to create test reception: status = %v", w.Code)
@@ -43,8 +36,7 @@ func createTestReception(t *testing.T, pvzID string, expectError bool) string {
 }
 
 func TestCreateReceptionHandler(t *testing.T) {
-	pvzID := createTestPVZ(t)
-
+	// Пустой pvzID - новый ПВЗ без приемок
 	tests := []struct {
 		name       string
 		pvzID      string
@@ -54,7 +46,7 @@ func TestCreateReceptionHandler(t *testing.T) {
 	}{
 		{
 			name:       "Valid reception creation",
-			pvzID:      pvzID,
+			pvzID:      "",
 			role:       "employee",
 			wantStatus: http.StatusCreated,
 		},
@@ -72,14 +64,14 @@ func TestCreateReceptionHandler(t *testing.T) {
 		},
 		{
 			name:       "Already has open reception",
-			pvzID:      pvzID,
+			pvzID:      "",
 			role:       "employee",
 			hasOpen:    true,
 			wantStatus: http.StatusBadRequest,
 		},
 		{
 			name:       "No auth token",
-			pvzID:      pvzID,
+			pvzID:      "",
 			role:       "",
 			wantStatus: http.StatusUnauthorized,
 		},
@@ -87,15 +79,17 @@ func TestCreateReceptionHandler(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Очищаем таблицу приемок перед каждым тестом
-			database.DB.Exec(context.Background(), "TRUNCATE reception CASCADE")
+			pvzID := tt.pvzID
+			if pvzID == "" {
+				pvzID = createTestPVZ(t)
+			}
 
 			if tt.hasOpen {
 				// Создаем приемку
-				createTestReception(t, tt.pvzID, false)
+				createTestReception(t, pvzID, false)
 			}
 
-			body := map[string]string{"pvzId": tt.pvzID}
+			body := map[string]string{"pvzId": pvzID}
 			jsonBody, _ := json.Marshal(body)
 			req := httptest.NewRequest(http.MethodPost, "/receptions", bytes.NewBuffer(jsonBody))
 
@@ -104,7 +98,7 @@ func TestCreateReceptionHandler(t *testing.T) {
 			}
 
 			w := httptest.NewRecorder()
-			CreateReceptionHandler(w, req)
+			testHandler().CreateReceptionHandler(w, req)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("CreateReceptionHandler() status = %v, want %v", w.Code, tt.wantStatus)
@@ -115,8 +109,8 @@ func TestCreateReceptionHandler(t *testing.T) {
 				if err := json.NewDecoder(w.Body).Decode(&reception); err != nil {
 					t.Errorf("Failed to decode response: %v", err)
 				}
-				if reception.PvzID != tt.pvzID {
-					t.Errorf("Got pvzID = %v, want %v", reception.PvzID, tt.pvzID)
+				if reception.PvzID != pvzID {
+					t.Errorf("Got pvzID = %v, want %v", reception.PvzID, pvzID)
 				}
 			}
 		})
@@ -191,7 +185,7 @@ func TestAddProductHandler(t *testing.T) {
 			}
 			w := httptest.NewRecorder()
 
-			AddProductHandler(w, req)
+			testHandler().AddProductHandler(w, req)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("AddProductHandler() status = %v, want %v", w.Code, tt.wantStatus)
@@ -213,7 +207,7 @@ func TestDeleteLastProductHandler(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/products", bytes.NewBuffer(jsonBody))
 	req = getTestToken(t, "employee", req)
 	w := httptest.NewRecorder()
-	AddProductHandler(w, req)
+	testHandler().AddProductHandler(w, req)
 
 	if w.Code != http.StatusCreated {
 		t.Fatalf("Failed to create test product: status = %v", w.Code)
@@ -239,10 +233,11 @@ func TestDeleteLastProductHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/pvz/%s/delete_last_product", pvzID), nil)
+			req.SetPathValue("pvzId", pvzID)
 			req = getTestToken(t, tt.role, req)
 			w := httptest.NewRecorder()
 
-			DeleteLastProductHandler(w, req)
+			testHandler().DeleteLastProductHandler(w, req)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("DeleteLastProductHandler() status = %v, want %v", w.Code, tt.wantStatus)
@@ -275,10 +270,11 @@ func TestCloseReceptionHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/pvz/%s/close_last_reception", pvzID), nil)
+			req.SetPathValue("pvzId", pvzID)
 			req = getTestToken(t, tt.role, req)
 			w := httptest.NewRecorder()
 
-			CloseReceptionHandler(w, req)
+			testHandler().CloseReceptionHandler(w, req)
 
 			if w.Code != tt.wantStatus {
 				t.Errorf("CloseReceptionHandler() status = %v, want %v", w.Code, tt.wantStatus)
@@ -296,3 +292,59 @@ func TestCloseReceptionHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestReceptionPreconditions(t *testing.T) {
+	pvzID := createTestPVZ(t)
+
+	send := func(path, ifMatch string, body any, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(http.MethodPost, path, &buf)
+		req.SetPathValue("pvzId", pvzID)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		req = getTestToken(t, "employee", req)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	openReception := func(ifMatch string) *httptest.ResponseRecorder {
+		return send("/receptions", ifMatch, map[string]string{"pvzId": pvzID}, testHandler().CreateReceptionHandler)
+	}
+	addProduct := func(ifMatch string) *httptest.ResponseRecorder {
+		body := map[string]string{"pvzId": pvzID, "type": "электроника"}
+		return send("/products", ifMatch, body, testHandler().AddProductHandler)
+	}
+	closeReception := func(ifMatch string) *httptest.ResponseRecorder {
+		path := fmt.Sprintf("/pvz/%s/close_last_reception", pvzID)
+		return send(path, ifMatch, nil, testHandler().CloseReceptionHandler)
+	}
+
+	// Открытие приемки сверяется с версией ПВЗ, новый ПВЗ создается с версией 1
+	if w := openReception(`"100"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("open with stale If-Match status = %v, want %v", w.Code, http.StatusPreconditionFailed)
+	}
+	if w := openReception(`"1"`); w.Code != http.StatusCreated || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("open with current If-Match = %v %q, want 201 and %q", w.Code, w.Header().Get("ETag"), `"1"`)
+	}
+
+	// Товары и закрытие сверяются с версией приемки, без If-Match версия не проверяется
+	if w := addProduct(""); w.Code != http.StatusCreated || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("add without If-Match = %v %q, want 201 and %q", w.Code, w.Header().Get("ETag"), `"2"`)
+	}
+	if w := addProduct(`"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("add with stale If-Match status = %v, want %v", w.Code, http.StatusPreconditionFailed)
+	}
+	if w := addProduct(`"2"`); w.Code != http.StatusCreated || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("add with current If-Match = %v %q, want 201 and %q", w.Code, w.Header().Get("ETag"), `"3"`)
+	}
+	if w := closeReception(`"2"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("close with stale If-Match status = %v, want %v", w.Code, http.StatusPreconditionFailed)
+	}
+	if w := closeReception(`"3"`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"4"` {
+		t.Fatalf("close with current If-Match = %v %q, want 200 and %q", w.Code, w.Header().Get("ETag"), `"4"`)
+	}
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/internal/validation"
+	"github.com/kosttiik/pvz-service/internal/webhook"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type CreateWebhookRequest struct {
+	URL        string             `json:"url" validate:"required,webhookURL"`
+	EventTypes []models.EventType `json:"eventTypes" validate:"required,enum=webhookEvent"`
+	// Если секрет не передан, он генерируется
+	Secret string `json:"secret" validate:"omitempty,min=16"`
+}
+
+// Секрет возвращается только при создании подписки
+type CreateWebhookResponse struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+func (h *Handler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	log := logger.FromContext(r.Context())
+	claims := utils.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.WriteProblem(w, r, dto.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var input CreateWebhookRequest
+	if err := utils.Decode(r, &input); err != nil {
+		log.Warn("Failed to decode webhook creation request", zap.Error(err))
+		utils.WriteDecodeError(w, r, err)
+		return
+	}
+
+	webhookURL := validation.Rule{Name: "webhookURL", Check: func(value string) error {
+		return webhook.ValidateURL(value, h.webhookConfig.TestMode)
+	}}
+	if err := validation.Struct(input, webhookURL); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			utils.WriteProblem(w, r, dto.CodeInternal, "Internal server error")
+			return
+		}
+		secret = generated
+	}
+
+	sub := models.WebhookSubscription{
+		ID:         uuid.New(),
+		URL:        input.URL,
+		EventTypes: input.EventTypes,
+		Secret:     secret,
+		CreatedBy:  claims.UserID,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := h.webhookRepo.CreateSubscription(r.Context(), &sub); err != nil {
+		log.Error("Failed to create webhook subscription", zap.Error(err))
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to create webhook")
+		return
+	}
+
+	log.Info("Webhook subscription created",
+		zap.String("id", sub.ID.String()),
+		zap.String("url", sub.URL),
+		zap.String("createdBy", claims.UserID))
+
+	utils.Write(w, r, CreateWebhookResponse{WebhookSubscription: sub, Secret: sub.Secret}, http.StatusCreated)
+}
+
+func (h *Handler) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.webhookRepo.ListSubscriptions(r.Context())
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to list webhook subscriptions", zap.Error(err))
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to list webhooks")
+		return
+	}
+
+	utils.Write(w, r, subs, http.StatusOK)
+}
+
+func (h *Handler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("webhookId"))
+	if err != nil {
+		utils.WriteFieldError(w, r, "webhookId", dto.FieldInvalidFormat, "Invalid webhook ID")
+		return
+	}
+
+	if err := h.webhookRepo.DeleteSubscription(r.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			utils.WriteProblem(w, r, dto.CodeWebhookNotFound, "Webhook not found")
+			return
+		}
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("webhookId"))
+	if err != nil {
+		utils.WriteFieldError(w, r, "webhookId", dto.FieldInvalidFormat, "Invalid webhook ID")
+		return
+	}
+
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limitNum, err := strconv.Atoi(l)
+		if err != nil || limitNum < 1 || limitNum > 500 {
+			utils.WriteFieldError(w, r, "limit", dto.FieldOutOfRange, "Invalid limit")
+			return
+		}
+		limit = limitNum
+	}
+
+	if _, err := h.webhookRepo.GetSubscription(r.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			utils.WriteProblem(w, r, dto.CodeWebhookNotFound, "Webhook not found")
+			return
+		}
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to get webhook")
+		return
+	}
+
+	deliveries, err := h.webhookRepo.ListDeliveries(r.Context(), id, limit)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to list webhook deliveries", zap.Error(err))
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to list deliveries")
+		return
+	}
+
+	utils.Write(w, r, deliveries, http.StatusOK)
+}
+
+func (h *Handler) TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("webhookId"))
+	if err != nil {
+		utils.WriteFieldError(w, r, "webhookId", dto.FieldInvalidFormat, "Invalid webhook ID")
+		return
+	}
+
+	sub, err := h.webhookRepo.GetSubscription(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			utils.WriteProblem(w, r, dto.CodeWebhookNotFound, "Webhook not found")
+			return
+		}
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to get webhook")
+		return
+	}
+
+	delivery, err := h.webhookTester.SendTest(r.Context(), *sub)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to send test webhook", zap.Error(err))
+		utils.WriteProblem(w, r, dto.CodeInternal, "Failed to send test webhook")
+		return
+	}
+
+	utils.Write(w, r, delivery, http.StatusOK)
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,204 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/dto"
+	handlertest "github.com/kosttiik/pvz-service/internal/handlers/internal/test"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/webhook"
+)
+
+func init() {
+	handlertest.Init()
+}
+
+func createDBTestWebhook(t *testing.T, h *Handler, url string) CreateWebhookResponse {
+	body := map[string]any{
+		"url":        url,
+		"eventTypes": []string{string(models.EventProductAdded)},
+	}
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBuffer(jsonBody))
+	req = getDBTestToken(t, "moderator", req)
+	w := httptest.NewRecorder()
+
+	h.CreateWebhookHandler(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create test webhook: status = %v", w.Code)
+	}
+
+	var resp CreateWebhookResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode webhook response: %v", err)
+	}
+	return resp
+}
+
+func TestIntegrationCreateWebhookHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]any
+		wantStatus int
+		wantFields []string
+	}{
+		{
+			name: "Valid webhook",
+			body: map[string]any{
+				"url":        "https://partner.example.com/hooks",
+				"eventTypes": []string{"product.added", "reception.closed"},
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "Insecure url",
+			body: map[string]any{
+				"url":        "http://partner.example.com/hooks",
+				"eventTypes": []string{"product.added"},
+			},
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"url"},
+		},
+		{
+			name: "Private url and unknown event type",
+			body: map[string]any{
+				"url":        "https://10.0.0.1/hooks",
+				"eventTypes": []string{"pvz.exploded"},
+			},
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"url", "eventTypes[0]"},
+		},
+		{
+			name: "Unknown event type",
+			body: map[string]any{
+				"url":        "https://partner.example.com/hooks",
+				"eventTypes": []string{"pvz.exploded"},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "No event types",
+			body: map[string]any{
+				"url": "https://partner.example.com/hooks",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Short secret",
+			body: map[string]any{
+				"url":        "https://partner.example.com/hooks",
+				"eventTypes": []string{"product.added"},
+				"secret":     "short",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonBody, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBuffer(jsonBody))
+			req = getDBTestToken(t, "moderator", req)
+			w := httptest.NewRecorder()
+
+			dbTestHandler().CreateWebhookHandler(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateWebhookHandler() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+
+			if tt.wantFields != nil {
+				var problem dto.Problem
+				if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+					t.Fatalf("Failed to decode problem: %v", err)
+				}
+				var fields []string
+				for _, fieldErr := range problem.Errors {
+					fields = append(fields, fieldErr.Field)
+				}
+				if !slices.Equal(fields, tt.wantFields) {
+					t.Errorf("Field errors = %v, want %v", fields, tt.wantFields)
+				}
+			}
+
+			if w.Code == http.StatusCreated {
+				var resp CreateWebhookResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Errorf("Failed to decode response: %v", err)
+				}
+				if resp.Secret == "" {
+					t.Error("Expected generated secret in response")
+				}
+			}
+		})
+	}
+}
+
+func TestIntegrationWebhookTestAndDeliveriesHandlers(t *testing.T) {
+	config := webhook.DefaultConfig()
+	config.TestMode = true
+	h := newDBTestHandler(config)
+
+	var signatureValid bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signatureValid = r.Header.Get(webhook.HeaderSignature) != ""
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sub := createDBTestWebhook(t, h, server.URL)
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/webhooks/%s/test", sub.ID), nil)
+	req.SetPathValue("webhookId", sub.ID.String())
+	w := httptest.NewRecorder()
+
+	h.TestWebhookHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("TestWebhookHandler() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if !signatureValid {
+		t.Error("Test webhook should be signed")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/webhooks/%s/deliveries", sub.ID), nil)
+	req.SetPathValue("webhookId", sub.ID.String())
+	w = httptest.NewRecorder()
+
+	h.ListWebhookDeliveriesHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("ListWebhookDeliveriesHandler() status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := json.NewDecoder(w.Body).Decode(&deliveries); err != nil {
+		t.Fatalf("Failed to decode deliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != models.DeliveryDelivered {
+		t.Errorf("Expected one delivered test delivery, got %+v", deliveries)
+	}
+
+	t.Run("Unknown webhook", func(t *testing.T) {
+		id := uuid.New().String()
+		req := httptest.NewRequest(http.MethodGet, "/webhooks/"+id+"/deliveries", nil)
+		req.SetPathValue("webhookId", id)
+		w := httptest.NewRecorder()
+
+		h.ListWebhookDeliveriesHandler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("ListWebhookDeliveriesHandler() status = %v, want %v", w.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package idempotency
+
+import "time"
+
+// Config - сколько хранится ответ и как долго ждут дубли запроса, который еще выполняется
+type Config struct {
+	// TTL - время жизни сохраненного ответа, повтор с тем же ключом позже выполнится заново
+	TTL time.Duration `yaml:"ttl"`
+	// LockTimeout - сколько держится отметка о выполняющемся запросе, если экземпляр упал не дописав ответ
+	LockTimeout time.Duration `yaml:"lockTimeout"`
+	// WaitTimeout - сколько дубль ждет первый запрос, прежде чем получить 409
+	WaitTimeout time.Duration `yaml:"waitTimeout"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		TTL:         24 * time.Hour,
+		LockTimeout: 30 * time.Second,
+		WaitTimeout: 5 * time.Second,
+	}
+}
@@ -0,0 +1,107 @@
+// Package idempotency - хранение ответов на запросы с заголовком Idempotency-Key в Redis
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "idempotency:"
+
+// Response - сохраненный ответ, повтор отдает его без изменений
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// Record - состояние ключа. Пока запрос выполняется, Response пустой, а Owner указывает на попытку,
+// которой разрешено записать ответ
+type Record struct {
+	Owner       string    `json:"owner,omitempty"`
+	Fingerprint string    `json:"fingerprint"`
+	Response    *Response `json:"response,omitempty"`
+}
+
+// beginScript ставит отметку о выполнении, если ключа нет, иначе возвращает текущее состояние
+var beginScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return false
+end
+return redis.call('GET', KEYS[1])
+`)
+
+// ownedScript меняет ключ, только пока им владеет та же попытка: после истечения LockTimeout
+// ключ мог захватить повтор, и его нельзя затирать
+var ownedScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current or cjson.decode(current).owner ~= ARGV[1] then
+	return 0
+end
+if ARGV[2] == '' then
+	redis.call('DEL', KEYS[1])
+else
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+end
+return 1
+`)
+
+type Store struct {
+	redis  *redis.Client
+	config Config
+}
+
+func NewStore(redisClient *redis.Client, config Config) *Store {
+	return &Store{redis: redisClient, config: config}
+}
+
+// Begin захватывает ключ для попытки owner. Возвращает nil, если ключ захвачен,
+// иначе запись первого запроса: выполняющегося или уже с ответом
+func (s *Store) Begin(ctx context.Context, key, owner, fingerprint string) (*Record, error) {
+	pending, err := json.Marshal(Record{Owner: owner, Fingerprint: fingerprint})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	raw, err := beginScript.Run(ctx, s.redis, []string{keyPrefix + key}, pending, s.config.LockTimeout.Milliseconds()).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin idempotent request: %w", err)
+	}
+
+	var record Record
+	if err := json.Unmarshal([]byte(raw), &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+	}
+	return &record, nil
+}
+
+// Complete сохраняет ответ на TTL
+func (s *Store) Complete(ctx context.Context, key, owner, fingerprint string, response Response) error {
+	data, err := json.Marshal(Record{Fingerprint: fingerprint, Response: &response})
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+	return s.owned(ctx, key, owner, string(data), s.config.TTL)
+}
+
+// Release снимает отметку, чтобы повтор выполнился заново. Нужен, если ответ не стоит запоминать
+func (s *Store) Release(ctx context.Context, key, owner string) error {
+	return s.owned(ctx, key, owner, "", 0)
+}
+
+func (s *Store) owned(ctx context.Context, key, owner, value string, ttl time.Duration) error {
+	err := ownedScript.Run(ctx, s.redis, []string{keyPrefix + key}, owner, value, ttl.Milliseconds()).Err()
+	if err != nil {
+		return fmt.Errorf("failed to update idempotency record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+//go:build integration
+
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/pkg/redis"
+)
+
+func TestStore(t *testing.T) {
+	if err := redis.Connect(redis.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to connect to redis: %v", err)
+	}
+	t.Cleanup(func() { redis.Close() })
+
+	store := NewStore(redis.Client, Config{TTL: time.Minute, LockTimeout: time.Minute})
+	ctx := context.Background()
+	key := "test:" + uuid.NewString()
+
+	if record, err := store.Begin(ctx, key, "first", "fp"); err != nil || record != nil {
+		t.Fatalf("first Begin() = %+v, %v, want key acquired", record, err)
+	}
+
+	record, err := store.Begin(ctx, key, "second", "fp")
+	if err != nil || record == nil || record.Owner != "first" || record.Response != nil {
+		t.Fatalf("second Begin() = %+v, %v, want pending record of first attempt", record, err)
+	}
+
+	// Чужая попытка не может записать ответ
+	response := Response{Status: http.StatusCreated, Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"id":1}`)}
+	if err := store.Complete(ctx, key, "second", "fp", response); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if record, _ := store.Begin(ctx, key, "third", "fp"); record == nil || record.Response != nil {
+		t.Fatalf("expected record to stay pending, got %+v", record)
+	}
+
+	if err := store.Complete(ctx, key, "first", "fp", response); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	record, err = store.Begin(ctx, key, "fourth", "fp")
+	if err != nil || record == nil || record.Response == nil {
+		t.Fatalf("Begin() = %+v, %v, want stored response", record, err)
+	}
+	if record.Response.Status != http.StatusCreated || string(record.Response.Body) != `{"id":1}` {
+		t.Errorf("unexpected stored response %+v", record.Response)
+	}
+
+	other := "test:" + uuid.NewString()
+	store.Begin(ctx, other, "first", "fp")
+	if err := store.Release(ctx, other, "first"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if record, _ := store.Begin(ctx, other, "second", "fp"); record != nil {
+		t.Errorf("expected released key to be acquired again, got %+v", record)
+	}
+}
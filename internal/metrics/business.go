@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Причины отказа для auth_failures_total
+const (
+	AuthReasonMissingHeader = "missing_header"
+	AuthReasonInvalidHeader = "invalid_header"
+	AuthReasonInvalidToken  = "invalid_token"
+	AuthReasonTokenRevoked  = "token_revoked"
+	AuthReasonForbidden     = "forbidden"
+	AuthReasonUnknownUser   = "unknown_user"
+	AuthReasonWrongPassword = "wrong_password"
+)
+
+// Политики для rate_limited_requests_total
+const (
+	RateLimitLoginIP       = "login_ip"
+	RateLimitLoginEmail    = "login_email"
+	RateLimitRegisterIP    = "register_ip"
+	RateLimitResetIP       = "password_reset_ip"
+	RateLimitResetEmail    = "password_reset_email"
+	RateLimitAccountLocked = "account_lockout"
+	// RateLimitQuotaPrefix + роль - квота пишущих запросов пользователя
+	RateLimitQuotaPrefix = "quota_"
+)
+
+// OpenReceptionsStore считает открытые приемки по городам
+type OpenReceptionsStore interface {
+	CountOpenByCity(ctx context.Context) (map[string]int, error)
+}
+
+// OpenReceptionsRefresher периодически перечитывает из Postgres число открытых приемок,
+// потому что по одному инстансу сервиса его не посчитать
+type OpenReceptionsRefresher struct {
+	store    OpenReceptionsStore
+	cities   []string
+	interval time.Duration
+}
+
+// cities - города, для которых гауж выставляется в 0, даже если открытых приемок нет
+func NewOpenReceptionsRefresher(store OpenReceptionsStore, cities []string, interval time.Duration) *OpenReceptionsRefresher {
+	return &OpenReceptionsRefresher{
+		store:    store,
+		cities:   cities,
+		interval: interval,
+	}
+}
+
+// Run обновляет гауж сразу и затем раз в interval до отмены контекста
+func (r *OpenReceptionsRefresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).Warn("Failed to refresh open receptions metric", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *OpenReceptionsRefresher) Refresh(ctx context.Context) error {
+	counts, err := r.store.CountOpenByCity(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, city := range r.cities {
+		OpenReceptions.WithLabelValues(city).Set(float64(counts[city]))
+	}
+	for city, count := range counts {
+		OpenReceptions.WithLabelValues(city).Set(float64(count))
+	}
+	return nil
+}
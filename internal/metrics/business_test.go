@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeOpenReceptionsStore struct {
+	counts map[string]int
+	err    error
+}
+
+func (s *fakeOpenReceptionsStore) CountOpenByCity(context.Context) (map[string]int, error) {
+	return s.counts, s.err
+}
+
+func TestOpenReceptionsRefresher(t *testing.T) {
+	store := &fakeOpenReceptionsStore{counts: map[string]int{"Москва": 3, "Казань": 1}}
+	refresher := NewOpenReceptionsRefresher(store, []string{"Москва", "Казань", "Санкт-Петербург"}, 0)
+
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]float64{"Москва": 3, "Казань": 1, "Санкт-Петербург": 0}
+	for city, count := range want {
+		if got := testutil.ToFloat64(OpenReceptions.WithLabelValues(city)); got != count {
+			t.Errorf("city %s: expected %v, got %v", city, count, got)
+		}
+	}
+
+	// Приемки в Москве закрылись - гауж должен упасть до 0, а не остаться старым
+	store.counts = map[string]int{"Казань": 2}
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := testutil.ToFloat64(OpenReceptions.WithLabelValues("Москва")); got != 0 {
+		t.Errorf("expected 0 open receptions in Москва, got %v", got)
+	}
+
+	store.err = errors.New("db down")
+	if err := refresher.Refresh(context.Background()); err == nil {
+		t.Error("expected error from store")
+	}
+	if got := testutil.ToFloat64(OpenReceptions.WithLabelValues("Казань")); got != 2 {
+		t.Errorf("expected gauge to keep last value on error, got %v", got)
+	}
+}
@@ -11,7 +11,7 @@ var (
 			Name: "http_requests_total",
 			Help: "Total number of HTTP requests",
 		},
-		[]string{"method", "endpoint"},
+		[]string{"method", "route", "status"},
 	)
 
 	ResponseTime = promauto.NewHistogramVec(
@@ -20,14 +20,31 @@ var (
 			Help:    "Response time in seconds",
 			Buckets: prometheus.DefBuckets,
 		},
-		[]string{"method", "endpoint"},
+		[]string{"method", "route", "status"},
 	)
 
-	PvzCreatedTotal = promauto.NewCounter(
+	ResponseSize = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "Response body size in bytes",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 10),
+		},
+		[]string{"method", "route"},
+	)
+
+	RequestsInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests being served",
+		},
+	)
+
+	PvzCreatedTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "pvz_created_total",
 			Help: "Total number of PVZ created",
 		},
+		[]string{"city"},
 	)
 
 	OrderReceiptsCreatedTotal = promauto.NewCounter(
@@ -37,10 +54,111 @@ var (
 		},
 	)
 
-	ProductsAddedTotal = promauto.NewCounter(
+	ProductsAddedTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "products_added_total",
 			Help: "Total number of products added",
 		},
+		[]string{"type"},
+	)
+
+	ReceptionDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name: "reception_duration_seconds",
+			Help: "Time from reception open to close in seconds",
+			// От минуты до двух суток
+			Buckets: prometheus.ExponentialBuckets(60, 2, 12),
+		},
+	)
+
+	OpenReceptions = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "open_receptions",
+			Help: "Number of receptions currently in progress",
+		},
+		[]string{"city"},
+	)
+
+	AuthFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_failures_total",
+			Help: "Total number of failed authentication and authorization attempts",
+		},
+		[]string{"reason"},
+	)
+
+	RateLimitedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limited_requests_total",
+			Help: "Total number of requests rejected by rate limits and account lockouts",
+		},
+		[]string{"policy"},
+	)
+
+	OutboxPublishedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_events_published_total",
+			Help: "Total number of outbox events published",
+		},
+		[]string{"type"},
+	)
+
+	OutboxFailedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_events_failed_total",
+			Help: "Total number of failed outbox publish attempts",
+		},
+		[]string{"type"},
+	)
+
+	OutboxDeadTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_events_dead_total",
+			Help: "Total number of outbox events moved to dead letter",
+		},
+		[]string{"type"},
+	)
+
+	PVZListCacheRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pvz_list_cache_requests_total",
+			Help: "Total number of PVZ list cache lookups by result",
+		},
+		[]string{"result"},
+	)
+
+	PVZListCacheInvalidationsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "pvz_list_cache_invalidations_total",
+			Help: "Total number of PVZ list date ranges invalidated by events",
+		},
+	)
+
+	SSEConnections = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sse_connections",
+			Help: "Number of open SSE connections",
+		},
+	)
+
+	WSConnections = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "dashboard_ws_connections",
+			Help: "Number of open dashboard WebSocket connections",
+		},
+	)
+
+	WSDroppedMessagesTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "dashboard_ws_dropped_messages_total",
+			Help: "Total number of dashboard deltas dropped for slow clients",
+		},
+	)
+
+	WSResyncsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "dashboard_ws_resyncs_total",
+			Help: "Total number of snapshots resent to slow dashboard clients",
+		},
 	)
 )
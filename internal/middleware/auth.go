@@ -1,22 +1,32 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"slices"
 	"strings"
 
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/metrics"
 	"github.com/kosttiik/pvz-service/internal/utils"
-	"github.com/kosttiik/pvz-service/pkg/cache"
 	"github.com/kosttiik/pvz-service/pkg/logger"
-	"github.com/kosttiik/pvz-service/pkg/redis"
 	"go.uber.org/zap"
 )
 
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	tokenCache := cache.NewTokenCache(redis.Client)
+// TokenStore - хранилище выданных токенов, токена нет в хранилище - значит он отозван
+type TokenStore interface {
+	Get(ctx context.Context, userID string) (string, error)
+}
+
+func AuthMiddleware(jwt *utils.JWT, tokenCache TokenStore) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware(jwt, tokenCache, next)
+	}
+}
 
+func authMiddleware(jwt *utils.JWT, tokenCache TokenStore, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log := logger.Log
+		log := logger.FromContext(r.Context())
 		log.Debug("Processing request",
 			zap.String("path", r.URL.Path),
 			zap.String("method", r.Method),
@@ -25,23 +35,26 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
 			log.Warn("No authorization token provided")
-			utils.WriteError(w, "Authorization header is required", http.StatusUnauthorized)
+			metrics.AuthFailuresTotal.WithLabelValues(metrics.AuthReasonMissingHeader).Inc()
+			utils.WriteProblem(w, r, dto.CodeUnauthorized, "Authorization header is required")
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			log.Warn("Invalid authorization header")
-			utils.WriteError(w, "Invalid authorization header", http.StatusUnauthorized)
+			metrics.AuthFailuresTotal.WithLabelValues(metrics.AuthReasonInvalidHeader).Inc()
+			utils.WriteProblem(w, r, dto.CodeInvalidToken, "Invalid authorization header")
 			return
 		}
 
-		claims, err := utils.ParseJWT(parts[1])
+		claims, err := jwt.Parse(parts[1])
 		if err != nil {
 			log.Warn("Invalid token",
 				zap.Error(err),
 				zap.String("path", r.URL.Path))
-			utils.WriteError(w, "Invalid token", http.StatusUnauthorized)
+			metrics.AuthFailuresTotal.WithLabelValues(metrics.AuthReasonInvalidToken).Inc()
+			utils.WriteProblem(w, r, dto.CodeInvalidToken, "Invalid token")
 			return
 		}
 
@@ -51,7 +64,8 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			log.Warn("Token not found in cache or invalid",
 				zap.String("userID", claims.UserID),
 				zap.Error(err))
-			utils.WriteError(w, "Token has been revoked or expired", http.StatusUnauthorized)
+			metrics.AuthFailuresTotal.WithLabelValues(metrics.AuthReasonTokenRevoked).Inc()
+			utils.WriteProblem(w, r, dto.CodeTokenRevoked, "Token has been revoked or expired")
 			return
 		}
 
@@ -72,13 +86,13 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 func RoleMiddleware(roles ...string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			log := logger.Log
+			log := logger.FromContext(r.Context())
 			claims := utils.GetUserFromContext(r.Context())
 			if claims == nil {
 				log.Warn("No claims found in context",
 					zap.String("path", r.URL.Path),
 					zap.String("method", r.Method))
-				utils.WriteError(w, "Unauthorized - no claims in context", http.StatusUnauthorized)
+				utils.WriteProblem(w, r, dto.CodeUnauthorized, "No claims in context")
 				return
 			}
 
@@ -90,7 +104,8 @@ func RoleMiddleware(roles ...string) func(http.HandlerFunc) http.HandlerFunc {
 					zap.String("userRole", string(claims.Role)),
 					zap.Strings("requiredRoles", roles),
 					zap.String("path", r.URL.Path))
-				utils.WriteError(w, "Forbidden", http.StatusForbidden)
+				metrics.AuthFailuresTotal.WithLabelValues(metrics.AuthReasonForbidden).Inc()
+				utils.WriteProblem(w, r, dto.CodeForbidden, "Forbidden")
 				return
 			}
 
@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/idempotency"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader отмечает ответ, отданный из хранилища
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKey = 255
+	// maxIdempotentBody - тело запроса больше этого в отпечаток не попадает целиком,
+	// такие запросы все равно отклоняют хендлеры
+	maxIdempotentBody = 10 << 20
+	// maxStoredResponse - ответы больше не сохраняются, повтор выполнится заново
+	maxStoredResponse = 1 << 20
+
+	idempotencyPollInterval = 50 * time.Millisecond
+)
+
+// IdempotencyStore хранит ответы по ключу, обычно это idempotency.Store
+type IdempotencyStore interface {
+	Begin(ctx context.Context, key, owner, fingerprint string) (*idempotency.Record, error)
+	Complete(ctx context.Context, key, owner, fingerprint string, response idempotency.Response) error
+	Release(ctx context.Context, key, owner string) error
+}
+
+// Idempotency повторяет сохраненный ответ на изменяющий запрос с тем же Idempotency-Key.
+// Ключ действует в рамках пользователя и маршрута, поэтому ставится после AuthMiddleware.
+// Дубль, пришедший пока первый запрос выполняется, ждет его до WaitTimeout, затем получает 409.
+// Ответы 5xx и временные отказы (408, 409, 423, 429) не сохраняются, чтобы повтор мог пройти.
+// Если Redis недоступен, запрос выполняется без защиты
+func Idempotency(store IdempotencyStore, config idempotency.Config) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			claims := utils.GetUserFromContext(r.Context())
+			if key == "" || claims == nil || !isMutating(r.Method) {
+				next(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKey {
+				utils.WriteProblem(w, r, dto.CodeInvalidRequest, "Idempotency-Key must not be longer than 255 characters")
+				return
+			}
+
+			log := logger.FromContext(r.Context())
+			fingerprint, err := requestFingerprint(r)
+			if err != nil {
+				utils.WriteProblem(w, r, dto.CodeInvalidRequest, "Failed to read request body")
+				return
+			}
+
+			storeKey := claims.UserID + ":" + r.Pattern + ":" + key
+			owner := uuid.NewString()
+			deadline := time.Now().Add(config.WaitTimeout)
+
+			for {
+				record, err := store.Begin(r.Context(), storeKey, owner, fingerprint)
+				if err != nil {
+					log.Warn("Idempotency check failed, processing request without it", zap.Error(err))
+					next(w, r)
+					return
+				}
+
+				switch {
+				case record == nil:
+					execute(w, r, next, store, storeKey, owner, fingerprint)
+					return
+				case record.Fingerprint != fingerprint:
+					utils.WriteProblem(w, r, dto.CodeIdempotencyMismatch, "Idempotency-Key was already used with a different request")
+					return
+				case record.Response != nil:
+					replay(w, *record.Response)
+					return
+				case time.Now().After(deadline):
+					utils.WriteProblem(w, r, dto.CodeIdempotencyConflict, "Request with this Idempotency-Key is still in progress, retry later")
+					return
+				}
+
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(idempotencyPollInterval):
+				}
+			}
+		}
+	}
+}
+
+// execute выполняет запрос и сохраняет ответ. Заголовки сохраняются только те, что выставил
+// хендлер: Request-ID, лимиты и прочие заголовки middleware у повтора свои
+func execute(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, store IdempotencyStore, key, owner, fingerprint string) {
+	before := w.Header().Clone()
+	rec := &responseCapture{ResponseWriter: w}
+	next(rec, r)
+
+	// Запрос мог быть отменен клиентом, а ответ сохранить все равно нужно
+	ctx := context.WithoutCancel(r.Context())
+	log := logger.FromContext(ctx)
+
+	status := rec.Status()
+	if retryable(status) || rec.overflow {
+		if err := store.Release(ctx, key, owner); err != nil {
+			log.Warn("Failed to release idempotency key", zap.Error(err))
+		}
+		return
+	}
+
+	header := make(http.Header)
+	for name, values := range w.Header() {
+		if !slices.Equal(before.Values(name), values) {
+			header[name] = slices.Clone(values)
+		}
+	}
+
+	response := idempotency.Response{Status: status, Header: header, Body: rec.body.Bytes()}
+	if err := store.Complete(ctx, key, owner, fingerprint, response); err != nil {
+		log.Warn("Failed to store idempotent response", zap.Error(err))
+	}
+}
+
+func replay(w http.ResponseWriter, response idempotency.Response) {
+	for _, name := range slices.Sorted(maps.Keys(response.Header)) {
+		w.Header()[name] = response.Header[name]
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(response.Status)
+	w.Write(response.Body)
+}
+
+// retryable - временный отказ: ответ не сохраняется, и повтор выполнит запрос заново.
+// Квота стоит после Idempotency, поэтому ее 429 с Retry-After тоже сюда попадает.
+// После 412 и 428 клиент перечитывает ресурс и повторяет запрос с новым If-Match
+func retryable(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusLocked, http.StatusTooManyRequests,
+		http.StatusPreconditionFailed, http.StatusPreconditionRequired:
+		return true
+	}
+	return status >= http.StatusInternalServerError
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+var fingerprintHeaders = []string{"If-Match", "Accept"}
+
+// requestFingerprint - хеш метода, пути, If-Match, Accept и тела: от заголовков зависят
+// проверка версии и формат сохраненного ответа. Тело возвращается обратно для хендлера
+func requestFingerprint(r *http.Request) (string, error) {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	for _, name := range fingerprintHeaders {
+		io.WriteString(hash, name+": "+strings.Join(r.Header.Values(name), ", ")+"\n")
+	}
+
+	if r.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody))
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		if err != nil {
+			return "", err
+		}
+		hash.Write(body)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// responseCapture пишет ответ клиенту и копит его для сохранения
+type responseCapture struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if c.body.Len()+len(b) > maxStoredResponse {
+		c.overflow = true
+	} else if !c.overflow {
+		c.body.Write(b)
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+func (c *responseCapture) Status() int {
+	if c.status == 0 {
+		return http.StatusOK
+	}
+	return c.status
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/idempotency"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/ratelimit"
+	"github.com/kosttiik/pvz-service/internal/utils"
+)
+
+// memoryIdempotencyStore повторяет семантику idempotency.Store без Redis и TTL
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]idempotency.Record
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]idempotency.Record)}
+}
+
+func (s *memoryIdempotencyStore) Begin(_ context.Context, key, owner, fingerprint string) (*idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		return &record, nil
+	}
+	s.records[key] = idempotency.Record{Owner: owner, Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, key, owner, fingerprint string, response idempotency.Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.records[key].Owner == owner {
+		s.records[key] = idempotency.Record{Fingerprint: fingerprint, Response: &response}
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.records[key].Owner == owner {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func idempotentRequest(handler http.HandlerFunc, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	req.Pattern = "POST /products"
+	req.Header.Set(IdempotencyKeyHeader, key)
+	req = req.WithContext(utils.SetUserContext(req.Context(), &models.Claims{UserID: "user", Role: models.Employee}))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	calls := 0
+	handler := Idempotency(newMemoryIdempotencyStore(), idempotency.DefaultConfig())(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"` + strings.Repeat("x", calls) + `"}`))
+	})
+
+	first := idempotentRequest(handler, "key-1", `{"type":"обувь"}`)
+	second := idempotentRequest(handler, "key-1", `{"type":"обувь"}`)
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %q, want %d %q", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get("Content-Type") != "application/json" || second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("unexpected replay headers %v", second.Header())
+	}
+
+	if w := idempotentRequest(handler, "key-1", `{"type":"одежда"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key with another body status = %d, want 422", w.Code)
+	}
+	if idempotentRequest(handler, "key-2", `{"type":"обувь"}`); calls != 2 {
+		t.Errorf("new key should execute request, calls = %d", calls)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	config := idempotency.Config{TTL: time.Minute, LockTimeout: time.Minute}
+	store := newMemoryIdempotencyStore()
+	handler := Idempotency(store, config)(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentRequest(handler, "key", "{}") }()
+	<-started
+
+	// Без ожидания дубль сразу получает 409
+	if w := idempotentRequest(handler, "key", "{}"); w.Code != http.StatusConflict {
+		t.Errorf("duplicate status = %d, want 409", w.Code)
+	}
+
+	// С ожиданием дубль получает ответ первого запроса
+	config.WaitTimeout = 5 * time.Second
+	waiting := Idempotency(store, config)(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("duplicate must not be executed")
+	})
+	go func() {
+		time.Sleep(2 * idempotencyPollInterval)
+		close(release)
+	}()
+	if w := idempotentRequest(waiting, "key", "{}"); w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("waiting duplicate = %d %v, want replayed 201", w.Code, w.Header())
+	}
+	<-done
+}
+
+func TestIdempotencySkipsServerErrors(t *testing.T) {
+	calls := 0
+	handler := Idempotency(newMemoryIdempotencyStore(), idempotency.DefaultConfig())(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	idempotentRequest(handler, "key", "{}")
+	idempotentRequest(handler, "key", "{}")
+	if calls != 2 {
+		t.Errorf("handler called %d times, want retry after 5xx", calls)
+	}
+}
+
+func TestIdempotencySkipsTransientErrors(t *testing.T) {
+	statuses := []int{
+		http.StatusRequestTimeout, http.StatusConflict, http.StatusLocked, http.StatusTooManyRequests,
+		http.StatusPreconditionFailed, http.StatusPreconditionRequired,
+	}
+	for _, status := range statuses {
+		calls := 0
+		handler := Idempotency(newMemoryIdempotencyStore(), idempotency.DefaultConfig())(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(status)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		})
+
+		idempotentRequest(handler, "key", "{}")
+		w := idempotentRequest(handler, "key", "{}")
+		if calls != 2 || w.Code != http.StatusCreated {
+			t.Errorf("after %d: handler called %d times, retry status = %d, want 201", status, calls, w.Code)
+		}
+		if w.Header().Get(IdempotentReplayedHeader) != "" {
+			t.Errorf("after %d: retry was replayed", status)
+		}
+	}
+}
+
+func TestIdempotencyRetriesAfterQuota(t *testing.T) {
+	quotas := ratelimit.QuotaConfig{Employee: ratelimit.Bucket{PerMinute: 60, Burst: 1}}
+	calls := 0
+	handler := Idempotency(newMemoryIdempotencyStore(), idempotency.DefaultConfig())(
+		Quota(ratelimit.NewLocalBucket(), quotas)(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+	idempotentRequest(handler, "first", "{}")
+	if w := idempotentRequest(handler, "second", "{}"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+
+	// Токен пополняется за секунду, повтор с тем же ключом доходит до хендлера
+	time.Sleep(time.Second)
+	w := idempotentRequest(handler, "second", "{}")
+	if w.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("retry status = %d, handler called %d times, want 201 and 2 calls", w.Code, calls)
+	}
+}
+
+func TestIdempotencyFingerprintHeaders(t *testing.T) {
+	handler := Idempotency(newMemoryIdempotencyStore(), idempotency.DefaultConfig())(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	send := func(ifMatch, accept string) int {
+		req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, "key")
+		req.Header.Set("If-Match", ifMatch)
+		req.Header.Set("Accept", accept)
+		req = req.WithContext(utils.SetUserContext(req.Context(), &models.Claims{UserID: "user", Role: models.Employee}))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Code
+	}
+
+	if code := send(`"1"`, "application/json"); code != http.StatusCreated {
+		t.Fatalf("first request status = %d, want 201", code)
+	}
+	if code := send(`"2"`, "application/json"); code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key with another If-Match status = %d, want 422", code)
+	}
+	if code := send(`"1"`, "application/x-protobuf"); code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key with another Accept status = %d, want 422", code)
+	}
+}
@@ -1,21 +1,99 @@
 package middleware
 
 import (
+	"bufio"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/kosttiik/pvz-service/internal/metrics"
 )
 
+// MetricsMiddleware считает запросы, время и размер ответа. Маршрут берется из r.Pattern,
+// который проставляет ServeMux, поэтому /pvz/{pvzId}/... не плодит метки по каждому ID.
+// Метод из шаблона вида "GET /pvz" в метку маршрута не попадает, а нестандартные методы
+// от клиентов сводятся к OTHER
 func MetricsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		metrics.RequestsTotal.WithLabelValues(r.Method, r.URL.Path).Inc()
+		metrics.RequestsInFlight.Inc()
+		defer metrics.RequestsInFlight.Dec()
 
-		next.ServeHTTP(w, r)
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
 
-		duration := time.Since(start).Seconds()
-		metrics.ResponseTime.WithLabelValues(r.Method, r.URL.Path).Observe(duration)
+		method := methodLabel(r.Method)
+		route := routeLabel(r.Pattern)
+		status := strconv.Itoa(rec.Status())
+
+		metrics.RequestsTotal.WithLabelValues(method, route, status).Inc()
+		metrics.ResponseTime.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+		metrics.ResponseSize.WithLabelValues(method, route).Observe(float64(rec.bytes))
 	})
 }
+
+// methodLabel оставляет стандартные методы HTTP как есть, остальные заменяет на OTHER,
+// чтобы клиент не мог создать произвольное число серий своими методами
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// statusRecorder запоминает код и размер ответа. Flush и Hijack пробрасываются дальше,
+// иначе перестанут работать SSE и WebSocket
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+func (rec *statusRecorder) Flush() {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	http.NewResponseController(rec.ResponseWriter).Flush()
+}
+
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(rec.ResponseWriter).Hijack()
+	if err == nil && rec.status == 0 {
+		rec.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap нужен http.ResponseController для доступа к SetWriteDeadline и т.д.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Status возвращает код ответа. Если хендлер ничего не записал, net/http ответит 200
+func (rec *statusRecorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/pvz/{pvzId}/events", MetricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("expected wrapped writer to implement http.Flusher")
+		}
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Error("expected wrapped writer to implement http.Hijacker")
+		}
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("hello"))
+		w.(http.Flusher).Flush()
+	})))
+
+	counter := metrics.RequestsTotal.WithLabelValues(http.MethodGet, "/pvz/{pvzId}/events", "418")
+	before := testutil.ToFloat64(counter)
+
+	for _, id := range []string{"a", "b", "c"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/pvz/"+id+"/events", nil))
+
+		if rr.Code != http.StatusTeapot {
+			t.Errorf("expected status %d, got %d", http.StatusTeapot, rr.Code)
+		}
+		if !rr.Flushed {
+			t.Error("expected Flush to reach underlying writer")
+		}
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 3 {
+		t.Errorf("expected 3 requests under route pattern, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.RequestsInFlight); got != 0 {
+		t.Errorf("expected no requests in flight, got %v", got)
+	}
+}
+
+func TestMetricsMiddlewareUnknownMethod(t *testing.T) {
+	handler := MetricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	counter := metrics.RequestsTotal.WithLabelValues("OTHER", "unmatched", "200")
+	before := testutil.ToFloat64(counter)
+	seriesBefore := testutil.CollectAndCount(metrics.RequestsTotal)
+
+	for _, method := range []string{"FOO", "BAR", "get"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/", nil))
+	}
+
+	if got := testutil.ToFloat64(counter) - before; got != 3 {
+		t.Errorf("expected 3 requests under OTHER method, got %v", got)
+	}
+	if got := testutil.CollectAndCount(metrics.RequestsTotal); got != seriesBefore {
+		t.Errorf("expected no new series for unknown methods, got %d, was %d", got, seriesBefore)
+	}
+}
+
+func TestStatusRecorderDefaultStatus(t *testing.T) {
+	rec := &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+	if rec.Status() != http.StatusOK {
+		t.Errorf("expected default status 200, got %d", rec.Status())
+	}
+
+	rec.Write([]byte("abc"))
+	rec.WriteHeader(http.StatusInternalServerError)
+	if rec.Status() != http.StatusOK || rec.bytes != 3 {
+		t.Errorf("expected 200 and 3 bytes, got %d and %d", rec.Status(), rec.bytes)
+	}
+}
+
+func TestRouteLabel(t *testing.T) {
+	tests := map[string]string{
+		"":                        "unmatched",
+		"/pvz":                    "/pvz",
+		"GET /pvz/{pvzId}/events": "/pvz/{pvzId}/events",
+		"POST /receptions":        "/receptions",
+	}
+	for pattern, want := range tests {
+		if got := routeLabel(pattern); got != want {
+			t.Errorf("routeLabel(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
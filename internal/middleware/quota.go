@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/ratelimit"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Quota ограничивает пишущие запросы пользователя бюджетом его роли. Ставится после
+// AuthMiddleware: без claims или без квоты для роли запрос пропускается
+func Quota(limiter ratelimit.BucketLimiter, quotas ratelimit.QuotaConfig) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims := utils.GetUserFromContext(r.Context())
+			if claims == nil {
+				next(w, r)
+				return
+			}
+
+			role := string(claims.Role)
+			bucket := quotas.For(role)
+			if !bucket.Enabled() {
+				next(w, r)
+				return
+			}
+
+			log := logger.FromContext(r.Context())
+			res, err := limiter.Take(r.Context(), claims.UserID, bucket)
+			if err != nil {
+				log.Warn("Quota check failed, letting request through", zap.Error(err))
+				next(w, r)
+				return
+			}
+
+			policy := RateLimitPolicy{Name: metrics.RateLimitQuotaPrefix + role, Limit: bucket.Burst, Window: bucket.Window()}
+			setRateLimitHeaders(w, policy, res)
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(res.Reset), 1)))
+				metrics.RateLimitedTotal.WithLabelValues(policy.Name).Inc()
+				log.Warn("Request quota exceeded",
+					zap.String("userID", claims.UserID),
+					zap.String("role", role))
+				utils.WriteProblem(w, r, dto.CodeTooManyRequests, "Request quota for role "+role+" exceeded, retry later")
+				return
+			}
+			next(w, r)
+		}
+	}
+}
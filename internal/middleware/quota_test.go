@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/ratelimit"
+	"github.com/kosttiik/pvz-service/internal/utils"
+)
+
+func TestQuota(t *testing.T) {
+	quotas := ratelimit.QuotaConfig{Employee: ratelimit.Bucket{PerMinute: 60, Burst: 2}}
+	handler := Quota(ratelimit.NewLocalBucket(), quotas)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	request := func(userID string, role models.Role) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/products", nil)
+		req = req.WithContext(utils.SetUserContext(req.Context(), &models.Claims{UserID: userID, Role: role}))
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	// Limit и Policy описывают одно ведро: 2 токена, которые пополняются за 2 секунды
+	w := request("scanner", models.Employee)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", w.Code)
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("RateLimit-Limit = %q, want 2", got)
+	}
+	if got := w.Header().Get("RateLimit-Policy"); got != "2;w=2" {
+		t.Errorf("RateLimit-Policy = %q, want 2;w=2", got)
+	}
+	if w := request("scanner", models.Employee); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", w.Code)
+	}
+
+	w = request("scanner", models.Employee)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+
+	// Квота считается на пользователя, у роли без квоты лимита нет
+	if w := request("another", models.Employee); w.Code != http.StatusCreated {
+		t.Errorf("another user status = %d, want 201", w.Code)
+	}
+	for range 5 {
+		if w := request("moderator", models.Moderator); w.Code != http.StatusCreated {
+			t.Fatalf("role without quota status = %d, want 201", w.Code)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/ratelimit"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// maxPeekBody - сколько тела читаем, чтобы достать email. Формы входа заведомо меньше
+const maxPeekBody = 64 << 10
+
+// Limiter проверяет и учитывает запрос по ключу, обычно это ratelimit.SlidingWindow
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (ratelimit.Result, error)
+}
+
+// RateLimitPolicy - лимит Limit запросов за Window на ключ. Пустой ключ - политика не применяется
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    func(r *http.Request) string
+}
+
+// RateLimit проверяет запрос по всем политикам и отвечает 429, если исчерпана хотя бы одна.
+// Заголовки RateLimit-* описывают самую строгую политику. Если Redis недоступен, запрос пропускается
+func RateLimit(limiter Limiter, policies ...RateLimitPolicy) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			log := logger.FromContext(r.Context())
+
+			var strictest *ratelimit.Result
+			var strictestPolicy RateLimitPolicy
+			for _, policy := range policies {
+				key := policy.Key(r)
+				if key == "" || policy.Limit <= 0 {
+					continue
+				}
+
+				res, err := limiter.Allow(r.Context(), policy.Name+":"+key, policy.Limit, policy.Window)
+				if err != nil {
+					log.Warn("Rate limit check failed, letting request through",
+						zap.String("policy", policy.Name),
+						zap.Error(err))
+					continue
+				}
+
+				if !res.Allowed {
+					setRateLimitHeaders(w, policy, res)
+					w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.Reset)))
+					metrics.RateLimitedTotal.WithLabelValues(policy.Name).Inc()
+					log.Warn("Request rate limited", zap.String("policy", policy.Name))
+					utils.WriteProblem(w, r, dto.CodeTooManyRequests, "Too many requests, retry later")
+					return
+				}
+
+				if strictest == nil || res.Remaining < strictest.Remaining {
+					strictest, strictestPolicy = &res, policy
+				}
+			}
+
+			if strictest != nil {
+				setRateLimitHeaders(w, strictestPolicy, *strictest)
+			}
+			next(w, r)
+		}
+	}
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, policy RateLimitPolicy, res ratelimit.Result) {
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	h.Set("RateLimit-Policy", strconv.Itoa(policy.Limit)+";w="+strconv.Itoa(ceilSeconds(policy.Window)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ClientIP - адрес клиента из соединения. X-Forwarded-For не учитывается:
+// без доверенного прокси его подделывает кто угодно
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// EmailFromBody достает email из тела запроса в любом поддерживаемом формате
+// и возвращает тело обратно, чтобы хендлер прочитал его заново
+func EmailFromBody(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBody))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil {
+		return ""
+	}
+
+	peek := r.Clone(r.Context())
+	peek.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req dto.LoginRequest
+	if err := utils.Decode(peek, &req); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(req.Email))
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/ratelimit"
+)
+
+// countingLimiter - фиксированное окно в памяти, чтобы не зависеть от Redis
+type countingLimiter struct {
+	counts map[string]int
+	err    error
+}
+
+func (l *countingLimiter) Allow(_ context.Context, key string, limit int, window time.Duration) (ratelimit.Result, error) {
+	if l.err != nil {
+		return ratelimit.Result{}, l.err
+	}
+	l.counts[key]++
+	count := l.counts[key]
+	return ratelimit.Result{
+		Allowed:   count <= limit,
+		Limit:     limit,
+		Remaining: max(limit-count, 0),
+		Reset:     window,
+	}, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := &countingLimiter{counts: make(map[string]int)}
+	var bodies []string
+	handler := RateLimit(limiter,
+		RateLimitPolicy{Name: "ip", Limit: 5, Window: time.Minute, Key: ClientIP},
+		RateLimitPolicy{Name: "email", Limit: 2, Window: time.Minute, Key: EmailFromBody},
+	)(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	})
+
+	login := func(email string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"`+email+`","password":"x"}`))
+		req.RemoteAddr = "10.0.0.1:5555"
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	w := login("User@Example.com")
+	if w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d", w.Code)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "1" {
+		t.Errorf("RateLimit-Remaining = %q, want the strictest policy (1)", got)
+	}
+	if got := w.Header().Get("RateLimit-Policy"); got != "2;w=60" {
+		t.Errorf("RateLimit-Policy = %q, want 2;w=60", got)
+	}
+	if len(bodies) != 1 || !strings.Contains(bodies[0], "User@Example.com") {
+		t.Fatalf("handler should receive the original body, got %q", bodies)
+	}
+
+	login("user@example.com")
+	w = login("USER@example.com")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request for same email status = %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Retry-After = %q, want 60", w.Header().Get("Retry-After"))
+	}
+
+	// Другой email с того же IP проходит, пока не исчерпан лимит по IP
+	if w := login("other@example.com"); w.Code != http.StatusOK {
+		t.Errorf("other email status = %d, want 200", w.Code)
+	}
+	login("third@example.com")
+	if w := login("fourth@example.com"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("request over IP limit status = %d, want 429", w.Code)
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	limiter := &countingLimiter{err: errors.New("redis is down")}
+	called := false
+	handler := RateLimit(limiter, RateLimitPolicy{Name: "ip", Limit: 1, Window: time.Minute, Key: ClientIP})(
+		func(w http.ResponseWriter, r *http.Request) { called = true },
+	)
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", nil))
+	if !called {
+		t.Error("expected request to pass when limiter is unavailable")
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID берет ID запроса из X-Request-ID или генерирует новый, возвращает его в ответе
+// и кладет в контекст логгер с request_id и trace_id. Должен стоять после Tracing, чтобы спан уже был
+func RequestID(base *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return requestID(base, next)
+	}
+}
+
+func requestID(base *zap.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		fields := []zap.Field{zap.String("request_id", requestID)}
+
+		span := trace.SpanFromContext(r.Context())
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			span.SetAttributes(attribute.String("http.request_id", requestID))
+			fields = append(fields,
+				zap.String("trace_id", spanContext.TraceID().String()),
+				zap.String("span_id", spanContext.SpanID().String()))
+		}
+
+		ctx := utils.SetRequestID(r.Context(), requestID)
+		ctx = logger.WithContext(ctx, base.With(fields...))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Чужой ID принимаем, только если он короткий и из печатных ASCII символов, чтобы не ломать логи
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
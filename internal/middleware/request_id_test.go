@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"go.uber.org/zap"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "Generated when missing", incoming: "", keep: false},
+		{name: "Incoming ID is kept", incoming: "abc-123", keep: true},
+		{name: "Too long ID is replaced", incoming: strings.Repeat("a", maxRequestIDLength+1), keep: false},
+		{name: "ID with spaces is replaced", incoming: "bad id", keep: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestID(zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = utils.RequestID(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/pvz", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			got := rr.Header().Get(RequestIDHeader)
+			if got == "" {
+				t.Fatal("expected X-Request-ID in response")
+			}
+			if got != seen {
+				t.Errorf("context request ID %q differs from header %q", seen, got)
+			}
+			if tt.keep && got != tt.incoming {
+				t.Errorf("expected incoming ID %q, got %q", tt.incoming, got)
+			}
+			if !tt.keep && got == tt.incoming {
+				t.Errorf("expected incoming ID %q to be replaced", tt.incoming)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Matcher находит хендлер и шаблон маршрута для запроса, как http.ServeMux.Handler
+type Matcher interface {
+	Handler(r *http.Request) (http.Handler, string)
+}
+
+// Tracing создает серверный спан на каждый запрос и продолжает трассу из заголовка traceparent.
+// Имя спана - метод и шаблон маршрута из mux, а не сырой путь, чтобы не плодить имена по ID
+func Tracing(mux Matcher) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return otelhttp.NewHandler(next, "http.server",
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return methodLabel(r.Method) + " " + RoutePattern(mux, r)
+			}),
+		)
+	}
+}
+
+// RoutePattern возвращает шаблон пути, под который попадает запрос
+func RoutePattern(mux Matcher, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	return routeLabel(pattern)
+}
+
+// routeLabel отрезает метод от шаблона вида "POST /receptions": метод и так идет отдельной меткой
+func routeLabel(pattern string) string {
+	if pattern == "" {
+		return "unmatched"
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return strings.TrimLeft(path, " \t")
+	}
+	return pattern
+}
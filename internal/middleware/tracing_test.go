@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		provider.Shutdown(context.Background())
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pvz/{pvzId}", func(w http.ResponseWriter, r *http.Request) {
+		// Спан запроса в БД внутри хендлера
+		_, span := otel.Tracer("test").Start(r.Context(), "postgres SELECT")
+		span.End()
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := Tracing(mux)(mux)
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		remoteSpanID = "00f067aa0ba902b7"
+	)
+	req := httptest.NewRequest(http.MethodGet, "/pvz/123", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+remoteSpanID+"-01")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	unmatched := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), unmatched)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("Got %d spans, want 3", len(spans))
+	}
+	child, server, notFound := spans[0], spans[1], spans[2]
+
+	if server.Name != "GET /pvz/{pvzId}" || server.SpanKind != trace.SpanKindServer {
+		t.Errorf("Unexpected server span %q of kind %v", server.Name, server.SpanKind)
+	}
+	if got := server.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("Server span trace id = %s, want %s from traceparent", got, traceID)
+	}
+	if got := server.Parent.SpanID().String(); got != remoteSpanID || !server.Parent.IsRemote() {
+		t.Errorf("Server span parent = %s, want remote %s", got, remoteSpanID)
+	}
+	if child.Parent.SpanID() != server.SpanContext.SpanID() || child.SpanContext.TraceID() != server.SpanContext.TraceID() {
+		t.Error("Span from handler should be a child of the server span")
+	}
+
+	// Ключ атрибута зависит от OTEL_SEMCONV_STABILITY_OPT_IN
+	var status attribute.Value
+	for _, kv := range server.Attributes {
+		if kv.Key == "http.response.status_code" || kv.Key == "http.status_code" {
+			status = kv.Value
+		}
+	}
+	if status.AsInt64() != http.StatusTeapot {
+		t.Errorf("Server span status code attribute = %v, want %d", status.Emit(), http.StatusTeapot)
+	}
+
+	if notFound.Name != "GET unmatched" {
+		t.Errorf("Unmatched request span = %q, want %q", notFound.Name, "GET unmatched")
+	}
+	if notFound.Parent.IsValid() {
+		t.Error("Request without traceparent should start a new trace")
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+// Доменные события, публикуемые через outbox
+const (
+	EventPVZCreated       EventType = "pvz.created"
+	EventReceptionCreated EventType = "reception.created"
+	EventReceptionClosed  EventType = "reception.closed"
+	EventProductAdded     EventType = "product.added"
+	EventProductDeleted   EventType = "product.deleted"
+)
+
+type OutboxStatus string
+
+// Статусы записи в outbox
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxPublished OutboxStatus = "published"
+	OutboxDead      OutboxStatus = "dead"
+)
+
+type Event struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        EventType       `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregateId"`
+	PvzID       string          `json:"pvzId"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// ReceptionEvent - данные событий приемки. PVZVersion - версия ПВЗ после изменения,
+// по ней подписчики отбрасывают повторы и события старше своего состояния
+type ReceptionEvent struct {
+	Reception
+	PVZVersion int `json:"pvzVersion"`
+}
+
+// ProductEvent - данные событий товара с версией приемки и числом ее товаров после изменения
+type ProductEvent struct {
+	Product
+	ReceptionVersion int `json:"receptionVersion"`
+	ProductCount     int `json:"productCount"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	pvz_v1 "github.com/kosttiik/pvz-service/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Преобразования моделей в сообщения proto/pvz.proto для ответов в application/x-protobuf
+
+var receptionStatusToProto = map[ReceptionStatus]pvz_v1.ReceptionStatus{
+	StatusInProgress: pvz_v1.ReceptionStatus_RECEPTION_STATUS_IN_PROGRESS,
+	StatusClosed:     pvz_v1.ReceptionStatus_RECEPTION_STATUS_CLOSED,
+}
+
+func (p PVZ) ToProto() *pvz_v1.PVZ {
+	return &pvz_v1.PVZ{
+		Id:               p.ID.String(),
+		RegistrationDate: timestamppb.New(p.RegistrationDate),
+		City:             p.City,
+		Version:          int64(p.Version),
+	}
+}
+
+func (p PVZ) MarshalProto() ([]byte, error) {
+	return proto.Marshal(p.ToProto())
+}
+
+func (r Reception) ToProto() *pvz_v1.Reception {
+	return &pvz_v1.Reception{
+		Id:       r.ID.String(),
+		DateTime: timestamppb.New(r.DateTime),
+		PvzId:    r.PvzID,
+		Status:   receptionStatusToProto[r.Status],
+		Version:  int64(r.Version),
+	}
+}
+
+func (r Reception) MarshalProto() ([]byte, error) {
+	return proto.Marshal(r.ToProto())
+}
+
+func (p Product) ToProto() *pvz_v1.Product {
+	return &pvz_v1.Product{
+		Id:          p.ID.String(),
+		DateTime:    timestamppb.New(p.DateTime),
+		Type:        p.Type,
+		ReceptionId: p.ReceptionID,
+	}
+}
+
+func (p Product) MarshalProto() ([]byte, error) {
+	return proto.Marshal(p.ToProto())
+}
@@ -17,4 +17,6 @@ type PVZ struct {
 	ID               uuid.UUID `json:"id"`
 	RegistrationDate time.Time `json:"registrationDate"`
 	City             string    `json:"city"`
+	// Version растет при каждом изменении ПВЗ и его приемок, из нее строится ETag
+	Version int `json:"version"`
 }
@@ -24,6 +24,8 @@ type Reception struct {
 	DateTime time.Time       `json:"dateTime"`
 	PvzID    string          `json:"pvzId"`
 	Status   ReceptionStatus `json:"status"`
+	// Version растет при закрытии приемки и изменении ее товаров
+	Version int `json:"version"`
 }
 
 func (s ReceptionStatus) IsValid() bool {
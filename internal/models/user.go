@@ -1,10 +1,35 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type User struct {
 	ID       uuid.UUID `json:"id"`
 	Email    string    `json:"email"`
 	Password string    `json:"-"`
 	Role     string    `json:"role"`
+	// EmailVerifiedAt - когда пользователь подтвердил почту, nil - еще не подтвердил
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+}
+
+type TokenPurpose string
+
+// Назначения одноразовых токенов из писем
+const (
+	PurposeEmailVerification TokenPurpose = "email_verification"
+	PurposePasswordReset     TokenPurpose = "password_reset"
+)
+
+// UserToken - одноразовый токен из письма. Сам токен уходит только в письмо,
+// хранится его хеш
+type UserToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Purpose   TokenPurpose
+	Hash      string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// События, на которые можно подписать вебхук
+var WebhookEventTypes = map[EventType]bool{
+	EventReceptionCreated: true,
+	EventReceptionClosed:  true,
+	EventProductAdded:     true,
+	EventProductDeleted:   true,
+}
+
+// Служебное событие для проверки подписки
+const EventWebhookTest EventType = "webhook.test"
+
+type DeliveryStatus string
+
+// Статусы доставки вебхука
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+type WebhookSubscription struct {
+	ID         uuid.UUID   `json:"id"`
+	URL        string      `json:"url"`
+	EventTypes []EventType `json:"eventTypes"`
+	Secret     string      `json:"-"`
+	CreatedBy  string      `json:"createdBy"`
+	CreatedAt  time.Time   `json:"createdAt"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	SubscriptionID uuid.UUID       `json:"subscriptionId"`
+	EventID        uuid.UUID       `json:"eventId"`
+	EventType      EventType       `json:"eventType"`
+	Payload        json.RawMessage `json:"-"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"responseStatus,omitempty"`
+	LastError      *string         `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+}
@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	EventsStream     = "pvz:events"
+	DeadLetterStream = "pvz:events:dead"
+
+	pvzChannelPrefix = "pvz:live:"
+
+	defaultStreamMaxLen = 100000
+)
+
+// RedisStreamSink публикует события в Redis Stream через XADD
+type RedisStreamSink struct {
+	redis  *redis.Client
+	stream string
+	maxLen int64
+}
+
+func NewRedisStreamSink(redisClient *redis.Client, stream string) *RedisStreamSink {
+	if redisClient == nil {
+		panic("redis client cannot be nil")
+	}
+	return &RedisStreamSink{
+		redis:  redisClient,
+		stream: stream,
+		maxLen: defaultStreamMaxLen,
+	}
+}
+
+func (s *RedisStreamSink) Publish(ctx context.Context, event models.Event) error {
+	args := &redis.XAddArgs{
+		Stream: s.stream,
+		MaxLen: s.maxLen,
+		Approx: true,
+		Values: map[string]any{
+			"id":          event.ID.String(),
+			"type":        string(event.Type),
+			"aggregateId": event.AggregateID.String(),
+			"pvzId":       event.PvzID,
+			"payload":     string(event.Payload),
+			"createdAt":   event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		},
+	}
+
+	if err := s.redis.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to publish event to stream %s: %w", s.stream, err)
+	}
+
+	return nil
+}
+
+// PVZChannel - канал Redis pub/sub с живыми событиями конкретного ПВЗ
+func PVZChannel(pvzID string) string {
+	return pvzChannelPrefix + pvzID
+}
+
+// RedisPubSubSink рассылает события в канал ПВЗ, на который подписаны
+// SSE-клиенты всех реплик. Pub/sub не хранит историю, поэтому события
+// получают только подключенные в момент публикации клиенты
+type RedisPubSubSink struct {
+	redis *redis.Client
+}
+
+func NewRedisPubSubSink(redisClient *redis.Client) *RedisPubSubSink {
+	if redisClient == nil {
+		panic("redis client cannot be nil")
+	}
+	return &RedisPubSubSink{redis: redisClient}
+}
+
+func (s *RedisPubSubSink) Publish(ctx context.Context, event models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err := s.redis.Publish(ctx, PVZChannel(event.PvzID), data).Err(); err != nil {
+		return fmt.Errorf("failed to publish event to pvz channel: %w", err)
+	}
+
+	return nil
+}
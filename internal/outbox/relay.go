@@ -0,0 +1,212 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Store - хранилище outbox, которое использует Relay
+type Store interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]repository.OutboxRecord, error)
+	MarkPublished(ctx context.Context, id uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, lastErr string, retryAfter time.Duration) error
+	MarkDead(ctx context.Context, id uuid.UUID, lastErr string) error
+	DeletePublished(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Lease        time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// Retention - сколько хранятся опубликованные события, SweepInterval - как часто их чистить
+	Retention     time.Duration
+	SweepInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:  time.Second,
+		BatchSize:     100,
+		Lease:         30 * time.Second,
+		MaxAttempts:   10,
+		BaseBackoff:   time.Second,
+		MaxBackoff:    5 * time.Minute,
+		Retention:     7 * 24 * time.Hour,
+		SweepInterval: time.Hour,
+	}
+}
+
+// Relay вычитывает события из outbox и публикует их в sink
+type Relay struct {
+	store      Store
+	sink       Sink
+	deadLetter Sink
+	config     Config
+}
+
+func NewRelay(store Store, sink Sink, config Config) *Relay {
+	return &Relay{
+		store:  store,
+		sink:   sink,
+		config: config,
+	}
+}
+
+// WithDeadLetter задает sink, в который дублируются события, исчерпавшие попытки
+func (r *Relay) WithDeadLetter(sink Sink) *Relay {
+	r.deadLetter = sink
+	return r
+}
+
+// Run крутит цикл публикации до отмены контекста
+func (r *Relay) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	log.Info("Outbox relay started",
+		zap.Duration("pollInterval", r.config.PollInterval),
+		zap.Int("batchSize", r.config.BatchSize))
+
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+	sweepTicker := time.NewTicker(r.config.SweepInterval)
+	defer sweepTicker.Stop()
+
+	for {
+		// Пока есть полные пачки, разгребаем без ожидания
+		for {
+			n, err := r.ProcessBatch(ctx)
+			if err != nil {
+				log.Error("Failed to process outbox batch", zap.Error(err))
+				break
+			}
+			if n < r.config.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		case <-sweepTicker.C:
+			if _, err := r.Sweep(ctx); err != nil {
+				log.Error("Failed to sweep published outbox events", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Sweep удаляет события, опубликованные больше Retention назад. Недоставленные события остаются
+func (r *Relay) Sweep(ctx context.Context) (int64, error) {
+	deleted, err := r.store.DeletePublished(ctx, r.config.Retention)
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		logger.FromContext(ctx).Info("Swept published outbox events", zap.Int64("deleted", deleted))
+	}
+	return deleted, nil
+}
+
+// ProcessBatch публикует одну пачку событий и возвращает их количество
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	records, err := r.store.Claim(ctx, r.config.BatchSize, r.config.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, rec := range records {
+		r.deliver(ctx, rec)
+	}
+
+	return len(records), nil
+}
+
+func (r *Relay) deliver(ctx context.Context, rec repository.OutboxRecord) {
+	log := logger.FromContext(ctx)
+	event := rec.Event
+
+	publishErr := r.sink.Publish(ctx, event)
+	if publishErr == nil {
+		if err := r.store.MarkPublished(ctx, event.ID); err != nil {
+			// Событие уйдет повторно после истечения lease, это допустимо для at-least-once
+			log.Error("Failed to mark outbox event published",
+				zap.String("id", event.ID.String()),
+				zap.Error(err))
+			return
+		}
+		metrics.OutboxPublishedTotal.WithLabelValues(string(event.Type)).Inc()
+		return
+	}
+
+	metrics.OutboxFailedTotal.WithLabelValues(string(event.Type)).Inc()
+	attempt := rec.Attempts + 1
+
+	if attempt >= r.config.MaxAttempts {
+		log.Error("Outbox event exhausted retries, moving to dead letter",
+			zap.String("id", event.ID.String()),
+			zap.String("type", string(event.Type)),
+			zap.Int("attempts", attempt),
+			zap.Error(publishErr))
+
+		if r.deadLetter != nil {
+			if dlErr := r.deadLetter.Publish(ctx, event); dlErr != nil {
+				log.Error("Failed to publish event to dead letter",
+					zap.String("id", event.ID.String()),
+					zap.Error(dlErr))
+			}
+		}
+
+		if err := r.store.MarkDead(ctx, event.ID, publishErr.Error()); err != nil {
+			log.Error("Failed to mark outbox event dead",
+				zap.String("id", event.ID.String()),
+				zap.Error(err))
+			return
+		}
+		metrics.OutboxDeadTotal.WithLabelValues(string(event.Type)).Inc()
+		return
+	}
+
+	retryAfter := Backoff(attempt, r.config.BaseBackoff, r.config.MaxBackoff)
+	log.Warn("Failed to publish outbox event, will retry",
+		zap.String("id", event.ID.String()),
+		zap.String("type", string(event.Type)),
+		zap.Int("attempt", attempt),
+		zap.Duration("retryAfter", retryAfter),
+		zap.Error(publishErr))
+
+	if err := r.store.MarkFailed(ctx, event.ID, publishErr.Error(), retryAfter); err != nil {
+		log.Error("Failed to mark outbox event failed",
+			zap.String("id", event.ID.String()),
+			zap.Error(err))
+	}
+}
+
+// Backoff - экспоненциальная задержка base * 2^(attempt-1), ограниченная max
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
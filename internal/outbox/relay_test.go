@@ -0,0 +1,244 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	logger.Close()
+	os.Exit(code)
+}
+
+type fakeStore struct {
+	mu        sync.Mutex
+	records   []repository.OutboxRecord
+	published map[uuid.UUID]bool
+	failed    map[uuid.UUID]time.Duration
+	dead      map[uuid.UUID]bool
+	swept     time.Duration
+}
+
+func newFakeStore(records ...repository.OutboxRecord) *fakeStore {
+	return &fakeStore{
+		records:   records,
+		published: make(map[uuid.UUID]bool),
+		failed:    make(map[uuid.UUID]time.Duration),
+		dead:      make(map[uuid.UUID]bool),
+	}
+}
+
+func (s *fakeStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]repository.OutboxRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.records) < limit {
+		limit = len(s.records)
+	}
+	claimed := s.records[:limit]
+	s.records = s.records[limit:]
+	return claimed, nil
+}
+
+func (s *fakeStore) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.published[id] = true
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(ctx context.Context, id uuid.UUID, lastErr string, retryAfter time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed[id] = retryAfter
+	return nil
+}
+
+func (s *fakeStore) MarkDead(ctx context.Context, id uuid.UUID, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dead[id] = true
+	return nil
+}
+
+func (s *fakeStore) DeletePublished(ctx context.Context, olderThan time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.swept = olderThan
+	return int64(len(s.published)), nil
+}
+
+type fakeSink struct {
+	mu     sync.Mutex
+	err    error
+	events []models.Event
+}
+
+func (s *fakeSink) Publish(ctx context.Context, event models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func newRecord(attempts int) repository.OutboxRecord {
+	return repository.OutboxRecord{
+		Event: models.Event{
+			ID:          uuid.New(),
+			Type:        models.EventReceptionCreated,
+			AggregateID: uuid.New(),
+			PvzID:       uuid.New().String(),
+			Payload:     []byte(`{}`),
+			CreatedAt:   time.Now(),
+		},
+		Attempts: attempts,
+	}
+}
+
+func TestRelayProcessBatch(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultConfig()
+	config.MaxAttempts = 3
+
+	t.Run("Published", func(t *testing.T) {
+		rec := newRecord(0)
+		store := newFakeStore(rec)
+		sink := &fakeSink{}
+
+		n, err := NewRelay(store, sink, config).ProcessBatch(ctx)
+		if err != nil {
+			t.Fatalf("ProcessBatch() error = %v", err)
+		}
+		if n != 1 {
+			t.Errorf("ProcessBatch() = %d, want 1", n)
+		}
+		if len(sink.events) != 1 || sink.events[0].ID != rec.Event.ID {
+			t.Errorf("Sink got %v, want event %s", sink.events, rec.Event.ID)
+		}
+		if !store.published[rec.Event.ID] {
+			t.Error("Event should be marked published")
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		rec := newRecord(1)
+		store := newFakeStore(rec)
+		sink := &fakeSink{err: errors.New("sink unavailable")}
+
+		if _, err := NewRelay(store, sink, config).ProcessBatch(ctx); err != nil {
+			t.Fatalf("ProcessBatch() error = %v", err)
+		}
+
+		retryAfter, ok := store.failed[rec.Event.ID]
+		if !ok {
+			t.Fatal("Event should be marked failed")
+		}
+		if want := 2 * config.BaseBackoff; retryAfter != want {
+			t.Errorf("retryAfter = %v, want %v", retryAfter, want)
+		}
+		if store.dead[rec.Event.ID] {
+			t.Error("Event should not be dead yet")
+		}
+	})
+
+	t.Run("DeadLetter", func(t *testing.T) {
+		rec := newRecord(config.MaxAttempts - 1)
+		store := newFakeStore(rec)
+		sink := &fakeSink{err: errors.New("sink unavailable")}
+		deadLetter := &fakeSink{}
+
+		relay := NewRelay(store, sink, config).WithDeadLetter(deadLetter)
+		if _, err := relay.ProcessBatch(ctx); err != nil {
+			t.Fatalf("ProcessBatch() error = %v", err)
+		}
+
+		if !store.dead[rec.Event.ID] {
+			t.Error("Event should be marked dead")
+		}
+		if len(deadLetter.events) != 1 {
+			t.Errorf("Dead letter got %d events, want 1", len(deadLetter.events))
+		}
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	base := time.Second
+	max := 10 * time.Second
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, max},
+		{50, max},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt, base, max); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestMultiSink(t *testing.T) {
+	ok := &fakeSink{}
+	failing := &fakeSink{err: errors.New("boom")}
+
+	err := MultiSink{ok, failing}.Publish(context.Background(), newRecord(0).Event)
+	if err == nil {
+		t.Error("MultiSink should return error when one of sinks fails")
+	}
+	if len(ok.events) != 1 {
+		t.Error("Healthy sink should still receive the event")
+	}
+}
+
+func TestMultiSinkBestEffort(t *testing.T) {
+	durable := &fakeSink{}
+	live := &fakeSink{err: errors.New("pubsub unavailable")}
+
+	// Сбой живых уведомлений не должен повторять событие в надежных sink'ах
+	err := MultiSink{durable, BestEffort("pubsub", live)}.Publish(context.Background(), newRecord(0).Event)
+	if err != nil {
+		t.Errorf("MultiSink error = %v, want nil for best-effort failure", err)
+	}
+	if len(durable.events) != 1 {
+		t.Errorf("Durable sink got %d events, want 1", len(durable.events))
+	}
+}
+
+func TestRelaySweep(t *testing.T) {
+	store := newFakeStore()
+	config := DefaultConfig()
+	config.Retention = time.Hour
+
+	if _, err := NewRelay(store, &fakeSink{}, config).Sweep(context.Background()); err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if store.swept != time.Hour {
+		t.Errorf("Sweep() deleted events older than %v, want %v", store.swept, time.Hour)
+	}
+}
@@ -0,0 +1,52 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Sink - получатель доменных событий. Доставка at-least-once,
+// поэтому реализации должны быть готовы к повторам одного и того же события
+type Sink interface {
+	Publish(ctx context.Context, event models.Event) error
+}
+
+// MultiSink публикует событие во все вложенные sink'и. Ошибка любого из них
+// повторяет событие во всех, поэтому ненадежные получатели оборачиваются в BestEffort
+type MultiSink []Sink
+
+func (m MultiSink) Publish(ctx context.Context, event models.Event) error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// BestEffort - sink, ошибки которого только логируются и не вызывают повтор события.
+// Подходит для живых уведомлений: опоздавшее уведомление не нужно, а повтор
+// продублировал бы событие в надежных sink'ах, которые его уже приняли
+func BestEffort(name string, sink Sink) Sink {
+	return bestEffort{name: name, sink: sink}
+}
+
+type bestEffort struct {
+	name string
+	sink Sink
+}
+
+func (s bestEffort) Publish(ctx context.Context, event models.Event) error {
+	if err := s.sink.Publish(ctx, event); err != nil {
+		logger.FromContext(ctx).Warn("Best-effort sink failed, event is not retried",
+			zap.String("sink", s.name),
+			zap.String("id", event.ID.String()),
+			zap.Error(err))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Package pvzcache подключает cache.PVZListCache к списку ПВЗ: Repository читает
+// GET /pvz через кэш, а Sink сбрасывает затронутые диапазоны по событиям из outbox
+package pvzcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/pkg/cache"
+)
+
+// Repository - PVZRepository, у которого GetPVZ читает страницы через кэш
+type Repository struct {
+	*repository.PVZRepository
+	cache *cache.PVZListCache
+}
+
+func NewRepository(repo *repository.PVZRepository, listCache *cache.PVZListCache) *Repository {
+	return &Repository{PVZRepository: repo, cache: listCache}
+}
+
+func (r *Repository) GetPVZ(ctx context.Context, filter repository.GetPVZFilter) ([]repository.PVZandReceptions, error) {
+	rng := cache.DateRange{Start: filter.StartDate, End: filter.EndDate}
+	data, outcome, err := r.cache.Get(ctx, rng, filter.Page, filter.Limit, func(ctx context.Context) ([]byte, error) {
+		list, err := r.PVZRepository.GetPVZ(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(list)
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.PVZListCacheRequestsTotal.WithLabelValues(string(outcome)).Inc()
+
+	var list []repository.PVZandReceptions
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode cached pvz list: %w", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,67 @@
+package pvzcache
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/metrics"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/pkg/cache"
+)
+
+// Invalidator сбрасывает страницы подходящих диапазонов, обычно это cache.PVZListCache
+type Invalidator interface {
+	Invalidate(ctx context.Context, match func(cache.DateRange) bool) (int, error)
+}
+
+// DateSource возвращает даты приемок ПВЗ, обычно это repository.PVZRepository
+type DateSource interface {
+	ReceptionDates(ctx context.Context, pvzID string) ([]time.Time, error)
+}
+
+// Sink сбрасывает кэш только тех диапазонов, в которые попадает измененный ПВЗ.
+// ПВЗ попадает в диапазон, если у него есть приемка с датой из диапазона, а в список
+// без границ попадают все ПВЗ. Изменение любой приемки или товара ПВЗ сдвигает
+// строки страниц его диапазонов, поэтому сбрасываются все страницы диапазона
+type Sink struct {
+	cache Invalidator
+	dates DateSource
+}
+
+func NewSink(listCache Invalidator, dates DateSource) *Sink {
+	return &Sink{cache: listCache, dates: dates}
+}
+
+func (s *Sink) Publish(ctx context.Context, event models.Event) error {
+	match, err := s.matcher(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	n, err := s.cache.Invalidate(ctx, match)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate pvz list cache: %w", err)
+	}
+	metrics.PVZListCacheInvalidationsTotal.Add(float64(n))
+
+	return nil
+}
+
+func (s *Sink) matcher(ctx context.Context, event models.Event) (func(cache.DateRange) bool, error) {
+	// У нового ПВЗ нет приемок, в диапазоны с границами он не попадает
+	if event.Type == models.EventPVZCreated {
+		return func(rng cache.DateRange) bool { return !rng.Bounded() }, nil
+	}
+
+	// Событие публикуется после коммита, так что новая приемка уже среди дат
+	dates, err := s.dates.ReceptionDates(ctx, event.PvzID)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(rng cache.DateRange) bool {
+		return !rng.Bounded() || slices.ContainsFunc(dates, rng.Contains)
+	}, nil
+}
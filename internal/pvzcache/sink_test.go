@@ -0,0 +1,105 @@
+package pvzcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/pkg/cache"
+)
+
+type fakeInvalidator struct {
+	ranges      []cache.DateRange
+	invalidated []cache.DateRange
+}
+
+func (f *fakeInvalidator) Invalidate(ctx context.Context, match func(cache.DateRange) bool) (int, error) {
+	f.invalidated = nil
+	for _, rng := range f.ranges {
+		if match(rng) {
+			f.invalidated = append(f.invalidated, rng)
+		}
+	}
+	return len(f.invalidated), nil
+}
+
+type fakeDates map[string][]time.Time
+
+func (f fakeDates) ReceptionDates(ctx context.Context, pvzID string) ([]time.Time, error) {
+	dates, ok := f[pvzID]
+	if !ok {
+		return nil, errors.New("unexpected pvz")
+	}
+	return dates, nil
+}
+
+func TestSink(t *testing.T) {
+	day := func(d int) *time.Time {
+		t := time.Date(2025, 4, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	all := cache.DateRange{}
+	firstWeek := cache.DateRange{Start: day(1), End: day(7)}
+	secondWeek := cache.DateRange{Start: day(8), End: day(14)}
+	sinceTenth := cache.DateRange{Start: day(10)}
+
+	invalidator := &fakeInvalidator{ranges: []cache.DateRange{all, firstWeek, secondWeek, sinceTenth}}
+	sink := NewSink(invalidator, fakeDates{
+		"busy":  {*day(3), *day(12)},
+		"early": {*day(7)},
+		"empty": nil,
+	})
+
+	tests := []struct {
+		name  string
+		event models.Event
+		want  []cache.DateRange
+	}{
+		{
+			name:  "New PVZ only in unbounded list",
+			event: models.Event{Type: models.EventPVZCreated, PvzID: "new"},
+			want:  []cache.DateRange{all},
+		},
+		{
+			name:  "Product of PVZ with receptions in several ranges",
+			event: models.Event{Type: models.EventProductAdded, PvzID: "busy"},
+			want:  []cache.DateRange{all, firstWeek, secondWeek, sinceTenth},
+		},
+		{
+			name:  "Reception on the range bound",
+			event: models.Event{Type: models.EventReceptionClosed, PvzID: "early"},
+			want:  []cache.DateRange{all, firstWeek},
+		},
+		{
+			name:  "PVZ without receptions",
+			event: models.Event{Type: models.EventProductDeleted, PvzID: "empty"},
+			want:  []cache.DateRange{all},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := sink.Publish(context.Background(), tt.event); err != nil {
+				t.Fatalf("Publish failed: %v", err)
+			}
+			if len(invalidator.invalidated) != len(tt.want) {
+				t.Fatalf("Expected %d invalidated ranges, got %d", len(tt.want), len(invalidator.invalidated))
+			}
+			for i, rng := range tt.want {
+				if invalidator.invalidated[i] != rng {
+					t.Errorf("Expected range %d to be %+v, got %+v", i, rng, invalidator.invalidated[i])
+				}
+			}
+		})
+	}
+
+	t.Run("Date lookup error is retried by outbox", func(t *testing.T) {
+		err := sink.Publish(context.Background(), models.Event{Type: models.EventReceptionCreated, PvzID: "unknown"})
+		if err == nil {
+			t.Fatal("Expected error for failed date lookup")
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	bucketPrefix = "quota:"
+	// maxLocalBuckets - после стольких ключей локальный лимитер выкидывает полные ведра
+	maxLocalBuckets = 10000
+)
+
+// tokenBucketScript пополняет ведро за прошедшее время и списывает токен одной операцией,
+// иначе параллельные запросы одного пользователя прочитали бы одинаковый остаток
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(now - ts, 0) / interval)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', key, math.ceil((burst - tokens) * interval) + 1000)
+
+local reset = 0
+if tokens < burst then
+	reset = math.ceil((math.floor(tokens) + 1 - tokens) * interval)
+end
+
+return {allowed, math.floor(tokens), reset}
+`)
+
+// BucketLimiter списывает токен из ведра по ключу
+type BucketLimiter interface {
+	Take(ctx context.Context, key string, bucket Bucket) (Result, error)
+}
+
+// TokenBucket - token bucket в Redis, общий для всех экземпляров сервиса
+type TokenBucket struct {
+	redis *redis.Client
+}
+
+func NewTokenBucket(redisClient *redis.Client) *TokenBucket {
+	return &TokenBucket{redis: redisClient}
+}
+
+// Take списывает токен. Reset в результате - через сколько появится следующий токен
+func (b *TokenBucket) Take(ctx context.Context, key string, bucket Bucket) (Result, error) {
+	values, err := tokenBucketScript.Run(ctx, b.redis,
+		[]string{bucketPrefix + key},
+		time.Now().UnixMilli(), bucket.interval().Milliseconds(), bucket.Burst,
+	).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to take token: %w", err)
+	}
+
+	return Result{
+		Allowed:   values[0] == 1,
+		Limit:     bucket.Burst,
+		Remaining: int(max(values[1], 0)),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+type localBucket struct {
+	tokens float64
+	ts     time.Time
+}
+
+// LocalBucket - token bucket в памяти процесса. Лимит считается на каждый экземпляр отдельно,
+// поэтому годится только как запасной вариант на время недоступности Redis
+type LocalBucket struct {
+	mu      sync.Mutex
+	buckets map[string]*localBucket
+	now     func() time.Time
+}
+
+func NewLocalBucket() *LocalBucket {
+	return &LocalBucket{buckets: make(map[string]*localBucket), now: time.Now}
+}
+
+func (b *LocalBucket) Take(_ context.Context, key string, bucket Bucket) (Result, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	state, ok := b.buckets[key]
+	if !ok {
+		if len(b.buckets) >= maxLocalBuckets {
+			b.evictFull(now, bucket)
+		}
+		state = &localBucket{tokens: float64(bucket.Burst), ts: now}
+		b.buckets[key] = state
+	}
+
+	interval := float64(bucket.interval())
+	state.tokens = refill(state, now, bucket)
+	state.ts = now
+
+	allowed := state.tokens >= 1
+	if allowed {
+		state.tokens--
+	}
+
+	var reset time.Duration
+	if state.tokens < float64(bucket.Burst) {
+		reset = time.Duration(math.Ceil((math.Floor(state.tokens) + 1 - state.tokens) * interval))
+	}
+
+	return Result{
+		Allowed:   allowed,
+		Limit:     bucket.Burst,
+		Remaining: int(state.tokens),
+		Reset:     reset,
+	}, nil
+}
+
+// evictFull удаляет ведра, которые успели наполниться: для них новое состояние не отличается от старого
+func (b *LocalBucket) evictFull(now time.Time, bucket Bucket) {
+	for key, state := range b.buckets {
+		if refill(state, now, bucket) >= float64(bucket.Burst) {
+			delete(b.buckets, key)
+		}
+	}
+}
+
+func refill(state *localBucket, now time.Time, bucket Bucket) float64 {
+	elapsed := max(now.Sub(state.ts), 0)
+	return min(float64(bucket.Burst), state.tokens+float64(elapsed)/float64(bucket.interval()))
+}
+
+// Fallback берет токены из primary, а при его ошибке - из secondary
+type Fallback struct {
+	primary   BucketLimiter
+	secondary BucketLimiter
+}
+
+func NewFallback(primary, secondary BucketLimiter) *Fallback {
+	return &Fallback{primary: primary, secondary: secondary}
+}
+
+func (f *Fallback) Take(ctx context.Context, key string, bucket Bucket) (Result, error) {
+	res, err := f.primary.Take(ctx, key, bucket)
+	if err == nil {
+		return res, nil
+	}
+
+	logger.FromContext(ctx).Warn("Quota storage unavailable, using in-process limiter", zap.Error(err))
+	return f.secondary.Take(ctx, key, bucket)
+}
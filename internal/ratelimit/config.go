@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/models"
+)
+
+// Config - лимиты для ручек авторизации и блокировка аккаунта после неудачных входов
+type Config struct {
+	// Window - окно скользящего лимита
+	Window        time.Duration `yaml:"window"`
+	LoginPerIP    int           `yaml:"loginPerIp"`
+	LoginPerEmail int           `yaml:"loginPerEmail"`
+	RegisterPerIP int           `yaml:"registerPerIp"`
+	// PasswordResetPerIP и PasswordResetPerEmail ограничивают письма сброса пароля
+	PasswordResetPerIP    int `yaml:"passwordResetPerIp"`
+	PasswordResetPerEmail int `yaml:"passwordResetPerEmail"`
+
+	Lockout LockoutConfig `yaml:"lockout"`
+	Quota   QuotaConfig   `yaml:"quota"`
+}
+
+// LockoutConfig - после Threshold неудачных входов за FailureWindow аккаунт блокируется
+// на BaseDelay, каждая следующая неудача удваивает блокировку вплоть до MaxDelay
+type LockoutConfig struct {
+	Threshold     int           `yaml:"threshold"`
+	FailureWindow time.Duration `yaml:"failureWindow"`
+	BaseDelay     time.Duration `yaml:"baseDelay"`
+	MaxDelay      time.Duration `yaml:"maxDelay"`
+}
+
+// QuotaConfig - бюджеты пишущих запросов на пользователя по ролям
+type QuotaConfig struct {
+	Employee  Bucket `yaml:"employee"`
+	Moderator Bucket `yaml:"moderator"`
+}
+
+// For возвращает бюджет роли, нулевой Bucket - квоты нет
+func (c QuotaConfig) For(role string) Bucket {
+	switch models.Role(role) {
+	case models.Employee:
+		return c.Employee
+	case models.Moderator:
+		return c.Moderator
+	}
+	return Bucket{}
+}
+
+// Bucket - token bucket: PerMinute токенов в минуту, не больше Burst подряд. PerMinute 0 отключает квоту
+type Bucket struct {
+	PerMinute int `yaml:"perMinute"`
+	Burst     int `yaml:"burst"`
+}
+
+func (b Bucket) Enabled() bool {
+	return b.PerMinute > 0
+}
+
+// Window - время, за которое пустое ведро наполняется до Burst. Burst токенов за Window
+// и есть скорость PerMinute, поэтому пара описывает квоту в заголовке RateLimit-Policy
+func (b Bucket) Window() time.Duration {
+	return time.Duration(b.Burst) * b.interval()
+}
+
+// interval - время пополнения одного токена
+func (b Bucket) interval() time.Duration {
+	return time.Minute / time.Duration(b.PerMinute)
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Window:        time.Minute,
+		LoginPerIP:    30,
+		LoginPerEmail: 10,
+		RegisterPerIP: 10,
+
+		PasswordResetPerIP:    10,
+		PasswordResetPerEmail: 3,
+		Lockout: LockoutConfig{
+			Threshold:     5,
+			FailureWindow: 15 * time.Minute,
+			BaseDelay:     30 * time.Second,
+			MaxDelay:      15 * time.Minute,
+		},
+		Quota: QuotaConfig{
+			// Сканер добавляет товары пачками, поэтому у кладовщика запас больше
+			Employee:  Bucket{PerMinute: 120, Burst: 30},
+			Moderator: Bucket{PerMinute: 60, Burst: 20},
+		},
+	}
+}
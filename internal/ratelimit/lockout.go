@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	failuresPrefix = "lockout:failures:"
+	lockPrefix     = "lockout:lock:"
+	addrsPrefix    = "lockout:addrs:"
+)
+
+// Lockout считает неудачные входы по паре email и IP клиента и временно блокирует
+// вход с этого адреса. Чужие неудачные попытки не блокируют владельца аккаунта
+type Lockout struct {
+	redis  *redis.Client
+	config LockoutConfig
+}
+
+func NewLockout(redisClient *redis.Client, config LockoutConfig) *Lockout {
+	return &Lockout{redis: redisClient, config: config}
+}
+
+// Locked возвращает оставшееся время блокировки, 0 - вход разрешен
+func (l *Lockout) Locked(ctx context.Context, email, ip string) (time.Duration, error) {
+	ttl, err := l.redis.PTTL(ctx, lockPrefix+lockoutKey(email, ip)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check lockout: %w", err)
+	}
+	// PTTL отдает -2 для отсутствующего ключа
+	return max(ttl, 0), nil
+}
+
+// Fail записывает неудачный вход и возвращает длительность блокировки, если она наступила.
+// Адреса с неудачами запоминаются по email, чтобы ResetAll снял блокировку со всех
+func (l *Lockout) Fail(ctx context.Context, email, ip string) (time.Duration, error) {
+	key := lockoutKey(email, ip)
+	addrs := addrsPrefix + normalizeEmail(email)
+
+	pipe := l.redis.TxPipeline()
+	incr := pipe.Incr(ctx, failuresPrefix+key)
+	pipe.PExpire(ctx, failuresPrefix+key, l.config.FailureWindow)
+	pipe.SAdd(ctx, addrs, ip)
+	pipe.PExpire(ctx, addrs, max(l.config.FailureWindow, l.config.MaxDelay))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	delay := LockoutDelay(int(incr.Val()), l.config)
+	if delay == 0 {
+		return 0, nil
+	}
+
+	if err := l.redis.Set(ctx, lockPrefix+key, 1, delay).Err(); err != nil {
+		return 0, fmt.Errorf("failed to lock account: %w", err)
+	}
+	return delay, nil
+}
+
+// Reset сбрасывает счетчик и блокировку email только для адреса ip: успешный вход с одного
+// адреса не должен давать новые попытки перебора с других
+func (l *Lockout) Reset(ctx context.Context, email, ip string) error {
+	key := lockoutKey(email, ip)
+
+	pipe := l.redis.TxPipeline()
+	pipe.Del(ctx, failuresPrefix+key, lockPrefix+key)
+	pipe.SRem(ctx, addrsPrefix+normalizeEmail(email), ip)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to reset lockout: %w", err)
+	}
+	return nil
+}
+
+// ResetAll сбрасывает счетчики и блокировки email со всех адресов
+func (l *Lockout) ResetAll(ctx context.Context, email string) error {
+	addrs := addrsPrefix + normalizeEmail(email)
+	ips, err := l.redis.SMembers(ctx, addrs).Result()
+	if err != nil {
+		return fmt.Errorf("failed to reset lockout: %w", err)
+	}
+
+	keys := []string{addrs}
+	for _, ip := range ips {
+		key := lockoutKey(email, ip)
+		keys = append(keys, failuresPrefix+key, lockPrefix+key)
+	}
+	if err := l.redis.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to reset lockout: %w", err)
+	}
+	return nil
+}
+
+// LockoutDelay - длительность блокировки после failures неудачных входов подряд:
+// BaseDelay на пороге, дальше удваивается с каждой неудачей, но не больше MaxDelay
+func LockoutDelay(failures int, config LockoutConfig) time.Duration {
+	if config.Threshold <= 0 || failures < config.Threshold {
+		return 0
+	}
+
+	delay := config.BaseDelay
+	for range failures - config.Threshold {
+		delay *= 2
+		if delay >= config.MaxDelay {
+			return config.MaxDelay
+		}
+	}
+	return min(delay, config.MaxDelay)
+}
+
+func lockoutKey(email, ip string) string {
+	return normalizeEmail(email) + "|" + ip
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,133 @@
+//go:build integration
+
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/pkg/redis"
+)
+
+func connectRedis(t *testing.T) {
+	t.Helper()
+	if err := redis.Connect(redis.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to connect to redis: %v", err)
+	}
+	t.Cleanup(func() { redis.Close() })
+}
+
+func TestSlidingWindow(t *testing.T) {
+	connectRedis(t)
+	limiter := NewSlidingWindow(redis.Client)
+	ctx := context.Background()
+	key := "test:" + uuid.NewString()
+
+	for i := range 3 {
+		res, err := limiter.Allow(ctx, key, 3, 200*time.Millisecond)
+		if err != nil {
+			t.Fatalf("Allow() error = %v", err)
+		}
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d: got %+v", i+1, res)
+		}
+	}
+
+	res, err := limiter.Allow(ctx, key, 3, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	if res.Allowed || res.Remaining != 0 || res.Reset <= 0 {
+		t.Fatalf("expected request over limit to be rejected, got %+v", res)
+	}
+
+	time.Sleep(res.Reset + 10*time.Millisecond)
+	if res, _ := limiter.Allow(ctx, key, 3, 200*time.Millisecond); !res.Allowed {
+		t.Errorf("expected request to pass after window slides, got %+v", res)
+	}
+}
+
+func TestLockout(t *testing.T) {
+	connectRedis(t)
+	lockout := NewLockout(redis.Client, LockoutConfig{
+		Threshold:     2,
+		FailureWindow: time.Minute,
+		BaseDelay:     time.Second,
+		MaxDelay:      time.Minute,
+	})
+	ctx := context.Background()
+	email := uuid.NewString() + "@Example.com"
+	const ip, otherIP = "192.0.2.1", "198.51.100.7"
+
+	if delay, err := lockout.Fail(ctx, email, ip); err != nil || delay != 0 {
+		t.Fatalf("first Fail() = %v, %v, want no lock", delay, err)
+	}
+	if delay, err := lockout.Fail(ctx, email, ip); err != nil || delay != time.Second {
+		t.Fatalf("second Fail() = %v, %v, want 1s lock", delay, err)
+	}
+
+	locked, err := lockout.Locked(ctx, strings.ToUpper(email), ip)
+	if err != nil || locked <= 0 {
+		t.Fatalf("Locked() = %v, %v, want active lock", locked, err)
+	}
+	if locked, err := lockout.Locked(ctx, email, otherIP); err != nil || locked != 0 {
+		t.Errorf("Locked() from another address = %v, %v, want no lock", locked, err)
+	}
+
+	// Reset снимает счетчик только своего адреса
+	lockout.Fail(ctx, email, otherIP)
+	if err := lockout.Reset(ctx, email, otherIP); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if delay, _ := lockout.Fail(ctx, email, otherIP); delay != 0 {
+		t.Errorf("expected failures from %s to be reset, got lock %v", otherIP, delay)
+	}
+	if locked, _ := lockout.Locked(ctx, email, ip); locked <= 0 {
+		t.Errorf("expected lock from %s to stay after reset of %s", ip, otherIP)
+	}
+
+	if err := lockout.ResetAll(ctx, email); err != nil {
+		t.Fatalf("ResetAll() error = %v", err)
+	}
+	if locked, _ := lockout.Locked(ctx, email, ip); locked != 0 {
+		t.Errorf("expected lock to be removed after reset, got %v", locked)
+	}
+	if delay, _ := lockout.Fail(ctx, email, otherIP); delay != 0 {
+		t.Errorf("expected failures from %s to be reset, got lock %v", otherIP, delay)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	connectRedis(t)
+	limiter := NewTokenBucket(redis.Client)
+	ctx := context.Background()
+	key := "test:" + uuid.NewString()
+	// 600 в минуту - токен каждые 100мс
+	bucket := Bucket{PerMinute: 600, Burst: 3}
+
+	for i := range 3 {
+		res, err := limiter.Take(ctx, key, bucket)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d: got %+v", i+1, res)
+		}
+	}
+
+	res, err := limiter.Take(ctx, key, bucket)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if res.Allowed || res.Reset <= 0 || res.Reset > 100*time.Millisecond {
+		t.Fatalf("expected empty bucket, got %+v", res)
+	}
+
+	time.Sleep(res.Reset + 10*time.Millisecond)
+	if res, _ := limiter.Take(ctx, key, bucket); !res.Allowed {
+		t.Errorf("expected token after refill, got %+v", res)
+	}
+}
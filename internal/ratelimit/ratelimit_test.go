@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockoutDelay(t *testing.T) {
+	config := LockoutConfig{Threshold: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: 2, want: 0},
+		{failures: 3, want: time.Second},
+		{failures: 4, want: 2 * time.Second},
+		{failures: 6, want: 8 * time.Second},
+		{failures: 7, want: 10 * time.Second},
+		{failures: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := LockoutDelay(tt.failures, config); got != tt.want {
+			t.Errorf("LockoutDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+
+	if got := LockoutDelay(100, LockoutConfig{}); got != 0 {
+		t.Errorf("expected lockout to be disabled without threshold, got %v", got)
+	}
+}
+
+func TestLocalBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewLocalBucket()
+	limiter.now = func() time.Time { return now }
+	bucket := Bucket{PerMinute: 60, Burst: 2}
+	ctx := context.Background()
+
+	for i := range 2 {
+		if res, _ := limiter.Take(ctx, "user", bucket); !res.Allowed || res.Remaining != 1-i {
+			t.Fatalf("request %d: got %+v", i+1, res)
+		}
+	}
+
+	res, _ := limiter.Take(ctx, "user", bucket)
+	if res.Allowed || res.Reset != time.Second {
+		t.Fatalf("expected empty bucket with 1s until next token, got %+v", res)
+	}
+	if res, _ := limiter.Take(ctx, "other", bucket); !res.Allowed {
+		t.Errorf("buckets of different keys must be independent, got %+v", res)
+	}
+
+	// Полсекунды дает полтокена - этого мало
+	now = now.Add(500 * time.Millisecond)
+	if res, _ := limiter.Take(ctx, "user", bucket); res.Allowed || res.Reset != 500*time.Millisecond {
+		t.Fatalf("expected request to wait for a whole token, got %+v", res)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if res, _ := limiter.Take(ctx, "user", bucket); !res.Allowed {
+		t.Errorf("expected token after refill, got %+v", res)
+	}
+
+	// За долгий простой ведро наполняется не больше чем до Burst
+	now = now.Add(time.Hour)
+	for range 2 {
+		limiter.Take(ctx, "user", bucket)
+	}
+	if res, _ := limiter.Take(ctx, "user", bucket); res.Allowed {
+		t.Errorf("expected bucket capped at burst, got %+v", res)
+	}
+}
+
+type failingBucket struct{}
+
+func (failingBucket) Take(context.Context, string, Bucket) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func TestFallback(t *testing.T) {
+	limiter := NewFallback(failingBucket{}, NewLocalBucket())
+	bucket := Bucket{PerMinute: 1, Burst: 1}
+
+	if res, err := limiter.Take(context.Background(), "user", bucket); err != nil || !res.Allowed {
+		t.Fatalf("Take() = %+v, %v, want token from fallback", res, err)
+	}
+	if res, err := limiter.Take(context.Background(), "user", bucket); err != nil || res.Allowed {
+		t.Errorf("Take() = %+v, %v, want fallback limit to apply", res, err)
+	}
+}
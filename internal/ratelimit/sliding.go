@@ -0,0 +1,79 @@
+// Package ratelimit - ограничение частоты запросов и защита от перебора паролей на Redis
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const slidingPrefix = "ratelimit:"
+
+// Result - состояние лимита после запроса
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset - через сколько освободится место в окне
+	Reset time.Duration
+}
+
+// slidingWindowScript хранит моменты запросов в sorted set и атомарно чистит окно,
+// считает запросы и добавляет текущий, если лимит не исчерпан
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, limit - count, reset}
+`)
+
+// SlidingWindow - лимит на N запросов за скользящее окно. В отличие от фиксированного окна
+// не пропускает двойную пачку запросов на стыке окон
+type SlidingWindow struct {
+	redis *redis.Client
+}
+
+func NewSlidingWindow(redisClient *redis.Client) *SlidingWindow {
+	return &SlidingWindow{redis: redisClient}
+}
+
+func (s *SlidingWindow) Allow(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	now := time.Now().UnixMilli()
+	values, err := slidingWindowScript.Run(ctx, s.redis,
+		[]string{slidingPrefix + key},
+		now, window.Milliseconds(), limit, strconv.FormatInt(now, 10)+"-"+uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	return Result{
+		Allowed:   values[0] == 1,
+		Limit:     limit,
+		Remaining: int(max(values[1], 0)),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AnalyticsRepository struct {
+	db *pgxpool.Pool
+}
+
+// Разрешенные разрезы и периоды агрегации
+var (
+	ThroughputGroups = map[string]bool{
+		"pvz":  true,
+		"city": true,
+		"type": true,
+	}
+
+	ThroughputPeriods = map[string]bool{
+		"day":   true,
+		"week":  true,
+		"month": true,
+	}
+)
+
+type ThroughputFilter struct {
+	GroupBy string
+	Period  string
+	From    time.Time
+	To      time.Time
+}
+
+type ThroughputRow struct {
+	Bucket                      time.Time `json:"bucket"`
+	Key                         string    `json:"key"`
+	City                        string    `json:"city,omitempty"`
+	Products                    int64     `json:"products"`
+	Receptions                  int64     `json:"receptions"`
+	ProductsPerReception        float64   `json:"productsPerReception"`
+	AvgReceptionDurationSeconds *float64  `json:"avgReceptionDurationSeconds"`
+}
+
+func NewAnalyticsRepository(db *pgxpool.Pool) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+// Throughput считает товары и приемки по корзинам date_trunc.
+// Сначала агрегируем по приемке, чтобы длительность не взвешивалась числом товаров.
+// Корзина определяется временем открытия приемки
+func (r *AnalyticsRepository) Throughput(ctx context.Context, filter ThroughputFilter) ([]ThroughputRow, error) {
+	if !ThroughputGroups[filter.GroupBy] {
+		return nil, fmt.Errorf("invalid group: %s", filter.GroupBy)
+	}
+	if !ThroughputPeriods[filter.Period] {
+		return nil, fmt.Errorf("invalid period: %s", filter.Period)
+	}
+
+	var perReception string
+	switch filter.GroupBy {
+	case "type":
+		// Для разреза по типу приемка учитывается в каждом типе, который в ней был
+		perReception = `
+			SELECT r.id, pr.type AS key, '' AS city,
+			       date_trunc($1, r.date_time) AS bucket,
+			       EXTRACT(EPOCH FROM (r.closed_at - r.date_time))::float8 AS duration,
+			       COUNT(pr.id) AS products
+			FROM reception r
+			JOIN product pr ON pr.reception_id = r.id
+			WHERE r.date_time >= $2 AND r.date_time < $3
+			GROUP BY r.id, pr.type
+		`
+	case "pvz":
+		perReception = `
+			SELECT r.id, r.pvz_id::text AS key, p.city AS city,
+			       date_trunc($1, r.date_time) AS bucket,
+			       EXTRACT(EPOCH FROM (r.closed_at - r.date_time))::float8 AS duration,
+			       COUNT(pr.id) AS products
+			FROM reception r
+			JOIN pvz p ON p.id = r.pvz_id
+			LEFT JOIN product pr ON pr.reception_id = r.id
+			WHERE r.date_time >= $2 AND r.date_time < $3
+			GROUP BY r.id, p.city
+		`
+	case "city":
+		perReception = `
+			SELECT r.id, p.city AS key, p.city AS city,
+			       date_trunc($1, r.date_time) AS bucket,
+			       EXTRACT(EPOCH FROM (r.closed_at - r.date_time))::float8 AS duration,
+			       COUNT(pr.id) AS products
+			FROM reception r
+			JOIN pvz p ON p.id = r.pvz_id
+			LEFT JOIN product pr ON pr.reception_id = r.id
+			WHERE r.date_time >= $2 AND r.date_time < $3
+			GROUP BY r.id, p.city
+		`
+	}
+
+	query := `
+		WITH per_reception AS (` + perReception + `)
+		SELECT bucket, key, city,
+		       SUM(products)::bigint,
+		       COUNT(*),
+		       AVG(products)::float8,
+		       AVG(duration)
+		FROM per_reception
+		GROUP BY bucket, key, city
+		ORDER BY bucket, key
+	`
+
+	rows, err := r.db.Query(ctx, query, filter.Period, filter.From, filter.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query throughput: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]ThroughputRow, 0)
+	for rows.Next() {
+		var row ThroughputRow
+		if err := rows.Scan(
+			&row.Bucket,
+			&row.Key,
+			&row.City,
+			&row.Products,
+			&row.Receptions,
+			&row.ProductsPerReception,
+			&row.AvgReceptionDurationSeconds,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan throughput row: %w", err)
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read throughput rows: %w", err)
+	}
+
+	return result, nil
+}
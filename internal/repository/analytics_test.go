@@ -0,0 +1,122 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/testutils"
+)
+
+func TestAnalyticsRepository(t *testing.T) {
+	pool := testutils.SetupTestDB(t)
+	defer pool.Close()
+
+	repo := NewAnalyticsRepository(pool)
+	ctx := context.Background()
+
+	_, err := pool.Exec(ctx, "TRUNCATE pvz, reception, product CASCADE")
+	if err != nil {
+		t.Fatalf("Failed to cleanup tables: %v", err)
+	}
+
+	opened := time.Date(2025, 4, 10, 10, 0, 0, 0, time.UTC)
+	pvzID := uuid.New()
+	_, err = pool.Exec(ctx,
+		"INSERT INTO pvz (id, registration_date, city) VALUES ($1, $2, $3)",
+		pvzID, opened, "Казань")
+	if err != nil {
+		t.Fatalf("Failed to create test PVZ: %v", err)
+	}
+
+	// Закрытая приемка длительностью час с тремя товарами и открытая с одним
+	closedID := uuid.New()
+	_, err = pool.Exec(ctx,
+		"INSERT INTO reception (id, date_time, pvz_id, status, closed_at) VALUES ($1, $2, $3, $4, $5)",
+		closedID, opened, pvzID, models.StatusClosed, opened.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create closed reception: %v", err)
+	}
+
+	openID := uuid.New()
+	_, err = pool.Exec(ctx,
+		"INSERT INTO reception (id, date_time, pvz_id, status) VALUES ($1, $2, $3, $4)",
+		openID, opened.Add(2*time.Hour), pvzID, models.StatusInProgress)
+	if err != nil {
+		t.Fatalf("Failed to create open reception: %v", err)
+	}
+
+	products := []struct {
+		receptionID uuid.UUID
+		productType string
+	}{
+		{closedID, "обувь"},
+		{closedID, "обувь"},
+		{closedID, "одежда"},
+		{openID, "обувь"},
+	}
+	for _, p := range products {
+		_, err := pool.Exec(ctx,
+			"INSERT INTO product (id, type, reception_id) VALUES ($1, $2, $3)",
+			uuid.New(), p.productType, p.receptionID)
+		if err != nil {
+			t.Fatalf("Failed to create product: %v", err)
+		}
+	}
+
+	filter := ThroughputFilter{
+		Period: "day",
+		From:   opened.Add(-24 * time.Hour),
+		To:     opened.Add(24 * time.Hour),
+	}
+
+	t.Run("GroupByCity", func(t *testing.T) {
+		filter.GroupBy = "city"
+		rows, err := repo.Throughput(ctx, filter)
+		if err != nil {
+			t.Fatalf("Failed to get throughput: %v", err)
+		}
+		if len(rows) != 1 {
+			t.Fatalf("Got %d rows, want 1", len(rows))
+		}
+
+		row := rows[0]
+		if row.Key != "Казань" || row.Products != 4 || row.Receptions != 2 {
+			t.Errorf("Unexpected row: %+v", row)
+		}
+		if row.ProductsPerReception != 2 {
+			t.Errorf("Got %v products per reception, want 2", row.ProductsPerReception)
+		}
+		if row.AvgReceptionDurationSeconds == nil || *row.AvgReceptionDurationSeconds != 3600 {
+			t.Errorf("Got avg duration %v, want 3600", row.AvgReceptionDurationSeconds)
+		}
+	})
+
+	t.Run("GroupByType", func(t *testing.T) {
+		filter.GroupBy = "type"
+		rows, err := repo.Throughput(ctx, filter)
+		if err != nil {
+			t.Fatalf("Failed to get throughput: %v", err)
+		}
+
+		got := make(map[string]int64)
+		for _, row := range rows {
+			got[row.Key] = row.Products
+		}
+		if got["обувь"] != 3 || got["одежда"] != 1 {
+			t.Errorf("Unexpected products by type: %v", got)
+		}
+	})
+
+	t.Run("InvalidPeriod", func(t *testing.T) {
+		filter.GroupBy = "city"
+		filter.Period = "hour; DROP TABLE pvz"
+		if _, err := repo.Throughput(ctx, filter); err == nil {
+			t.Error("Expected error for invalid period")
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kosttiik/pvz-service/internal/models"
+)
+
+type ExportRepository struct {
+	db *pgxpool.Pool
+}
+
+// Строка выгрузки: приемка и один ее товар. Для приемки без товаров поля товара пустые
+type ExportRow struct {
+	PvzID             uuid.UUID
+	City              string
+	ReceptionID       uuid.UUID
+	ReceptionDateTime time.Time
+	ReceptionStatus   models.ReceptionStatus
+	ReceptionClosedAt *time.Time
+	ProductID         *uuid.UUID
+	ProductType       *string
+	ProductDateTime   *time.Time
+}
+
+func NewExportRepository(db *pgxpool.Pool) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+// StreamReceptions читает строки по мере их прихода от сервера и отдает их в fn,
+// не накапливая результат в памяти. Ошибка из fn прерывает чтение
+func (r *ExportRepository) StreamReceptions(ctx context.Context, from, to time.Time, fn func(ExportRow) error) error {
+	query := `
+		SELECT p.id, p.city,
+		       r.id, r.date_time, r.status, r.closed_at,
+		       pr.id, pr.type, pr.date_time
+		FROM reception r
+		JOIN pvz p ON p.id = r.pvz_id
+		LEFT JOIN product pr ON pr.reception_id = r.id
+		WHERE r.date_time >= $1 AND r.date_time < $2
+		ORDER BY r.date_time, r.id, pr.date_time
+	`
+
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query receptions export: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ExportRow
+		if err := rows.Scan(
+			&row.PvzID, &row.City,
+			&row.ReceptionID, &row.ReceptionDateTime, &row.ReceptionStatus, &row.ReceptionClosedAt,
+			&row.ProductID, &row.ProductType, &row.ProductDateTime,
+		); err != nil {
+			return fmt.Errorf("failed to scan export row: %w", err)
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read export rows: %w", err)
+	}
+
+	return nil
+}
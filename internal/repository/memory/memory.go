@@ -0,0 +1,469 @@
+// Package memory - in-memory реализации репозиториев для unit тестов сервисов и хендлеров.
+// Поведение повторяет Postgres версии из internal/repository, включая их ошибки,
+// но события в outbox не пишутся
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+)
+
+// Store - общее хранилище, на которое смотрят все репозитории одного набора.
+// Так, как и в базе, товар нельзя добавить в несуществующую приемку
+type Store struct {
+	mu         sync.Mutex
+	pvz        []models.PVZ
+	receptions []models.Reception
+	products   []models.Product
+	users      map[string]models.User
+	userTokens []models.UserToken
+	tokens     map[string]string
+	webhooks   []models.WebhookSubscription
+	deliveries []delivery
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:  make(map[string]models.User),
+		tokens: make(map[string]string),
+	}
+}
+
+func (s *Store) PVZ() *PVZRepository {
+	return &PVZRepository{store: s}
+}
+
+func (s *Store) Receptions() *ReceptionRepository {
+	return &ReceptionRepository{store: s}
+}
+
+func (s *Store) Products() *ProductRepository {
+	return &ProductRepository{store: s}
+}
+
+func (s *Store) Users() *UserRepository {
+	return &UserRepository{store: s}
+}
+
+func (s *Store) Tokens() *TokenStore {
+	return &TokenStore{store: s}
+}
+
+func (s *Store) Webhooks() *WebhookRepository {
+	return &WebhookRepository{store: s}
+}
+
+type PVZRepository struct {
+	store *Store
+}
+
+func (r *PVZRepository) Create(_ context.Context, pvz *models.PVZ) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.pvz = append(r.store.pvz, *pvz)
+	return nil
+}
+
+func (r *PVZRepository) GetByID(_ context.Context, id string) (*models.PVZ, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	pvz, ok := r.store.findPVZ(id)
+	if !ok {
+		return nil, repository.ErrPVZNotFound
+	}
+	return &pvz, nil
+}
+
+// GetCityActivity возвращает ПВЗ города с открытой приемкой и числом товаров в ней
+func (r *PVZRepository) GetCityActivity(_ context.Context, city string) ([]repository.PVZActivity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	activity := make([]repository.PVZActivity, 0)
+	for _, pvz := range r.store.pvz {
+		if pvz.City != city {
+			continue
+		}
+		a := repository.PVZActivity{PVZ: pvz}
+		if i, ok := r.store.lastOpenReception(pvz.ID.String()); ok {
+			reception := r.store.receptions[i]
+			a.OpenReception = &reception
+			a.ProductCount = len(r.store.productsOf(reception.ID.String()))
+		}
+		activity = append(activity, a)
+	}
+
+	sort.SliceStable(activity, func(i, j int) bool {
+		return activity[i].PVZ.RegistrationDate.Before(activity[j].PVZ.RegistrationDate)
+	})
+	return activity, nil
+}
+
+// Import создает ПВЗ, которых еще нет, и проставляет им версию. В dryRun ничего не сохраняется
+func (r *PVZRepository) Import(_ context.Context, pvzs []models.PVZ, dryRun bool) ([]bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	created := make([]bool, len(pvzs))
+	seen := make(map[uuid.UUID]bool, len(pvzs))
+	var imported []models.PVZ
+	for i := range pvzs {
+		pvz := &pvzs[i]
+		if _, ok := r.store.findPVZ(pvz.ID.String()); ok || seen[pvz.ID] {
+			continue
+		}
+		seen[pvz.ID] = true
+		created[i] = true
+		pvz.Version = 1
+		imported = append(imported, *pvz)
+	}
+
+	if !dryRun {
+		r.store.pvz = append(r.store.pvz, imported...)
+	}
+	return created, nil
+}
+
+// GetPVZ фильтрует ПВЗ по датам приемок и отдает страницу, новые ПВЗ первыми
+func (r *PVZRepository) GetPVZ(_ context.Context, filter repository.GetPVZFilter) ([]repository.PVZandReceptions, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	pvzs := slices.Clone(r.store.pvz)
+	sort.SliceStable(pvzs, func(i, j int) bool {
+		return pvzs[i].RegistrationDate.After(pvzs[j].RegistrationDate)
+	})
+
+	var result []repository.PVZandReceptions
+	for _, pvz := range pvzs {
+		item := repository.PVZandReceptions{PVZ: pvz}
+		for _, reception := range r.store.receptions {
+			if reception.PvzID != pvz.ID.String() {
+				continue
+			}
+			if filter.StartDate != nil && reception.DateTime.Before(*filter.StartDate) {
+				continue
+			}
+			if filter.EndDate != nil && reception.DateTime.After(*filter.EndDate) {
+				continue
+			}
+			item.Receptions = append(item.Receptions, repository.ReceptionAndProducts{
+				Reception: reception,
+				Products:  r.store.productsOf(reception.ID.String()),
+			})
+		}
+
+		if (filter.StartDate != nil || filter.EndDate != nil) && len(item.Receptions) == 0 {
+			continue
+		}
+		result = append(result, item)
+	}
+
+	if filter.Limit > 0 {
+		offset := max(filter.Page-1, 0) * filter.Limit
+		if offset >= len(result) {
+			return []repository.PVZandReceptions{}, nil
+		}
+		result = result[offset:min(offset+filter.Limit, len(result))]
+	}
+	return result, nil
+}
+
+type ReceptionRepository struct {
+	store *Store
+}
+
+func (r *ReceptionRepository) HasOpenReception(_ context.Context, pvzID string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	_, ok := r.store.lastOpenReception(pvzID)
+	return ok, nil
+}
+
+func (r *ReceptionRepository) Create(_ context.Context, reception *models.Reception, pvzVersion int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if !reception.Status.IsValid() {
+		return fmt.Errorf("invalid reception status: %s", reception.Status)
+	}
+	if _, err := r.store.bumpPVZVersion(reception.PvzID, pvzVersion); err != nil {
+		return err
+	}
+
+	r.store.receptions = append(r.store.receptions, *reception)
+	return nil
+}
+
+func (r *ReceptionRepository) GetLastOpenReception(_ context.Context, pvzID string) (*models.Reception, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i, ok := r.store.lastOpenReception(pvzID)
+	if !ok {
+		return nil, repository.ErrNoOpenReception
+	}
+	reception := r.store.receptions[i]
+	return &reception, nil
+}
+
+func (r *ReceptionRepository) CloseLastReception(_ context.Context, pvzID string, receptionVersion int) (*models.Reception, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i, ok := r.store.lastOpenReception(pvzID)
+	if !ok {
+		return nil, repository.ErrNoOpenReception
+	}
+	if _, err := r.store.bumpReceptionVersion(r.store.receptions[i].ID.String(), receptionVersion); err != nil {
+		return nil, err
+	}
+	if _, err := r.store.bumpPVZVersion(pvzID, repository.AnyVersion); err != nil {
+		return nil, err
+	}
+
+	r.store.receptions[i].Status = models.StatusClosed
+	reception := r.store.receptions[i]
+	return &reception, nil
+}
+
+type ProductRepository struct {
+	store *Store
+}
+
+func (r *ProductRepository) Create(_ context.Context, product *models.Product, receptionVersion int) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	version, err := r.store.bumpReceptionVersion(product.ReceptionID, receptionVersion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create product: %w", err)
+	}
+
+	r.store.products = append(r.store.products, *product)
+	return version, nil
+}
+
+// DeleteLastFromReception удаляет последний добавленный в приемку товар
+func (r *ProductRepository) DeleteLastFromReception(_ context.Context, receptionID string, receptionVersion int) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := len(r.store.products) - 1; i >= 0; i-- {
+		if r.store.products[i].ReceptionID == receptionID {
+			version, err := r.store.bumpReceptionVersion(receptionID, receptionVersion)
+			if err != nil {
+				return 0, err
+			}
+			r.store.products = slices.Delete(r.store.products, i, i+1)
+			return version, nil
+		}
+	}
+	return 0, errors.New("failed to delete last product: no products in reception")
+}
+
+// Products возвращает товары приемки в порядке добавления
+func (r *ProductRepository) Products(receptionID string) []models.Product {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.productsOf(receptionID)
+}
+
+type UserRepository struct {
+	store *Store
+}
+
+func (r *UserRepository) Create(_ context.Context, user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[user.Email]; ok {
+		return fmt.Errorf("failed to create user: email %s already exists", user.Email)
+	}
+	r.store.users[user.Email] = *user
+	return nil
+}
+
+func (r *UserRepository) ExistsByEmail(_ context.Context, email string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	_, ok := r.store.users[email]
+	return ok, nil
+}
+
+func (r *UserRepository) GetByID(_ context.Context, id uuid.UUID) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, user := range r.store.users {
+		if user.ID == id {
+			return &user, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *UserRepository) GetByEmail(_ context.Context, email string) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[email]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) CreateToken(_ context.Context, token *models.UserToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.userTokens = slices.DeleteFunc(r.store.userTokens, func(t models.UserToken) bool {
+		return t.UserID == token.UserID && t.Purpose == token.Purpose && t.UsedAt == nil
+	})
+	r.store.userTokens = append(r.store.userTokens, *token)
+	return nil
+}
+
+func (r *UserRepository) VerifyEmail(_ context.Context, tokenHash string) (*models.User, error) {
+	return r.consume(tokenHash, models.PurposeEmailVerification, func(*models.User) {})
+}
+
+func (r *UserRepository) ResetPassword(_ context.Context, tokenHash, passwordHash string) (*models.User, error) {
+	return r.consume(tokenHash, models.PurposePasswordReset, func(user *models.User) {
+		user.Password = passwordHash
+	})
+}
+
+// consume гасит токен и применяет update к его пользователю, как транзакция в Postgres версии.
+// Почта при этом отмечается подтвержденной
+func (r *UserRepository) consume(tokenHash string, purpose models.TokenPurpose, update func(*models.User)) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	i := slices.IndexFunc(r.store.userTokens, func(t models.UserToken) bool {
+		return t.Hash == tokenHash && t.Purpose == purpose && t.UsedAt == nil && t.ExpiresAt.After(now)
+	})
+	if i < 0 {
+		return nil, repository.ErrTokenNotFound
+	}
+
+	for email, user := range r.store.users {
+		if user.ID != r.store.userTokens[i].UserID {
+			continue
+		}
+		r.store.userTokens[i].UsedAt = &now
+		update(&user)
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = &now
+		}
+		r.store.users[email] = user
+		return &user, nil
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+// TokenStore повторяет cache.TokenCache: один токен на пользователя
+type TokenStore struct {
+	store *Store
+}
+
+func (t *TokenStore) Set(_ context.Context, userID string, token string) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	t.store.tokens[userID] = token
+	return nil
+}
+
+func (t *TokenStore) Get(_ context.Context, userID string) (string, error) {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	token, ok := t.store.tokens[userID]
+	if !ok {
+		return "", fmt.Errorf("token for user %s not found", userID)
+	}
+	return token, nil
+}
+
+func (t *TokenStore) Invalidate(_ context.Context, userID string) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	delete(t.store.tokens, userID)
+	return nil
+}
+
+// bumpPVZVersion повторяет проверку версии из Postgres репозиториев
+func (s *Store) bumpPVZVersion(pvzID string, expected int) (int, error) {
+	for i := range s.pvz {
+		if s.pvz[i].ID.String() != pvzID {
+			continue
+		}
+		if expected != repository.AnyVersion && s.pvz[i].Version != expected {
+			return 0, repository.ErrVersionMismatch
+		}
+		s.pvz[i].Version++
+		return s.pvz[i].Version, nil
+	}
+	return 0, repository.ErrPVZNotFound
+}
+
+// bumpReceptionVersion - то же для приемки
+func (s *Store) bumpReceptionVersion(receptionID string, expected int) (int, error) {
+	i := slices.IndexFunc(s.receptions, func(reception models.Reception) bool {
+		return reception.ID.String() == receptionID
+	})
+	if i < 0 {
+		return 0, fmt.Errorf("reception %s not found", receptionID)
+	}
+	if expected != repository.AnyVersion && s.receptions[i].Version != expected {
+		return 0, repository.ErrVersionMismatch
+	}
+	s.receptions[i].Version++
+	return s.receptions[i].Version, nil
+}
+
+func (s *Store) findPVZ(id string) (models.PVZ, bool) {
+	for _, pvz := range s.pvz {
+		if pvz.ID.String() == id {
+			return pvz, true
+		}
+	}
+	return models.PVZ{}, false
+}
+
+func (s *Store) lastOpenReception(pvzID string) (int, bool) {
+	for i := len(s.receptions) - 1; i >= 0; i-- {
+		if s.receptions[i].PvzID == pvzID && s.receptions[i].Status == models.StatusInProgress {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (s *Store) productsOf(receptionID string) []models.Product {
+	var products []models.Product
+	for _, product := range s.products {
+		if product.ReceptionID == receptionID {
+			products = append(products, product)
+		}
+	}
+	return products
+}
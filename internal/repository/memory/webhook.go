@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+)
+
+// delivery - доставка вебхука со временем следующей попытки, как колонка next_attempt_at
+type delivery struct {
+	models.WebhookDelivery
+	nextAttemptAt time.Time
+}
+
+// WebhookRepository повторяет repository.WebhookRepository: подписки, журнал
+// доставок и очередь, из которой их забирает webhook.Dispatcher
+type WebhookRepository struct {
+	store *Store
+}
+
+func (r *WebhookRepository) CreateSubscription(_ context.Context, sub *models.WebhookSubscription) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.webhooks = append(r.store.webhooks, *sub)
+	return nil
+}
+
+func (r *WebhookRepository) GetSubscription(_ context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.findWebhook(id)
+	if i < 0 {
+		return nil, repository.ErrWebhookNotFound
+	}
+	sub := r.store.webhooks[i]
+	return &sub, nil
+}
+
+// ListSubscriptions возвращает подписки, новые первыми
+func (r *WebhookRepository) ListSubscriptions(_ context.Context) ([]models.WebhookSubscription, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	subs := slices.Clone(r.store.webhooks)
+	sort.SliceStable(subs, func(i, j int) bool {
+		return subs[i].CreatedAt.After(subs[j].CreatedAt)
+	})
+	if subs == nil {
+		subs = []models.WebhookSubscription{}
+	}
+	return subs, nil
+}
+
+// DeleteSubscription удаляет подписку вместе с журналом ее доставок
+func (r *WebhookRepository) DeleteSubscription(_ context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	i := r.store.findWebhook(id)
+	if i < 0 {
+		return repository.ErrWebhookNotFound
+	}
+	r.store.webhooks = slices.Delete(r.store.webhooks, i, i+1)
+	r.store.deliveries = slices.DeleteFunc(r.store.deliveries, func(d delivery) bool {
+		return d.SubscriptionID == id
+	})
+	return nil
+}
+
+func (r *WebhookRepository) RecordDelivery(_ context.Context, d *models.WebhookDelivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.deliveries = append(r.store.deliveries, delivery{WebhookDelivery: *d, nextAttemptAt: time.Now()})
+	return nil
+}
+
+// ClaimDeliveries забирает ожидающие доставки, у которых подошло время попытки,
+// и откладывает их на lease, чтобы их не взял другой обработчик
+func (r *WebhookRepository) ClaimDeliveries(_ context.Context, limit int, lease time.Duration) ([]repository.WebhookTask, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	var tasks []repository.WebhookTask
+	for i := range r.store.deliveries {
+		d := &r.store.deliveries[i]
+		if len(tasks) == limit || d.Status != models.DeliveryPending || d.nextAttemptAt.After(now) {
+			continue
+		}
+		j := r.store.findWebhook(d.SubscriptionID)
+		if j < 0 {
+			continue
+		}
+		d.nextAttemptAt = now.Add(lease)
+		tasks = append(tasks, repository.WebhookTask{Delivery: d.WebhookDelivery, Subscription: r.store.webhooks[j]})
+	}
+	return tasks, nil
+}
+
+func (r *WebhookRepository) MarkDelivered(_ context.Context, id uuid.UUID, responseStatus int) error {
+	return r.update(id, func(d *delivery) {
+		now := time.Now().UTC()
+		d.Status = models.DeliveryDelivered
+		d.Attempts++
+		d.ResponseStatus = &responseStatus
+		d.LastError = nil
+		d.DeliveredAt = &now
+	})
+}
+
+func (r *WebhookRepository) MarkFailed(_ context.Context, id uuid.UUID, responseStatus *int, lastErr string, retryAfter time.Duration) error {
+	return r.update(id, func(d *delivery) {
+		d.Attempts++
+		d.ResponseStatus = responseStatus
+		d.LastError = &lastErr
+		d.nextAttemptAt = time.Now().Add(retryAfter)
+	})
+}
+
+func (r *WebhookRepository) MarkDead(_ context.Context, id uuid.UUID, responseStatus *int, lastErr string) error {
+	return r.update(id, func(d *delivery) {
+		d.Status = models.DeliveryDead
+		d.Attempts++
+		d.ResponseStatus = responseStatus
+		d.LastError = &lastErr
+	})
+}
+
+// ListDeliveries возвращает журнал доставок подписки, новые первыми
+func (r *WebhookRepository) ListDeliveries(_ context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for _, d := range r.store.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, d.WebhookDelivery)
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	return deliveries[:min(limit, len(deliveries))], nil
+}
+
+// update меняет доставку по ID. Как и UPDATE в Postgres версии, неизвестный ID не ошибка
+func (r *WebhookRepository) update(id uuid.UUID, fn func(*delivery)) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i := range r.store.deliveries {
+		if r.store.deliveries[i].ID == id {
+			fn(&r.store.deliveries[i])
+		}
+	}
+	return nil
+}
+
+func (s *Store) findWebhook(id uuid.UUID) int {
+	return slices.IndexFunc(s.webhooks, func(sub models.WebhookSubscription) bool {
+		return sub.ID == id
+	})
+}
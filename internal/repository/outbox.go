@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kosttiik/pvz-service/internal/models"
+)
+
+type OutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+// Запись outbox вместе со служебными полями доставки
+type OutboxRecord struct {
+	Event    models.Event
+	Attempts int
+}
+
+func NewOutboxRepository(db *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// insertEvent пишет событие в outbox в рамках транзакции вызывающего репозитория,
+// поэтому событие появляется только вместе с закоммиченными данными
+func insertEvent(ctx context.Context, tx pgx.Tx, eventType models.EventType, aggregateID uuid.UUID, pvzID string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO outbox (id, event_type, aggregate_id, pvz_id, payload)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := tx.Exec(ctx, query, uuid.New(), eventType, aggregateID, pvzID, data); err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// Claim забирает пачку готовых к отправке событий и продлевает их next_attempt_at на lease,
+// чтобы другие реплики не взяли те же записи, пока текущая их публикует
+func (r *OutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]OutboxRecord, error) {
+	query := `
+		UPDATE outbox
+		SET next_attempt_at = now() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE status = $3 AND next_attempt_at <= now()
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, aggregate_id, pvz_id, payload, created_at, attempts
+	`
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds(), models.OutboxPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var records []OutboxRecord
+	for rows.Next() {
+		var rec OutboxRecord
+		if err := rows.Scan(
+			&rec.Event.ID,
+			&rec.Event.Type,
+			&rec.Event.AggregateID,
+			&rec.Event.PvzID,
+			&rec.Event.Payload,
+			&rec.Event.CreatedAt,
+			&rec.Attempts,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox events: %w", err)
+	}
+
+	return records, nil
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE outbox
+		SET status = $2, published_at = now(), last_error = NULL
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id, models.OutboxPublished); err != nil {
+		return fmt.Errorf("failed to mark outbox event published: %w", err)
+	}
+	return nil
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, lastErr string, retryAfter time.Duration) error {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1,
+		    last_error = $2,
+		    next_attempt_at = now() + make_interval(secs => $3)
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id, lastErr, retryAfter.Seconds()); err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+	return nil
+}
+
+// DeletePublished удаляет события, опубликованные больше olderThan назад
+func (r *OutboxRepository) DeletePublished(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `
+		DELETE FROM outbox
+		WHERE status = $1 AND published_at < now() - make_interval(secs => $2)
+	`
+	tag, err := r.db.Exec(ctx, query, models.OutboxPublished, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete published outbox events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *OutboxRepository) MarkDead(ctx context.Context, id uuid.UUID, lastErr string) error {
+	query := `
+		UPDATE outbox
+		SET status = $3, attempts = attempts + 1, last_error = $2
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id, lastErr, models.OutboxDead); err != nil {
+		return fmt.Errorf("failed to mark outbox event dead: %w", err)
+	}
+	return nil
+}
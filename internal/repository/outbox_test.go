@@ -0,0 +1,106 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/testutils"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	pool := testutils.SetupTestDB(t)
+	defer pool.Close()
+
+	repo := NewOutboxRepository(pool)
+	pvzRepo := NewPVZRepository(pool)
+	ctx := context.Background()
+
+	_, err := pool.Exec(ctx, "TRUNCATE pvz, reception, product, outbox CASCADE")
+	if err != nil {
+		t.Fatalf("Failed to cleanup tables: %v", err)
+	}
+
+	pvz := &models.PVZ{
+		ID:               uuid.New(),
+		RegistrationDate: time.Now().UTC(),
+		City:             "Москва",
+	}
+
+	t.Run("CreateWritesEvent", func(t *testing.T) {
+		if err := pvzRepo.Create(ctx, pvz); err != nil {
+			t.Fatalf("Failed to create PVZ: %v", err)
+		}
+
+		records, err := repo.Claim(ctx, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("Failed to claim events: %v", err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("Got %d events, want 1", len(records))
+		}
+
+		event := records[0].Event
+		if event.Type != models.EventPVZCreated {
+			t.Errorf("Got event type %s, want %s", event.Type, models.EventPVZCreated)
+		}
+		if event.AggregateID != pvz.ID {
+			t.Errorf("Got aggregate ID %s, want %s", event.AggregateID, pvz.ID)
+		}
+	})
+
+	t.Run("ClaimedEventsAreLeased", func(t *testing.T) {
+		records, err := repo.Claim(ctx, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("Failed to claim events: %v", err)
+		}
+		if len(records) != 0 {
+			t.Errorf("Got %d events, leased events must not be claimed twice", len(records))
+		}
+	})
+
+	t.Run("MarkFailedAndPublished", func(t *testing.T) {
+		var id uuid.UUID
+		if err := pool.QueryRow(ctx, "SELECT id FROM outbox WHERE aggregate_id = $1", pvz.ID).Scan(&id); err != nil {
+			t.Fatalf("Failed to get outbox event: %v", err)
+		}
+
+		if err := repo.MarkFailed(ctx, id, "sink unavailable", 0); err != nil {
+			t.Fatalf("Failed to mark event failed: %v", err)
+		}
+
+		records, err := repo.Claim(ctx, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("Failed to claim events: %v", err)
+		}
+		if len(records) != 1 || records[0].Attempts != 1 {
+			t.Fatalf("Expected failed event to be claimed again with 1 attempt, got %v", records)
+		}
+
+		if err := repo.MarkPublished(ctx, id); err != nil {
+			t.Fatalf("Failed to mark event published: %v", err)
+		}
+
+		var status models.OutboxStatus
+		if err := pool.QueryRow(ctx, "SELECT status FROM outbox WHERE id = $1", id).Scan(&status); err != nil {
+			t.Fatalf("Failed to get outbox status: %v", err)
+		}
+		if status != models.OutboxPublished {
+			t.Errorf("Got status %s, want %s", status, models.OutboxPublished)
+		}
+	})
+
+	t.Run("DeletePublished", func(t *testing.T) {
+		// Свежие события переживают очистку, старые удаляются
+		if deleted, err := repo.DeletePublished(ctx, time.Hour); err != nil || deleted != 0 {
+			t.Fatalf("DeletePublished() = %d, %v, want nothing deleted", deleted, err)
+		}
+		if deleted, err := repo.DeletePublished(ctx, -time.Hour); err != nil || deleted != 1 {
+			t.Fatalf("DeletePublished() = %d, %v, want 1 deleted", deleted, err)
+		}
+	})
+}
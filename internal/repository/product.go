@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kosttiik/pvz-service/internal/models"
 )
@@ -16,32 +17,50 @@ func NewProductRepository(db *pgxpool.Pool) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (r *ProductRepository) Create(ctx context.Context, product *models.Product) error {
+// Create добавляет товар, если версия приемки совпадает с receptionVersion. Возвращает новую версию приемки
+func (r *ProductRepository) Create(ctx context.Context, product *models.Product, receptionVersion int) (int, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
 	query := `
 		INSERT INTO product (id, type, reception_id)
 		VALUES ($1, $2, $3)
+		RETURNING (SELECT pvz_id FROM reception WHERE id = $3)
 	`
-	if _, err := tx.Exec(ctx, query, product.ID, product.Type, product.ReceptionID); err != nil {
-		return fmt.Errorf("failed to create product: %w", err)
+	var pvzID string
+	if err := tx.QueryRow(ctx, query, product.ID, product.Type, product.ReceptionID).Scan(&pvzID); err != nil {
+		return 0, fmt.Errorf("failed to create product: %w", err)
+	}
+
+	version, err := bumpReceptionVersion(ctx, tx, product.ReceptionID, receptionVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	payload, err := productEvent(ctx, tx, *product, version)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertEvent(ctx, tx, models.EventProductAdded, product.ID, pvzID, payload); err != nil {
+		return 0, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return version, nil
 }
 
-func (r *ProductRepository) DeleteLastFromReception(ctx context.Context, receptionID string) error {
+// DeleteLastFromReception удаляет последний товар приемки, если ее версия совпадает с receptionVersion.
+// Возвращает новую версию приемки
+func (r *ProductRepository) DeleteLastFromReception(ctx context.Context, receptionID string, receptionVersion int) (int, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
@@ -54,17 +73,49 @@ func (r *ProductRepository) DeleteLastFromReception(ctx context.Context, recepti
             ORDER BY date_time DESC 
             LIMIT 1
         )
-        RETURNING id`
+        RETURNING id, date_time, type, reception_id,
+                  (SELECT pvz_id FROM reception WHERE id = $1)`
 
-	var deletedID string
-	err = tx.QueryRow(ctx, query, receptionID).Scan(&deletedID)
+	var deleted models.Product
+	var pvzID string
+	err = tx.QueryRow(ctx, query, receptionID).Scan(
+		&deleted.ID,
+		&deleted.DateTime,
+		&deleted.Type,
+		&deleted.ReceptionID,
+		&pvzID,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to delete last product: %w", err)
+		return 0, fmt.Errorf("failed to delete last product: %w", err)
+	}
+
+	version, err := bumpReceptionVersion(ctx, tx, receptionID, receptionVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	payload, err := productEvent(ctx, tx, deleted, version)
+	if err != nil {
+		return 0, err
+	}
+	if err := insertEvent(ctx, tx, models.EventProductDeleted, deleted.ID, pvzID, payload); err != nil {
+		return 0, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return version, nil
+}
+
+// productEvent дополняет товар состоянием приемки после изменения, чтобы подписчики
+// присваивали число товаров, а не считали его сами по событиям
+func productEvent(ctx context.Context, tx pgx.Tx, product models.Product, receptionVersion int) (models.ProductEvent, error) {
+	event := models.ProductEvent{Product: product, ReceptionVersion: receptionVersion}
+	query := "SELECT COUNT(*) FROM product WHERE reception_id = $1"
+	if err := tx.QueryRow(ctx, query, product.ReceptionID).Scan(&event.ProductCount); err != nil {
+		return event, fmt.Errorf("failed to count products: %w", err)
+	}
+	return event, nil
 }
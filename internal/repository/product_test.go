@@ -1,7 +1,10 @@
+//go:build integration
+
 package repository
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -57,14 +60,41 @@ func TestProductRepository(t *testing.T) {
 			ReceptionID: receptionID.String(),
 		}
 
-		if err := repo.Create(ctx, product); err != nil {
+		version, err := repo.Create(ctx, product, 1)
+		if err != nil {
 			t.Fatalf("Failed to create product: %v", err)
 		}
+		if version != 2 {
+			t.Errorf("Got reception version = %d, want 2", version)
+		}
+	})
+
+	t.Run("Create_StaleVersion", func(t *testing.T) {
+		product := &models.Product{
+			ID:          uuid.New(),
+			Type:        "одежда",
+			ReceptionID: receptionID.String(),
+		}
+
+		if _, err := repo.Create(ctx, product, 1); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("Expected ErrVersionMismatch, got %v", err)
+		}
 	})
 
 	t.Run("DeleteLastFromReception", func(t *testing.T) {
-		if err := repo.DeleteLastFromReception(ctx, receptionID.String()); err != nil {
+		version, err := repo.DeleteLastFromReception(ctx, receptionID.String(), AnyVersion)
+		if err != nil {
 			t.Fatalf("Failed to delete last product: %v", err)
 		}
+		if version != 3 {
+			t.Errorf("Got reception version = %d, want 3", version)
+		}
+
+		// Товары не меняют версию ПВЗ
+		var pvzVersion int
+		pool.QueryRow(ctx, "SELECT version FROM pvz WHERE id = $1", pvzID).Scan(&pvzVersion)
+		if pvzVersion != 1 {
+			t.Errorf("Got pvz version = %d, want 1", pvzVersion)
+		}
 	})
 }
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -14,6 +15,8 @@ import (
 	"go.uber.org/zap"
 )
 
+var ErrPVZNotFound = errors.New("pvz not found")
+
 type PVZRepository struct {
 	db *pgxpool.Pool
 }
@@ -35,12 +38,144 @@ type ReceptionAndProducts struct {
 	Products  []models.Product
 }
 
+// Текущее состояние ПВЗ для дашборда: открытая приемка и число товаров в ней
+type PVZActivity struct {
+	PVZ           models.PVZ        `json:"pvz"`
+	OpenReception *models.Reception `json:"openReception"`
+	ProductCount  int               `json:"productCount"`
+}
+
 func NewPVZRepository(db *pgxpool.Pool) *PVZRepository {
 	return &PVZRepository{db: db}
 }
 
+func (r *PVZRepository) Create(ctx context.Context, pvz *models.PVZ) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO pvz (id, registration_date, city, version)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.Exec(ctx, query, pvz.ID, pvz.RegistrationDate, pvz.City, pvz.Version); err != nil {
+		return fmt.Errorf("failed to create pvz: %w", err)
+	}
+
+	if err := insertEvent(ctx, tx, models.EventPVZCreated, pvz.ID, pvz.ID.String(), pvz); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PVZRepository) GetByID(ctx context.Context, id string) (*models.PVZ, error) {
+	query := `
+		SELECT id, registration_date, city, version
+		FROM pvz
+		WHERE id = $1
+	`
+
+	pvz := &models.PVZ{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&pvz.ID,
+		&pvz.RegistrationDate,
+		&pvz.City,
+		&pvz.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPVZNotFound
+		}
+		return nil, fmt.Errorf("failed to get pvz: %w", err)
+	}
+
+	return pvz, nil
+}
+
+func (r *PVZRepository) GetCityActivity(ctx context.Context, city string) ([]PVZActivity, error) {
+	query := `
+		SELECT p.id, p.registration_date, p.city, p.version,
+		       r.id, r.date_time, r.version, COUNT(pr.id)
+		FROM pvz p
+		LEFT JOIN reception r ON r.pvz_id = p.id AND r.status = $2
+		LEFT JOIN product pr ON pr.reception_id = r.id
+		WHERE p.city = $1
+		GROUP BY p.id, r.id
+		ORDER BY p.registration_date
+	`
+
+	rows, err := r.db.Query(ctx, query, city, models.StatusInProgress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query city activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := make([]PVZActivity, 0)
+	for rows.Next() {
+		var a PVZActivity
+		var receptionID *uuid.UUID
+		var receptionDateTime *time.Time
+		var receptionVersion *int
+
+		if err := rows.Scan(
+			&a.PVZ.ID, &a.PVZ.RegistrationDate, &a.PVZ.City, &a.PVZ.Version,
+			&receptionID, &receptionDateTime, &receptionVersion, &a.ProductCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan city activity: %w", err)
+		}
+
+		if receptionID != nil {
+			a.OpenReception = &models.Reception{
+				ID:       *receptionID,
+				DateTime: *receptionDateTime,
+				PvzID:    a.PVZ.ID.String(),
+				Status:   models.StatusInProgress,
+				Version:  *receptionVersion,
+			}
+		}
+		activity = append(activity, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read city activity: %w", err)
+	}
+
+	return activity, nil
+}
+
+// ReceptionDates возвращает даты всех приемок ПВЗ. По ним кэш списка ПВЗ понимает,
+// в какие диапазоны фильтра попадает ПВЗ
+func (r *PVZRepository) ReceptionDates(ctx context.Context, pvzID string) ([]time.Time, error) {
+	rows, err := r.db.Query(ctx, `SELECT date_time FROM reception WHERE pvz_id = $1`, pvzID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reception dates: %w", err)
+	}
+
+	defer rows.Close()
+
+	var dates []time.Time
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			return nil, fmt.Errorf("failed to scan reception date: %w", err)
+		}
+		dates = append(dates, date)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reception dates: %w", err)
+	}
+
+	return dates, nil
+}
+
 func (r *PVZRepository) GetPVZ(ctx context.Context, filter GetPVZFilter) ([]PVZandReceptions, error) {
-	log := logger.Log
+	log := logger.FromContext(ctx)
 
 	log.Debug("Getting PVZ list with filter",
 		zap.Any("filter", filter))
@@ -77,8 +212,8 @@ func (r *PVZRepository) GetPVZ(ctx context.Context, filter GetPVZFilter) ([]PVZa
 
 	query += fmt.Sprintf(`
         )
-        SELECT p.id, p.registration_date, p.city,
-               r.id, r.date_time, r.status,
+        SELECT p.id, p.registration_date, p.city, p.version,
+               r.id, r.date_time, r.status, r.version,
                pr.id, pr.date_time, pr.type
         FROM filtered_pvz p
         LEFT JOIN reception r ON p.id = r.pvz_id
@@ -104,11 +239,12 @@ func (r *PVZRepository) GetPVZ(ctx context.Context, filter GetPVZFilter) ([]PVZa
 	for rows.Next() {
 		var pvz models.PVZ
 		var receptionID, receptionDateTime, receptionStatus sql.NullString
+		var receptionVersion sql.NullInt32
 		var productID, productDateTime, productType sql.NullString
 
 		err := rows.Scan(
-			&pvz.ID, &pvz.RegistrationDate, &pvz.City,
-			&receptionID, &receptionDateTime, &receptionStatus,
+			&pvz.ID, &pvz.RegistrationDate, &pvz.City, &pvz.Version,
+			&receptionID, &receptionDateTime, &receptionStatus, &receptionVersion,
 			&productID, &productDateTime, &productType,
 		)
 		if err != nil {
@@ -129,6 +265,7 @@ func (r *PVZRepository) GetPVZ(ctx context.Context, filter GetPVZFilter) ([]PVZa
 				DateTime: parseTime(receptionDateTime.String),
 				Status:   models.ReceptionStatus(receptionStatus.String),
 				PvzID:    pvz.ID.String(),
+				Version:  int(receptionVersion.Int32),
 			}
 
 			// Ищем существующую приемку или создаем новую
@@ -190,3 +327,47 @@ func parseTime(t string) time.Time {
 	}
 	return parsed
 }
+
+// Import создает ПВЗ одной транзакцией и проставляет созданным версию. ПВЗ с уже существующим id
+// пропускаются, результат по каждому ПВЗ возвращается в том же порядке. При dryRun транзакция откатывается
+func (r *PVZRepository) Import(ctx context.Context, pvzs []models.PVZ, dryRun bool) ([]bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO pvz (id, registration_date, city)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO NOTHING
+		RETURNING version
+	`
+
+	created := make([]bool, len(pvzs))
+	for i := range pvzs {
+		pvz := &pvzs[i]
+		err := tx.QueryRow(ctx, query, pvz.ID, pvz.RegistrationDate, pvz.City).Scan(&pvz.Version)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to import pvz %s: %w", pvz.ID, err)
+		}
+		created[i] = true
+
+		if err := insertEvent(ctx, tx, models.EventPVZCreated, pvz.ID, pvz.ID.String(), pvz); err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return created, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
@@ -1,3 +1,5 @@
+//go:build integration
+
 package repository
 
 import (
@@ -86,4 +88,33 @@ func TestPVZRepository(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("ReceptionDates", func(t *testing.T) {
+		dates, err := repo.ReceptionDates(ctx, pvzID.String())
+		if err != nil {
+			t.Fatalf("Failed to get reception dates: %v", err)
+		}
+
+		if len(dates) != 1 || !dates[0].Equal(baseTime.Truncate(time.Microsecond)) {
+			t.Errorf("Expected reception date %v, got %v", baseTime, dates)
+		}
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		pvzs := []models.PVZ{
+			{ID: uuid.New(), City: "Казань", RegistrationDate: baseTime},
+			{ID: pvzID, City: "Москва", RegistrationDate: baseTime},
+		}
+
+		created, err := repo.Import(ctx, pvzs, false)
+		if err != nil {
+			t.Fatalf("Failed to import PVZ: %v", err)
+		}
+		if !created[0] || created[1] {
+			t.Errorf("Got created = %v, want only the new PVZ", created)
+		}
+		if pvzs[0].Version != 1 {
+			t.Errorf("Got imported PVZ version = %d, want 1", pvzs[0].Version)
+		}
+	})
 }
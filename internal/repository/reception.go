@@ -12,6 +12,8 @@ import (
 	"go.uber.org/zap"
 )
 
+var ErrNoOpenReception = errors.New("no open reception found")
+
 type ReceptionRepository struct {
 	db *pgxpool.Pool
 }
@@ -34,8 +36,9 @@ func (r *ReceptionRepository) HasOpenReception(ctx context.Context, pvzID string
 	return exists, nil
 }
 
-func (r *ReceptionRepository) Create(ctx context.Context, reception *models.Reception) error {
-	log := logger.Log
+// Create создает приемку и увеличивает версию ПВЗ, если она совпадает с pvzVersion
+func (r *ReceptionRepository) Create(ctx context.Context, reception *models.Reception, pvzVersion int) error {
+	log := logger.FromContext(ctx)
 	log.Debug("Creating reception",
 		zap.String("id", reception.ID.String()),
 		zap.String("pvzID", reception.PvzID))
@@ -50,14 +53,24 @@ func (r *ReceptionRepository) Create(ctx context.Context, reception *models.Rece
 	}
 	defer tx.Rollback(ctx)
 
+	version, err := bumpPVZVersion(ctx, tx, reception.PvzID, pvzVersion)
+	if err != nil {
+		return err
+	}
+
 	query := `
-        INSERT INTO reception (id, date_time, pvz_id, status)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO reception (id, date_time, pvz_id, status, version)
+        VALUES ($1, $2, $3, $4, $5)
     `
-	if _, err := tx.Exec(ctx, query, reception.ID, reception.DateTime, reception.PvzID, reception.Status); err != nil {
+	if _, err := tx.Exec(ctx, query, reception.ID, reception.DateTime, reception.PvzID, reception.Status, reception.Version); err != nil {
 		return fmt.Errorf("failed to create reception: %w", err)
 	}
 
+	payload := models.ReceptionEvent{Reception: *reception, PVZVersion: version}
+	if err := insertEvent(ctx, tx, models.EventReceptionCreated, reception.ID, reception.PvzID, payload); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -71,7 +84,7 @@ func (r *ReceptionRepository) Create(ctx context.Context, reception *models.Rece
 
 func (r *ReceptionRepository) GetLastOpenReception(ctx context.Context, pvzID string) (*models.Reception, error) {
 	query := `
-		SELECT id, date_time, pvz_id, status
+		SELECT id, date_time, pvz_id, status, version
 		FROM reception
 		WHERE pvz_id = $1 AND status = 'in_progress'
 		ORDER BY date_time DESC
@@ -83,18 +96,21 @@ func (r *ReceptionRepository) GetLastOpenReception(ctx context.Context, pvzID st
 		&reception.DateTime,
 		&reception.PvzID,
 		&reception.Status,
+		&reception.Version,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("no open reception found")
+			return nil, ErrNoOpenReception
 		}
 		return nil, fmt.Errorf("failed to get reception: %w", err)
 	}
 	return reception, nil
 }
 
-func (r *ReceptionRepository) CloseLastReception(ctx context.Context, pvzID string) (*models.Reception, error) {
-	log := logger.Log
+// CloseLastReception закрывает открытую приемку, если ее версия совпадает с receptionVersion,
+// и увеличивает версии приемки и ПВЗ. Возвращает закрытую приемку с новой версией
+func (r *ReceptionRepository) CloseLastReception(ctx context.Context, pvzID string, receptionVersion int) (*models.Reception, error) {
+	log := logger.FromContext(ctx)
 	log.Debug("Closing last reception",
 		zap.String("pvzID", pvzID))
 
@@ -106,14 +122,14 @@ func (r *ReceptionRepository) CloseLastReception(ctx context.Context, pvzID stri
 
 	query := `
         UPDATE reception 
-        SET status = $1
+        SET status = $1, closed_at = now() AT TIME ZONE 'UTC', version = version + 1
         WHERE id = (
             SELECT id FROM reception
             WHERE pvz_id = $2 AND status = $3
             ORDER BY date_time DESC
             LIMIT 1
-        )
-        RETURNING id, date_time, pvz_id, status
+        ) AND ($4::int = 0 OR version = $4::int)
+        RETURNING id, date_time, pvz_id, status, version
     `
 
 	reception := &models.Reception{}
@@ -121,19 +137,32 @@ func (r *ReceptionRepository) CloseLastReception(ctx context.Context, pvzID stri
 		models.StatusClosed,
 		pvzID,
 		models.StatusInProgress,
+		receptionVersion,
 	).Scan(
 		&reception.ID,
 		&reception.DateTime,
 		&reception.PvzID,
 		&reception.Status,
+		&reception.Version,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("no open reception found")
+			return nil, r.closeMiss(ctx, pvzID)
 		}
 		return nil, fmt.Errorf("failed to close reception: %w", err)
 	}
 
+	// Закрытие меняет состояние ПВЗ, поэтому растет и его версия
+	version, err := bumpPVZVersion(ctx, tx, pvzID, AnyVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := models.ReceptionEvent{Reception: *reception, PVZVersion: version}
+	if err := insertEvent(ctx, tx, models.EventReceptionClosed, reception.ID, reception.PvzID, payload); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -143,3 +172,45 @@ func (r *ReceptionRepository) CloseLastReception(ctx context.Context, pvzID stri
 		zap.String("pvzID", reception.PvzID))
 	return reception, nil
 }
+
+// closeMiss объясняет, почему приемка не закрылась: ее нет или версия не совпала
+func (r *ReceptionRepository) closeMiss(ctx context.Context, pvzID string) error {
+	hasOpen, err := r.HasOpenReception(ctx, pvzID)
+	if err != nil {
+		return err
+	}
+	if hasOpen {
+		return ErrVersionMismatch
+	}
+	return ErrNoOpenReception
+}
+
+// CountOpenByCity возвращает число открытых приемок в каждом городе
+func (r *ReceptionRepository) CountOpenByCity(ctx context.Context) (map[string]int, error) {
+	query := `
+		SELECT p.city, COUNT(*)
+		FROM reception r
+		JOIN pvz p ON p.id = r.pvz_id
+		WHERE r.status = $1
+		GROUP BY p.city
+	`
+	rows, err := r.db.Query(ctx, query, models.StatusInProgress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open receptions: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var city string
+		var count int
+		if err := rows.Scan(&city, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan open receptions: %w", err)
+		}
+		counts[city] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count open receptions: %w", err)
+	}
+	return counts, nil
+}
@@ -1,7 +1,10 @@
+//go:build integration
+
 package repository
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -40,9 +43,10 @@ func TestReceptionRepository(t *testing.T) {
 			DateTime: time.Now(),
 			PvzID:    pvzID.String(),
 			Status:   models.StatusInProgress,
+			Version:  1,
 		}
 
-		if err := repo.Create(ctx, reception); err != nil {
+		if err := repo.Create(ctx, reception, 1); err != nil {
 			t.Fatalf("Failed to create reception: %v", err)
 		}
 
@@ -67,6 +71,12 @@ func TestReceptionRepository(t *testing.T) {
 		}
 	})
 
+	t.Run("CloseLastReception_StaleVersion", func(t *testing.T) {
+		if _, err := repo.CloseLastReception(ctx, pvzID.String(), 2); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("Expected ErrVersionMismatch, got %v", err)
+		}
+	})
+
 	t.Run("CloseLastReception", func(t *testing.T) {
 		// Создаем тестовую приёмку
 		newReceptionID := uuid.New()
@@ -77,7 +87,7 @@ func TestReceptionRepository(t *testing.T) {
 			t.Fatalf("Failed to create test reception: %v", err)
 		}
 
-		reception, err := repo.CloseLastReception(ctx, pvzID.String())
+		reception, err := repo.CloseLastReception(ctx, pvzID.String(), AnyVersion)
 		if err != nil {
 			t.Fatalf("Failed to close reception: %v", err)
 		}
@@ -97,7 +107,7 @@ func TestReceptionRepository(t *testing.T) {
 
 	t.Run("CloseLastReception_NoOpenReception", func(t *testing.T) {
 		nonExistentPVZID := uuid.New().String()
-		_, err := repo.CloseLastReception(ctx, nonExistentPVZID)
+		_, err := repo.CloseLastReception(ctx, nonExistentPVZID, AnyVersion)
 		if err == nil {
 			t.Error("Expected error when no open reception exists")
 		}
@@ -110,12 +120,24 @@ func TestReceptionRepository(t *testing.T) {
 			PvzID:    pvzID.String(),
 			Status:   models.StatusInProgress,
 		}
-		err := repo.Create(ctx, reception)
+		err := repo.Create(ctx, reception, AnyVersion)
 		if err == nil {
 			t.Error("Expected error when creating reception with duplicate ID")
 		}
 	})
 
+	t.Run("Create_StaleVersion", func(t *testing.T) {
+		reception := &models.Reception{
+			ID:       uuid.New(),
+			DateTime: time.Now(),
+			PvzID:    pvzID.String(),
+			Status:   models.StatusInProgress,
+		}
+		if err := repo.Create(ctx, reception, 1); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("Expected ErrVersionMismatch, got %v", err)
+		}
+	})
+
 	t.Run("HasOpenReception_NonExistentPVZ", func(t *testing.T) {
 		hasOpen, err := repo.HasOpenReception(ctx, uuid.New().String())
 		if err != nil {
@@ -127,7 +149,7 @@ func TestReceptionRepository(t *testing.T) {
 	})
 
 	t.Run("CloseReception_StatusChange", func(t *testing.T) {
-		reception, err := repo.CloseLastReception(ctx, pvzID.String())
+		reception, err := repo.CloseLastReception(ctx, pvzID.String(), AnyVersion)
 		if err != nil {
 			t.Fatalf("Failed to close reception: %v", err)
 		}
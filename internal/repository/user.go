@@ -7,10 +7,17 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kosttiik/pvz-service/internal/models"
 )
 
+var (
+	ErrUserNotFound = errors.New("user not found")
+	// ErrTokenNotFound - токена из письма нет, он истек или уже использован
+	ErrTokenNotFound = errors.New("token not found")
+)
+
 type UserRepository struct {
 	db *pgxpool.Pool
 }
@@ -21,20 +28,29 @@ func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, password, role)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (id, email, password, role, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5)
 	`
 
-	if _, err := r.db.Exec(ctx, query, user.ID, user.Email, user.Password, user.Role); err != nil {
+	if _, err := r.db.Exec(ctx, query, user.ID, user.Email, user.Password, user.Role, user.EmailVerifiedAt); err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
 	return nil
 }
 
+func (r *UserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)", email).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user email: %w", err)
+	}
+	return exists, nil
+}
+
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	query := `
-        SELECT id, email, password, role
+        SELECT id, email, password, role, email_verified_at
         FROM users
         WHERE id = $1
     `
@@ -45,11 +61,12 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 		&user.Email,
 		&user.Password,
 		&user.Role,
+		&user.EmailVerifiedAt,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -60,7 +77,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, email, password, role
+		SELECT id, email, password, role, email_verified_at
 		FROM users
 		WHERE email = $1
 	`
@@ -71,11 +88,12 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 		&user.Email,
 		&user.Password,
 		&user.Role,
+		&user.EmailVerifiedAt,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user not found: %w", err)
+			return nil, ErrUserNotFound
 		}
 
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -83,3 +101,133 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 
 	return user, nil
 }
+
+// CreateToken сохраняет токен из письма. Прежние неиспользованные токены пользователя
+// с тем же назначением удаляются, действует только последнее письмо
+func (r *UserRepository) CreateToken(ctx context.Context, token *models.UserToken) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		"DELETE FROM user_token WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL",
+		token.UserID, token.Purpose); err != nil {
+		return fmt.Errorf("failed to delete previous tokens: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_token (id, user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := tx.Exec(ctx, query, token.ID, token.UserID, token.Purpose, token.Hash, token.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to create token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyEmail гасит токен подтверждения почты и отмечает почту подтвержденной
+func (r *UserRepository) VerifyEmail(ctx context.Context, tokenHash string) (*models.User, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	userID, err := consumeToken(ctx, tx, tokenHash, models.PurposeEmailVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := updateUser(ctx, tx, userID, `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, now()), updated_at = now()
+		WHERE id = $1
+		RETURNING id, email, password, role, email_verified_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return user, nil
+}
+
+// ResetPassword гасит токен сброса и меняет хеш пароля. Письмо дошло до владельца
+// адреса, поэтому почта заодно считается подтвержденной
+func (r *UserRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (*models.User, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	userID, err := consumeToken(ctx, tx, tokenHash, models.PurposePasswordReset)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := updateUser(ctx, tx, userID, `
+		UPDATE users
+		SET password = $2, email_verified_at = COALESCE(email_verified_at, now()), updated_at = now()
+		WHERE id = $1
+		RETURNING id, email, password, role, email_verified_at
+	`, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return user, nil
+}
+
+// consumeToken атомарно отмечает токен использованным, так что погасить его можно только один раз
+func consumeToken(ctx context.Context, tx pgx.Tx, tokenHash string, purpose models.TokenPurpose) (uuid.UUID, error) {
+	query := `
+		UPDATE user_token
+		SET used_at = now()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > now()
+		RETURNING user_id
+	`
+
+	var userID uuid.UUID
+	if err := tx.QueryRow(ctx, query, tokenHash, purpose).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, ErrTokenNotFound
+		}
+		return uuid.Nil, fmt.Errorf("failed to consume token: %w", err)
+	}
+
+	return userID, nil
+}
+
+func updateUser(ctx context.Context, tx pgx.Tx, userID uuid.UUID, query string, args ...any) (*models.User, error) {
+	user := &models.User{}
+	err := tx.QueryRow(ctx, query, append([]any{userID}, args...)...).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.EmailVerifiedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return user, nil
+}
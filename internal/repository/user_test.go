@@ -1,8 +1,13 @@
+//go:build integration
+
 package repository
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kosttiik/pvz-service/internal/models"
@@ -79,4 +84,64 @@ func TestUserRepository(t *testing.T) {
 			t.Error("Expected error when creating user with duplicate email")
 		}
 	})
+	t.Run("Tokens", func(t *testing.T) {
+		user := &models.User{
+			ID:       uuid.New(),
+			Email:    "tokens@example.com",
+			Password: "hashedpass",
+			Role:     "employee",
+		}
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+
+		newToken := func(purpose models.TokenPurpose, hash string, ttl time.Duration) {
+			err := repo.CreateToken(ctx, &models.UserToken{
+				ID:        uuid.New(),
+				UserID:    user.ID,
+				Purpose:   purpose,
+				Hash:      hash,
+				ExpiresAt: time.Now().Add(ttl),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create token: %v", err)
+			}
+		}
+
+		// Новый токен заменяет неиспользованный старый
+		newToken(models.PurposeEmailVerification, strings.Repeat("a", 64), time.Hour)
+		newToken(models.PurposeEmailVerification, strings.Repeat("b", 64), time.Hour)
+		if _, err := repo.VerifyEmail(ctx, strings.Repeat("a", 64)); !errors.Is(err, ErrTokenNotFound) {
+			t.Errorf("Expected ErrTokenNotFound for replaced token, got %v", err)
+		}
+
+		verified, err := repo.VerifyEmail(ctx, strings.Repeat("b", 64))
+		if err != nil {
+			t.Fatalf("Failed to verify email: %v", err)
+		}
+		if verified.EmailVerifiedAt == nil {
+			t.Error("Expected email to be verified")
+		}
+		if _, err := repo.VerifyEmail(ctx, strings.Repeat("b", 64)); !errors.Is(err, ErrTokenNotFound) {
+			t.Errorf("Expected ErrTokenNotFound for used token, got %v", err)
+		}
+
+		// Токен одного назначения не подходит для другого
+		newToken(models.PurposePasswordReset, strings.Repeat("c", 64), time.Hour)
+		if _, err := repo.VerifyEmail(ctx, strings.Repeat("c", 64)); !errors.Is(err, ErrTokenNotFound) {
+			t.Errorf("Expected ErrTokenNotFound for wrong purpose, got %v", err)
+		}
+		reset, err := repo.ResetPassword(ctx, strings.Repeat("c", 64), "newhash")
+		if err != nil {
+			t.Fatalf("Failed to reset password: %v", err)
+		}
+		if reset.Password != "newhash" {
+			t.Errorf("Got password %q, want newhash", reset.Password)
+		}
+
+		newToken(models.PurposePasswordReset, strings.Repeat("d", 64), -time.Minute)
+		if _, err := repo.ResetPassword(ctx, strings.Repeat("d", 64), "otherhash"); !errors.Is(err, ErrTokenNotFound) {
+			t.Errorf("Expected ErrTokenNotFound for expired token, got %v", err)
+		}
+	})
 }
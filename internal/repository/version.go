@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrVersionMismatch - ресурс изменился с тех пор, как клиент получил его версию
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// AnyVersion - изменение без проверки версии (If-Match: *)
+const AnyVersion = 0
+
+// bumpPVZVersion увеличивает версию ПВЗ в транзакции изменения и возвращает новую.
+// Строка ПВЗ блокируется до конца транзакции, поэтому из параллельных изменений
+// с одной версией проходит только первое
+func bumpPVZVersion(ctx context.Context, tx pgx.Tx, pvzID string, expected int) (int, error) {
+	query := `
+		UPDATE pvz SET version = version + 1
+		WHERE id = $1 AND ($2::int = 0 OR version = $2::int)
+		RETURNING version
+	`
+	var version int
+	err := tx.QueryRow(ctx, query, pvzID, expected).Scan(&version)
+	if err == nil {
+		return version, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to update pvz version: %w", err)
+	}
+
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pvz WHERE id = $1)", pvzID).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("failed to check pvz: %w", err)
+	}
+	if !exists {
+		return 0, ErrPVZNotFound
+	}
+	return 0, ErrVersionMismatch
+}
+
+// bumpReceptionVersion - то же для приемки: ее версией защищены товары и закрытие приемки
+func bumpReceptionVersion(ctx context.Context, tx pgx.Tx, receptionID string, expected int) (int, error) {
+	query := `
+		UPDATE reception SET version = version + 1
+		WHERE id = $1 AND ($2::int = 0 OR version = $2::int)
+		RETURNING version
+	`
+	var version int
+	err := tx.QueryRow(ctx, query, receptionID, expected).Scan(&version)
+	if err == nil {
+		return version, nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrVersionMismatch
+	}
+	return 0, fmt.Errorf("failed to update reception version: %w", err)
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kosttiik/pvz-service/internal/models"
+)
+
+var ErrWebhookNotFound = errors.New("webhook subscription not found")
+
+type WebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+// Доставка вместе с подпиской, по которой ее надо отправить
+type WebhookTask struct {
+	Delivery     models.WebhookDelivery
+	Subscription models.WebhookSubscription
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscription (id, url, event_types, secret, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := r.db.Exec(ctx, query,
+		sub.ID, sub.URL, eventTypesToStrings(sub.EventTypes), sub.Secret, sub.CreatedBy, sub.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	query := `
+		SELECT id, url, event_types, secret, created_by, created_at
+		FROM webhook_subscription
+		WHERE id = $1
+	`
+	sub, err := scanSubscription(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	query := `
+		SELECT id, url, event_types, secret, created_by, created_at
+		FROM webhook_subscription
+		ORDER BY created_at DESC
+	`
+	return r.querySubscriptions(ctx, query)
+}
+
+// FindByEventType возвращает подписки, которые должны получить событие данного типа
+func (r *WebhookRepository) FindByEventType(ctx context.Context, eventType models.EventType) ([]models.WebhookSubscription, error) {
+	query := `
+		SELECT id, url, event_types, secret, created_by, created_at
+		FROM webhook_subscription
+		WHERE $1 = ANY(event_types)
+	`
+	return r.querySubscriptions(ctx, query, string(eventType))
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, "DELETE FROM webhook_subscription WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// EnqueueDeliveries ставит событие в очередь доставки для каждой подписки.
+// Повторная постановка того же события игнорируется, т.к. outbox доставляет at-least-once
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, event models.Event, payload []byte, subs []models.WebhookSubscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO webhook_delivery (id, subscription_id, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+
+	batch := &pgx.Batch{}
+	for _, sub := range subs {
+		batch.Queue(query, uuid.New(), sub.ID, event.ID, event.Type, payload)
+	}
+
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// RecordDelivery сохраняет уже выполненную доставку (используется для тестовой отправки)
+func (r *WebhookRepository) RecordDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_delivery (id, subscription_id, event_id, event_type, payload,
+		                              status, attempts, response_status, last_error, created_at, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	if _, err := r.db.Exec(ctx, query,
+		d.ID, d.SubscriptionID, d.EventID, d.EventType, d.Payload,
+		d.Status, d.Attempts, d.ResponseStatus, d.LastError, d.CreatedAt, d.DeliveredAt,
+	); err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimDeliveries забирает пачку доставок, готовых к отправке, аналогично OutboxRepository.Claim
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookTask, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE webhook_delivery
+			SET next_attempt_at = now() + make_interval(secs => $2)
+			WHERE id IN (
+				SELECT id FROM webhook_delivery
+				WHERE status = $3 AND next_attempt_at <= now()
+				ORDER BY created_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, created_at
+		)
+		SELECT c.id, c.subscription_id, c.event_id, c.event_type, c.payload, c.status, c.attempts, c.created_at,
+		       s.id, s.url, s.event_types, s.secret, s.created_by, s.created_at
+		FROM claimed c
+		JOIN webhook_subscription s ON s.id = c.subscription_id
+	`
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds(), models.DeliveryPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []WebhookTask
+	for rows.Next() {
+		var task WebhookTask
+		var eventTypes []string
+		if err := rows.Scan(
+			&task.Delivery.ID,
+			&task.Delivery.SubscriptionID,
+			&task.Delivery.EventID,
+			&task.Delivery.EventType,
+			&task.Delivery.Payload,
+			&task.Delivery.Status,
+			&task.Delivery.Attempts,
+			&task.Delivery.CreatedAt,
+			&task.Subscription.ID,
+			&task.Subscription.URL,
+			&eventTypes,
+			&task.Subscription.Secret,
+			&task.Subscription.CreatedBy,
+			&task.Subscription.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		task.Subscription.EventTypes = stringsToEventTypes(eventTypes)
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook deliveries: %w", err)
+	}
+
+	return tasks, nil
+}
+
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, id uuid.UUID, responseStatus int) error {
+	query := `
+		UPDATE webhook_delivery
+		SET status = $2, attempts = attempts + 1, response_status = $3, last_error = NULL, delivered_at = now()
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id, models.DeliveryDelivered, responseStatus); err != nil {
+		return fmt.Errorf("failed to mark webhook delivered: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) MarkFailed(ctx context.Context, id uuid.UUID, responseStatus *int, lastErr string, retryAfter time.Duration) error {
+	query := `
+		UPDATE webhook_delivery
+		SET attempts = attempts + 1,
+		    response_status = $2,
+		    last_error = $3,
+		    next_attempt_at = now() + make_interval(secs => $4)
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id, responseStatus, lastErr, retryAfter.Seconds()); err != nil {
+		return fmt.Errorf("failed to mark webhook failed: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) MarkDead(ctx context.Context, id uuid.UUID, responseStatus *int, lastErr string) error {
+	query := `
+		UPDATE webhook_delivery
+		SET status = $4, attempts = attempts + 1, response_status = $2, last_error = $3
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id, responseStatus, lastErr, models.DeliveryDead); err != nil {
+		return fmt.Errorf("failed to mark webhook dead: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries возвращает журнал доставок подписки, новые первыми
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	query := `
+		SELECT id, subscription_id, event_id, event_type, status, attempts,
+		       response_status, last_error, created_at, delivered_at
+		FROM webhook_delivery
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(
+			&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+			&d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) querySubscriptions(ctx context.Context, query string, args ...any) ([]models.WebhookSubscription, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := make([]models.WebhookSubscription, 0)
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subs = append(subs, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+func scanSubscription(row pgx.Row) (*models.WebhookSubscription, error) {
+	sub := &models.WebhookSubscription{}
+	var eventTypes []string
+	if err := row.Scan(
+		&sub.ID,
+		&sub.URL,
+		&eventTypes,
+		&sub.Secret,
+		&sub.CreatedBy,
+		&sub.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	sub.EventTypes = stringsToEventTypes(eventTypes)
+	return sub, nil
+}
+
+func eventTypesToStrings(types []models.EventType) []string {
+	result := make([]string, len(types))
+	for i, t := range types {
+		result[i] = string(t)
+	}
+	return result
+}
+
+func stringsToEventTypes(types []string) []models.EventType {
+	result := make([]models.EventType, len(types))
+	for i, t := range types {
+		result[i] = models.EventType(t)
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/middleware"
+	"github.com/kosttiik/pvz-service/internal/utils"
+)
+
+// Middleware - обертка хендлера, совместима с AuthMiddleware и RoleMiddleware
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// Router регистрирует маршруты с методами ("POST /receptions") на собственном ServeMux.
+// На путь с неподходящим методом отвечает 405 с заголовком Allow в формате problem details
+type Router struct {
+	mux *http.ServeMux
+	// methods общий для всех групп: путь -> зарегистрированные методы
+	methods     map[string][]string
+	middlewares []Middleware
+}
+
+func NewRouter() *Router {
+	return &Router{
+		mux:     http.NewServeMux(),
+		methods: make(map[string][]string),
+	}
+}
+
+// Group возвращает роутер на том же mux, к маршрутам которого дополнительно применяются mws.
+// Middleware выполняются в порядке добавления: сначала внешних групп, потом вложенных. nil пропускается
+func (rt *Router) Group(mws ...Middleware) *Router {
+	middlewares := slices.Clone(rt.middlewares)
+	for _, mw := range mws {
+		if mw != nil {
+			middlewares = append(middlewares, mw)
+		}
+	}
+
+	return &Router{
+		mux:         rt.mux,
+		methods:     rt.methods,
+		middlewares: middlewares,
+	}
+}
+
+func (rt *Router) Get(path string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodGet, path, handler)
+}
+
+func (rt *Router) Post(path string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodPost, path, handler)
+}
+
+func (rt *Router) Delete(path string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodDelete, path, handler)
+}
+
+// Handle регистрирует хендлер на метод и путь. Метрики вешаются на каждый маршрут,
+// чтобы в r.Pattern уже был шаблон пути
+func (rt *Router) Handle(method, path string, handler http.HandlerFunc) {
+	for _, mw := range slices.Backward(rt.middlewares) {
+		handler = mw(handler)
+	}
+	rt.mux.Handle(method+" "+path, middleware.MetricsMiddleware(handler))
+
+	// Шаблон без метода менее специфичен, поэтому ловит только остальные методы
+	if _, ok := rt.methods[path]; !ok {
+		rt.mux.Handle(path, middleware.MetricsMiddleware(rt.methodNotAllowed(path)))
+	}
+	rt.methods[path] = append(rt.methods[path], method)
+}
+
+func (rt *Router) methodNotAllowed(path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", rt.allow(path))
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		utils.WriteProblem(w, r, dto.CodeMethodNotAllowed, "Method "+r.Method+" is not allowed for "+path)
+	}
+}
+
+// allow собирает значение заголовка Allow. GET в ServeMux обслуживает и HEAD
+func (rt *Router) allow(path string) string {
+	methods := slices.Clone(rt.methods[path])
+	if slices.Contains(methods, http.MethodGet) {
+		methods = append(methods, http.MethodHead)
+	}
+	methods = append(methods, http.MethodOptions)
+	slices.Sort(methods)
+	return strings.Join(slices.Compact(methods), ", ")
+}
+
+// Handler находит маршрут запроса, нужен для имени спана в middleware.Tracing
+func (rt *Router) Handler(r *http.Request) (http.Handler, string) {
+	return rt.mux.Handler(r)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r)
+}
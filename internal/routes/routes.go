@@ -1,45 +1,70 @@
 package routes
 
 import (
-	"net/http"
-
 	"github.com/kosttiik/pvz-service/internal/handlers"
 	"github.com/kosttiik/pvz-service/internal/middleware"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func SetupRoutes() {
-	http.HandleFunc("/ping", handlers.PingHandler)
-
-	http.HandleFunc("/dummyLogin", handlers.DummyLoginHandler)
-	http.HandleFunc("/register", handlers.RegisterHandler)
-	http.HandleFunc("/login", handlers.LoginHandler)
-	http.HandleFunc("/logout", middleware.AuthMiddleware(handlers.LogoutHandler))
-
-	http.HandleFunc("/pvz", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			middleware.AuthMiddleware(handlers.GetPVZListHandler)(w, r)
-		case http.MethodPost:
-			middleware.AuthMiddleware(
-				middleware.RoleMiddleware("moderator")(handlers.CreatePVZHandler),
-			)(w, r)
-		}
-	})
-
-	http.HandleFunc("/receptions", middleware.AuthMiddleware(
-		middleware.RoleMiddleware("employee")(handlers.CreateReceptionHandler)),
-	)
-	http.HandleFunc("/pvz/{pvzId}/close_last_reception", middleware.AuthMiddleware(
-		middleware.RoleMiddleware("employee")(handlers.CloseReceptionHandler)),
-	)
-
-	http.HandleFunc("/products", middleware.AuthMiddleware(
-		middleware.RoleMiddleware("employee")(handlers.AddProductHandler)),
-	)
-	http.HandleFunc("/pvz/{pvzId}/delete_last_product", middleware.AuthMiddleware(
-		middleware.RoleMiddleware("employee")(handlers.DeleteLastProductHandler)),
-	)
-
-	http.Handle("/metrics", promhttp.Handler())
+// Middlewares - middleware, которые зависят от экземпляра приложения
+type Middlewares struct {
+	// Auth - AuthMiddleware, настроенный на JWT и хранилище токенов приложения
+	Auth Middleware
+	// LoginLimit и RegisterLimit ограничивают частоту входов и регистраций, nil - без лимита
+	LoginLimit    Middleware
+	RegisterLimit Middleware
+	// PasswordResetLimit ограничивает письма сброса пароля, nil - без лимита
+	PasswordResetLimit Middleware
+	// Quota - квота пишущих запросов пользователя по роли, nil - без квоты
+	Quota Middleware
+	// Idempotency - повтор ответов на запросы с Idempotency-Key, nil - без него
+	Idempotency Middleware
+}
+
+// New собирает маршруты приложения
+func New(h *handlers.Handler, mw Middlewares) *Router {
+	r := NewRouter()
+
+	r.Get("/ping", h.PingHandler)
+	r.Get("/healthz", h.HealthzHandler)
+	r.Get("/readyz", h.ReadyzHandler)
+	r.Get("/metrics", promhttp.Handler().ServeHTTP)
+
+	r.Post("/dummyLogin", h.DummyLoginHandler)
+	r.Group(mw.RegisterLimit).Post("/register", h.RegisterHandler)
+	r.Group(mw.LoginLimit).Post("/login", h.LoginHandler)
+	r.Post("/email/verify", h.VerifyEmailHandler)
+	r.Group(mw.PasswordResetLimit).Post("/password/forgot", h.ForgotPasswordHandler)
+	r.Post("/password/reset", h.ResetPasswordHandler)
+
+	// Повтор отдается раньше проверки квоты, чтобы не тратить ее
+	authorized := r.Group(mw.Auth, mw.Idempotency)
+	authorized.Post("/logout", h.LogoutHandler)
+
+	staff := authorized.Group(middleware.RoleMiddleware("employee", "moderator"))
+	staff.Get("/pvz", h.GetPVZListHandler)
+	staff.Get("/pvz/{pvzId}/events", h.PVZEventsHandler)
+	staff.Get("/dashboard/ws", h.DashboardHandler)
+
+	// Все маршруты кладовщика пишущие
+	employee := authorized.Group(middleware.RoleMiddleware("employee"), mw.Quota)
+	employee.Post("/receptions", h.CreateReceptionHandler)
+	employee.Post("/pvz/{pvzId}/close_last_reception", h.CloseReceptionHandler)
+	employee.Post("/products", h.AddProductHandler)
+	employee.Post("/pvz/{pvzId}/delete_last_product", h.DeleteLastProductHandler)
+
+	moderator := authorized.Group(middleware.RoleMiddleware("moderator"))
+	moderator.Get("/analytics/throughput", h.ThroughputHandler)
+	moderator.Get("/export/receptions", h.ExportReceptionsHandler)
+	moderator.Get("/webhooks", h.ListWebhooksHandler)
+	moderator.Get("/webhooks/{webhookId}/deliveries", h.ListWebhookDeliveriesHandler)
+
+	moderatorWrites := moderator.Group(mw.Quota)
+	moderatorWrites.Post("/pvz", h.CreatePVZHandler)
+	moderatorWrites.Post("/pvz/import", h.ImportPVZHandler)
+	moderatorWrites.Post("/webhooks", h.CreateWebhookHandler)
+	moderatorWrites.Delete("/webhooks/{webhookId}", h.DeleteWebhookHandler)
+	moderatorWrites.Post("/webhooks/{webhookId}/test", h.TestWebhookHandler)
+
+	return r
 }
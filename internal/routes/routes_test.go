@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kosttiik/pvz-service/internal/handlers"
+	"github.com/kosttiik/pvz-service/internal/middleware"
+	"github.com/kosttiik/pvz-service/internal/repository/memory"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"go.uber.org/zap"
+)
+
+func newInstance(secret string) http.Handler {
+	tokens := memory.NewStore().Tokens()
+	jwt := utils.NewJWT(secret, 0)
+
+	h := handlers.New(handlers.Deps{Tokens: tokens, JWT: jwt})
+	return middleware.RequestID(zap.NewNop())(New(h, Middlewares{Auth: middleware.AuthMiddleware(jwt, tokens)}))
+}
+
+func dummyLogin(t *testing.T, instance http.Handler) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/dummyLogin", strings.NewReader(`{"role":"employee"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	instance.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("dummyLogin status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode token: %v", err)
+	}
+	return resp.Token
+}
+
+func logout(instance http.Handler, token string) int {
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	instance.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestInstancesAreIsolated(t *testing.T) {
+	first := newInstance("first_secret")
+	second := newInstance("second_secret")
+
+	token := dummyLogin(t, first)
+
+	if status := logout(second, token); status != http.StatusUnauthorized {
+		t.Errorf("second instance accepted token of the first one, status = %d", status)
+	}
+	if status := logout(first, token); status != http.StatusNoContent {
+		t.Errorf("first instance rejected its own token, status = %d", status)
+	}
+	if status := logout(first, token); status != http.StatusUnauthorized {
+		t.Errorf("token accepted after logout, status = %d", status)
+	}
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	instance := newInstance("secret")
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+	}{
+		{method: http.MethodGet, path: "/receptions", wantStatus: http.StatusMethodNotAllowed, wantAllow: "OPTIONS, POST"},
+		{method: http.MethodPut, path: "/pvz", wantStatus: http.StatusMethodNotAllowed, wantAllow: "GET, HEAD, OPTIONS, POST"},
+		{method: http.MethodGet, path: "/pvz/8c5f4f1e-0a4b-4c8e-9f5a-2a1b3c4d5e6f/close_last_reception", wantStatus: http.StatusMethodNotAllowed, wantAllow: "OPTIONS, POST"},
+		{method: http.MethodOptions, path: "/webhooks", wantStatus: http.StatusNoContent, wantAllow: "GET, HEAD, OPTIONS, POST"},
+		{method: http.MethodGet, path: "/unknown", wantStatus: http.StatusNotFound},
+		// Поток событий закрыт теми же middleware, что и остальные маршруты сотрудников
+		{method: http.MethodGet, path: "/pvz/8c5f4f1e-0a4b-4c8e-9f5a-2a1b3c4d5e6f/events", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			instance.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if tt.wantStatus == http.StatusMethodNotAllowed && w.Header().Get("Content-Type") != utils.ContentTypeProblem {
+				t.Errorf("expected problem details, got Content-Type %q", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestRouterGroups(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next(w, r)
+			}
+		}
+	}
+
+	r := NewRouter()
+	outer := r.Group(trace("outer"))
+	inner := outer.Group(trace("inner"))
+	outer.Get("/outer", func(w http.ResponseWriter, r *http.Request) {})
+	inner.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler:"+r.PathValue("id"))
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/42", nil))
+	if got, want := strings.Join(calls, ","), "outer,inner,handler:42"; got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+
+	calls = nil
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/outer", nil))
+	if got, want := strings.Join(calls, ","), "outer"; got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
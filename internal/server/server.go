@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	Addr              string        `yaml:"addr"`
+	ReadTimeout       time.Duration `yaml:"readTimeout"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	// WriteTimeout не действует на SSE, WebSocket и экспорт - они снимают дедлайн сами
+	WriteTimeout    time.Duration `yaml:"writeTimeout"`
+	IdleTimeout     time.Duration `yaml:"idleTimeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Addr:              ":8080",
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		ShutdownTimeout:   30 * time.Second,
+	}
+}
+
+// Server - http.Server с таймаутами и плавной остановкой
+type Server struct {
+	http        *http.Server
+	config      Config
+	stopStreams context.CancelFunc
+}
+
+func New(handler http.Handler, config Config) *Server {
+	streams, stopStreams := context.WithCancel(context.Background())
+
+	srv := &http.Server{
+		Addr:              config.Addr,
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return utils.SetShutdownContext(context.Background(), streams)
+		},
+	}
+	// Shutdown не ждет hijacked соединений и не отменяет контексты запросов,
+	// поэтому долгоживущие потоки закрываем сами
+	srv.RegisterOnShutdown(stopStreams)
+
+	return &Server{
+		http:        srv,
+		config:      config,
+		stopStreams: stopStreams,
+	}
+}
+
+// Run принимает соединения до отмены ctx, после чего перестает принимать новые
+// и ждет завершения текущих запросов не дольше ShutdownTimeout
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		s.stopStreams()
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve работает как Run, но на уже открытом listener
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	log := logger.FromContext(ctx)
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info("Starting server", zap.String("address", ln.Addr().String()))
+		if err := s.http.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		s.stopStreams()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info("Shutting down server", zap.Duration("timeout", s.config.ShutdownTimeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	if err := s.http.Shutdown(shutdownCtx); err != nil {
+		log.Warn("Graceful shutdown timed out, closing remaining connections", zap.Error(err))
+		s.http.Close()
+		return err
+	}
+
+	log.Info("Server stopped")
+	return nil
+}
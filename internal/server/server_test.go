@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init(); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	logger.Close()
+	os.Exit(code)
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	slowStarted := make(chan struct{})
+	streamStarted := make(chan struct{})
+	streamDone := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(slowStarted)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := utils.StreamContext(r)
+		defer cancel()
+		defer close(streamDone)
+
+		w.WriteHeader(http.StatusOK)
+		http.NewResponseController(w).Flush()
+		close(streamStarted)
+		<-ctx.Done()
+	})
+
+	config := DefaultConfig()
+	config.ShutdownTimeout = 5 * time.Second
+	srv := New(mux, config)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	baseURL := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Serve(ctx, ln) }()
+
+	streamResp, err := http.Get(baseURL + "/stream")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer streamResp.Body.Close()
+	<-streamStarted
+
+	type result struct {
+		body string
+		err  error
+	}
+	slowResult := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(baseURL + "/slow")
+		if err != nil {
+			slowResult <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		slowResult <- result{body: string(body), err: err}
+	}()
+	<-slowStarted
+
+	start := time.Now()
+	cancel()
+
+	select {
+	case <-streamDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream was not cancelled on shutdown")
+	}
+
+	res := <-slowResult
+	if res.err != nil || res.body != "done" {
+		t.Errorf("expected in-flight request to finish, got %q, %v", res.body, res.err)
+	}
+
+	if err := <-runErr; err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("shutdown took too long: %v", elapsed)
+	}
+
+	if _, err := http.Get(baseURL + "/slow"); err == nil {
+		t.Error("expected server to stop accepting connections")
+	}
+}
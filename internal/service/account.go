@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"github.com/kosttiik/pvz-service/pkg/mailer"
+	"go.uber.org/zap"
+)
+
+var errMailDisabled = errors.New("mail is not configured")
+
+// MailConfig - письма подтверждения почты и сброса пароля
+type MailConfig struct {
+	// VerifyURL и ResetURL - страницы, на которые ведут ссылки из писем, токен
+	// добавляется параметром token. Пустой URL - в письмо попадает только токен
+	VerifyURL string
+	ResetURL  string
+	// VerificationTTL и ResetTTL - сколько действуют токены
+	VerificationTTL time.Duration
+	ResetTTL        time.Duration
+}
+
+// WithMailer включает подтверждение почты и сброс пароля. Без почты подтверждать
+// нечем, и зарегистрированные пользователи сразу считаются подтвержденными
+func (s *AuthService) WithMailer(m Mailer, config MailConfig) *AuthService {
+	s.mailer = m
+	s.mail = config
+	return s
+}
+
+// VerifyEmail подтверждает почту по токену из письма
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	user, err := s.users.VerifyEmail(ctx, utils.HashOneTimeToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrTokenNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+	return user, nil
+}
+
+// ForgotPassword отправляет письмо со ссылкой на сброс пароля. Для неизвестного
+// адреса письмо не отправляется, но и ошибки нет: ответ не выдает, зарегистрирован ли адрес.
+// По той же причине ошибка отправки только логируется, а сама отправка в приложении
+// идет через mailer.Queue и не задерживает ответ
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	if s.mailer == nil {
+		return errMailDisabled
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			logger.FromContext(ctx).Info("Password reset requested for unknown email")
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	token, err := s.newToken(ctx, user.ID, models.PurposePasswordReset, s.mail.ResetTTL)
+	if err != nil {
+		return err
+	}
+
+	err = s.send(ctx, user.Email, "Сброс пароля", fmt.Sprintf(
+		"Здравствуйте!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s и сработает один раз. Если вы не запрашивали сброс, просто удалите это письмо.",
+		link(s.mail.ResetURL, token), formatTTL(s.mail.ResetTTL)))
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to send password reset email",
+			zap.String("userID", user.ID.String()),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// ResetPassword меняет пароль по токену из письма. Текущая сессия пользователя
+// отзывается, блокировка входа снимается
+func (s *AuthService) ResetPassword(ctx context.Context, token, password string) (*models.User, error) {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user, err := s.users.ResetPassword(ctx, utils.HashOneTimeToken(token), hashedPassword)
+	if err != nil {
+		if errors.Is(err, repository.ErrTokenNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	if err := s.tokens.Invalidate(ctx, user.ID.String()); err != nil {
+		return nil, fmt.Errorf("failed to invalidate token: %w", err)
+	}
+	if s.guard != nil {
+		if err := s.guard.ResetAll(ctx, user.Email); err != nil {
+			logger.FromContext(ctx).Warn("Failed to reset login failures", zap.Error(err))
+		}
+	}
+
+	return user, nil
+}
+
+// sendVerification отправляет письмо подтверждения почты новому пользователю
+func (s *AuthService) sendVerification(ctx context.Context, user *models.User) error {
+	token, err := s.newToken(ctx, user.ID, models.PurposeEmailVerification, s.mail.VerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, user.Email, "Подтверждение почты", fmt.Sprintf(
+		"Здравствуйте!\n\nЧтобы подтвердить адрес и войти в сервис, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s. Если вы не регистрировались, просто удалите это письмо.",
+		link(s.mail.VerifyURL, token), formatTTL(s.mail.VerificationTTL)))
+}
+
+func (s *AuthService) newToken(ctx context.Context, userID uuid.UUID, purpose models.TokenPurpose, ttl time.Duration) (string, error) {
+	token, hash, err := utils.NewOneTimeToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.users.CreateToken(ctx, &models.UserToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Purpose:   purpose,
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *AuthService) send(ctx context.Context, to, subject, body string) error {
+	if err := s.mailer.Send(ctx, mailer.Message{To: to, Subject: subject, Body: body}); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// link добавляет токен к URL страницы. Без URL клиенту отдается сам токен
+func link(base, token string) string {
+	u, err := url.Parse(base)
+	if base == "" || err != nil {
+		return token
+	}
+
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d ч", int(ttl.Hours()))
+	}
+	return fmt.Sprintf("%d мин", int(ttl.Minutes()))
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// AuthService выпускает токены и ведет сессии. У пользователя одна активная сессия:
+// новый вход отзывает предыдущий токен
+type AuthService struct {
+	users  UserRepository
+	tokens TokenStore
+	jwt    *utils.JWT
+	guard  LoginGuard
+	mailer Mailer
+	mail   MailConfig
+}
+
+func NewAuthService(users UserRepository, tokens TokenStore, jwt *utils.JWT) *AuthService {
+	return &AuthService{users: users, tokens: tokens, jwt: jwt}
+}
+
+// WithLoginGuard включает блокировку входа после серии неудачных попыток с одного адреса
+func (s *AuthService) WithLoginGuard(guard LoginGuard) *AuthService {
+	s.guard = guard
+	return s
+}
+
+// DummyLogin выдает токен случайному пользователю с указанной ролью
+func (s *AuthService) DummyLogin(ctx context.Context, role string) (string, error) {
+	return s.issue(ctx, uuid.New().String(), role)
+}
+
+// Register создает пользователя с хешированным паролем и отправляет письмо для
+// подтверждения почты. Если письмо не ушло, пользователь все равно создается:
+// подтвердить почту можно и через сброс пароля
+func (s *AuthService) Register(ctx context.Context, email, password, role string) (*models.User, error) {
+	exists, err := s.users.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+	if exists {
+		return nil, ErrEmailTaken
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &models.User{
+		ID:       uuid.New(),
+		Email:    email,
+		Password: hashedPassword,
+		Role:     role,
+	}
+
+	if s.mailer == nil {
+		now := time.Now().UTC()
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if s.mailer != nil {
+		if err := s.sendVerification(ctx, user); err != nil {
+			logger.FromContext(ctx).Warn("Failed to send verification email",
+				zap.String("userID", user.ID.String()),
+				zap.Error(err))
+		}
+	}
+	return user, nil
+}
+
+// Login проверяет пароль и выдает новый токен, отзывая предыдущий.
+// Блокировка действует на пару email и clientIP: заблокированная пара отвечает
+// *LockedError, не доходя до проверки пароля. С неподтвержденной почтой вход
+// запрещен, но только после верного пароля, чтобы статус почты не был виден без пароля
+func (s *AuthService) Login(ctx context.Context, email, password, clientIP string) (*models.User, string, error) {
+	if err := s.checkLocked(ctx, email, clientIP); err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			s.recordFailure(ctx, email, clientIP)
+			return nil, "", ErrUnknownUser
+		}
+		return nil, "", fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := utils.CheckPassword(password, user.Password); err != nil {
+		s.recordFailure(ctx, email, clientIP)
+		return nil, "", ErrWrongPassword
+	}
+
+	if user.EmailVerifiedAt == nil {
+		return nil, "", ErrEmailNotVerified
+	}
+
+	if s.guard != nil {
+		if err := s.guard.Reset(ctx, email, clientIP); err != nil {
+			logger.FromContext(ctx).Warn("Failed to reset login failures", zap.Error(err))
+		}
+	}
+
+	if err := s.tokens.Invalidate(ctx, user.ID.String()); err != nil {
+		return nil, "", fmt.Errorf("failed to invalidate token: %w", err)
+	}
+
+	token, err := s.issue(ctx, user.ID.String(), user.Role)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
+
+// Logout отзывает токен пользователя
+func (s *AuthService) Logout(ctx context.Context, actor *models.Claims) error {
+	if actor == nil {
+		return ErrUnauthorized
+	}
+	if err := s.tokens.Invalidate(ctx, actor.UserID); err != nil {
+		return fmt.Errorf("failed to invalidate token: %w", err)
+	}
+	return nil
+}
+
+// checkLocked пропускает вход, если хранилище блокировок недоступно: лимиты по IP и email остаются
+func (s *AuthService) checkLocked(ctx context.Context, email, clientIP string) error {
+	if s.guard == nil {
+		return nil
+	}
+
+	locked, err := s.guard.Locked(ctx, email, clientIP)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to check account lockout", zap.Error(err))
+		return nil
+	}
+	if locked > 0 {
+		return &LockedError{RetryAfter: locked}
+	}
+	return nil
+}
+
+// recordFailure учитывает неудачный вход. Неизвестный email считается так же,
+// чтобы по блокировке нельзя было понять, зарегистрирован ли адрес
+func (s *AuthService) recordFailure(ctx context.Context, email, clientIP string) {
+	if s.guard == nil {
+		return
+	}
+
+	delay, err := s.guard.Fail(ctx, email, clientIP)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to record login failure", zap.Error(err))
+		return
+	}
+	if delay > 0 {
+		logger.FromContext(ctx).Warn("Login locked after failed attempts",
+			zap.String("ip", clientIP),
+			zap.Duration("lockout", delay))
+	}
+}
+
+func (s *AuthService) issue(ctx context.Context, userID, role string) (string, error) {
+	token, err := s.jwt.Generate(userID, role)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	if err := s.tokens.Set(ctx, userID, token); err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+	return token, nil
+}
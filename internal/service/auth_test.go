@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/repository/memory"
+	"github.com/kosttiik/pvz-service/internal/utils"
+	"github.com/kosttiik/pvz-service/pkg/mailer"
+)
+
+func TestAuthServiceLogin(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	jwt := utils.NewJWT("test_secret", 0)
+	svc := NewAuthService(store.Users(), store.Tokens(), jwt)
+
+	user, err := svc.Register(ctx, "user@example.com", "password123", "employee")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if user.Password == "password123" {
+		t.Error("expected password to be hashed")
+	}
+
+	if _, err := svc.Register(ctx, "user@example.com", "password123", "employee"); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("Register() duplicate error = %v, want %v", err, ErrEmailTaken)
+	}
+
+	if _, _, err := svc.Login(ctx, "user@example.com", "wrong", testIP); !errors.Is(err, ErrWrongPassword) || !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login() wrong password error = %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "nobody@example.com", "password123", testIP); !errors.Is(err, ErrUnknownUser) || !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login() unknown user error = %v", err)
+	}
+
+	_, first, err := svc.Login(ctx, "user@example.com", "password123", testIP)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	claims, err := jwt.Parse(first)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if claims.UserID != user.ID.String() || string(claims.Role) != "employee" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	// Повторный вход заменяет токен в хранилище
+	_, second, err := svc.Login(ctx, "user@example.com", "password123", testIP)
+	if err != nil {
+		t.Fatalf("second Login() error = %v", err)
+	}
+	stored, err := store.Tokens().Get(ctx, user.ID.String())
+	if err != nil || stored != second {
+		t.Errorf("stored token = %q, %v, want latest token", stored, err)
+	}
+
+	if err := svc.Logout(ctx, claims); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if _, err := store.Tokens().Get(ctx, user.ID.String()); err == nil {
+		t.Error("expected token to be removed after logout")
+	}
+}
+
+const testIP = "192.0.2.1"
+
+// fakeGuard блокирует вход на минуту после threshold неудачных попыток с одного адреса
+type fakeGuard struct {
+	threshold int
+	failures  map[string]int
+}
+
+func (g *fakeGuard) Locked(_ context.Context, email, ip string) (time.Duration, error) {
+	if g.failures[email+"|"+ip] >= g.threshold {
+		return time.Minute, nil
+	}
+	return 0, nil
+}
+
+func (g *fakeGuard) Fail(_ context.Context, email, ip string) (time.Duration, error) {
+	g.failures[email+"|"+ip]++
+	return g.Locked(context.Background(), email, ip)
+}
+
+func (g *fakeGuard) Reset(_ context.Context, email, ip string) error {
+	delete(g.failures, email+"|"+ip)
+	return nil
+}
+
+func (g *fakeGuard) ResetAll(_ context.Context, email string) error {
+	for key := range g.failures {
+		if strings.HasPrefix(key, email+"|") {
+			delete(g.failures, key)
+		}
+	}
+	return nil
+}
+
+func TestAuthServiceLockout(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	guard := &fakeGuard{threshold: 2, failures: make(map[string]int)}
+	svc := NewAuthService(store.Users(), store.Tokens(), utils.NewJWT("test_secret", 0)).WithLoginGuard(guard)
+
+	if _, err := svc.Register(ctx, "user@example.com", "password123", "employee"); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// Успешный вход сбрасывает счетчик
+	svc.Login(ctx, "user@example.com", "wrong", testIP)
+	if _, _, err := svc.Login(ctx, "user@example.com", "password123", testIP); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if len(guard.failures) != 0 {
+		t.Errorf("failures = %v after successful login, want none", guard.failures)
+	}
+
+	svc.Login(ctx, "user@example.com", "wrong", testIP)
+	svc.Login(ctx, "user@example.com", "wrong", testIP)
+
+	// Даже верный пароль не проходит, пока аккаунт заблокирован
+	_, _, err := svc.Login(ctx, "user@example.com", "password123", testIP)
+	var locked *LockedError
+	if !errors.As(err, &locked) || !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("Login() error = %v, want LockedError", err)
+	}
+	if locked.RetryAfter != time.Minute {
+		t.Errorf("RetryAfter = %s, want 1m", locked.RetryAfter)
+	}
+
+	// Блокировка касается только адреса, с которого подбирали пароль,
+	// и вход с другого адреса ее не снимает
+	if _, _, err := svc.Login(ctx, "user@example.com", "password123", "198.51.100.7"); err != nil {
+		t.Errorf("Login() from another address error = %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "user@example.com", "password123", testIP); !errors.Is(err, ErrAccountLocked) {
+		t.Errorf("Login() after success from another address error = %v, want %v", err, ErrAccountLocked)
+	}
+
+	// Неизвестный email блокируется так же, как существующий
+	svc.Login(ctx, "nobody@example.com", "x", testIP)
+	svc.Login(ctx, "nobody@example.com", "x", testIP)
+	if _, _, err := svc.Login(ctx, "nobody@example.com", "x", testIP); !errors.Is(err, ErrAccountLocked) {
+		t.Errorf("Login() unknown email error = %v, want %v", err, ErrAccountLocked)
+	}
+}
+
+// fakeMailer запоминает отправленные письма, с err отвечает ошибкой
+type fakeMailer struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return m.err
+}
+
+// lastToken достает токен из ссылки в последнем письме
+func (m *fakeMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	if len(m.sent) == 0 {
+		t.Fatal("no mail sent")
+	}
+
+	for _, field := range strings.Fields(m.sent[len(m.sent)-1].Body) {
+		if u, err := url.Parse(field); err == nil && u.Query().Get("token") != "" {
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatal("no token link in mail")
+	return ""
+}
+
+var testMailConfig = MailConfig{
+	VerifyURL:       "https://pvz.example/verify",
+	ResetURL:        "https://pvz.example/reset",
+	VerificationTTL: 24 * time.Hour,
+	ResetTTL:        time.Hour,
+}
+
+func TestAuthServiceEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	mail := &fakeMailer{}
+	svc := NewAuthService(store.Users(), store.Tokens(), utils.NewJWT("test_secret", 0)).WithMailer(mail, testMailConfig)
+
+	if _, err := svc.Register(ctx, "user@example.com", "password123", "employee"); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if len(mail.sent) != 1 || mail.sent[0].To != "user@example.com" {
+		t.Fatalf("sent = %+v, want one verification mail", mail.sent)
+	}
+
+	// Неверный пароль проверяется раньше подтверждения почты
+	if _, _, err := svc.Login(ctx, "user@example.com", "wrong", testIP); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("Login() wrong password error = %v, want %v", err, ErrWrongPassword)
+	}
+	if _, _, err := svc.Login(ctx, "user@example.com", "password123", testIP); !errors.Is(err, ErrEmailNotVerified) {
+		t.Errorf("Login() unverified error = %v, want %v", err, ErrEmailNotVerified)
+	}
+
+	if _, err := svc.VerifyEmail(ctx, "forged"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyEmail() forged token error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	token := mail.lastToken(t)
+	user, err := svc.VerifyEmail(ctx, token)
+	if err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+	if user.EmailVerifiedAt == nil {
+		t.Error("expected email to be verified")
+	}
+	if _, err := svc.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyEmail() reused token error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	if _, _, err := svc.Login(ctx, "user@example.com", "password123", testIP); err != nil {
+		t.Errorf("Login() after verification error = %v", err)
+	}
+}
+
+func TestAuthServicePasswordReset(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	mail := &fakeMailer{}
+	guard := &fakeGuard{threshold: 1, failures: make(map[string]int)}
+	svc := NewAuthService(store.Users(), store.Tokens(), utils.NewJWT("test_secret", 0)).
+		WithLoginGuard(guard).
+		WithMailer(mail, testMailConfig)
+
+	user, err := svc.Register(ctx, "user@example.com", "password123", "employee")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	store.Tokens().Set(ctx, user.ID.String(), "old-session")
+
+	// Для неизвестного адреса ответ тот же, но письма нет
+	if err := svc.ForgotPassword(ctx, "nobody@example.com"); err != nil {
+		t.Errorf("ForgotPassword() unknown email error = %v", err)
+	}
+	if len(mail.sent) != 1 {
+		t.Fatalf("sent %d mails, want only the verification mail", len(mail.sent))
+	}
+
+	// Действует только последняя ссылка
+	if err := svc.ForgotPassword(ctx, "user@example.com"); err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+	stale := mail.lastToken(t)
+	if err := svc.ForgotPassword(ctx, "user@example.com"); err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+	token := mail.lastToken(t)
+	if _, err := svc.ResetPassword(ctx, stale, "newpassword123"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ResetPassword() stale token error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	svc.Login(ctx, "user@example.com", "wrong", testIP)
+	if _, err := svc.ResetPassword(ctx, token, "newpassword123"); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if _, err := svc.ResetPassword(ctx, token, "otherpassword123"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ResetPassword() reused token error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	// Сброс отзывает сессию и снимает блокировку, а письмо подтверждает почту
+	if _, err := store.Tokens().Get(ctx, user.ID.String()); err == nil {
+		t.Error("expected session to be revoked after reset")
+	}
+	if len(guard.failures) != 0 {
+		t.Errorf("failures = %v after password reset, want none", guard.failures)
+	}
+	if _, _, err := svc.Login(ctx, "user@example.com", "password123", testIP); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("Login() old password error = %v, want %v", err, ErrWrongPassword)
+	}
+	guard.Reset(ctx, "user@example.com", testIP)
+	if _, _, err := svc.Login(ctx, "user@example.com", "newpassword123", testIP); err != nil {
+		t.Errorf("Login() new password error = %v", err)
+	}
+}
+
+func TestAuthServiceForgotPasswordWithoutMailer(t *testing.T) {
+	store := memory.NewStore()
+	svc := NewAuthService(store.Users(), store.Tokens(), utils.NewJWT("test_secret", 0))
+
+	if err := svc.ForgotPassword(context.Background(), "user@example.com"); err == nil {
+		t.Error("expected error when mail is not configured")
+	}
+}
+
+func TestAuthServiceForgotPasswordSendFailure(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	mail := &fakeMailer{}
+	svc := NewAuthService(store.Users(), store.Tokens(), utils.NewJWT("test_secret", 0)).
+		WithMailer(mail, testMailConfig)
+
+	if _, err := svc.Register(ctx, "user@example.com", "password123", "employee"); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// Ошибка отправки не должна отличать зарегистрированный адрес от неизвестного
+	mail.err = errors.New("smtp is down")
+	for _, email := range []string{"user@example.com", "nobody@example.com"} {
+		if err := svc.ForgotPassword(ctx, email); err != nil {
+			t.Errorf("ForgotPassword(%s) error = %v, want nil", email, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+)
+
+type PVZService struct {
+	repo PVZRepository
+}
+
+func NewPVZService(repo PVZRepository) *PVZService {
+	return &PVZService{repo: repo}
+}
+
+// Create заводит ПВЗ. Доступно только модератору
+func (s *PVZService) Create(ctx context.Context, actor *models.Claims, city string) (*models.PVZ, error) {
+	if err := requireRole(actor, models.Moderator); err != nil {
+		return nil, err
+	}
+
+	pvz := &models.PVZ{
+		ID:               uuid.New(),
+		City:             city,
+		RegistrationDate: time.Now().UTC(),
+		Version:          1,
+	}
+
+	if err := s.repo.Create(ctx, pvz); err != nil {
+		return nil, fmt.Errorf("failed to create pvz: %w", err)
+	}
+	return pvz, nil
+}
+
+// List возвращает страницу ПВЗ с приемками и товарами. Доступно сотруднику и модератору
+func (s *PVZService) List(ctx context.Context, actor *models.Claims, filter repository.GetPVZFilter) ([]repository.PVZandReceptions, error) {
+	if err := requireRole(actor, models.Employee, models.Moderator); err != nil {
+		return nil, err
+	}
+
+	list, err := s.repo.GetPVZ(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pvz list: %w", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/repository/memory"
+)
+
+func TestPVZService(t *testing.T) {
+	ctx := context.Background()
+	svc := NewPVZService(memory.NewStore().PVZ())
+
+	if _, err := svc.Create(ctx, employee, "Москва"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Create() by employee error = %v, want %v", err, ErrForbidden)
+	}
+
+	for _, city := range []string{"Москва", "Казань", "Санкт-Петербург"} {
+		if _, err := svc.Create(ctx, moderator, city); err != nil {
+			t.Fatalf("Create(%s) error = %v", city, err)
+		}
+	}
+
+	list, err := svc.List(ctx, employee, repository.GetPVZFilter{Page: 2, Limit: 2})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 1 {
+		t.Errorf("List() page 2 returned %d items, want 1", len(list))
+	}
+
+	if _, err := svc.List(ctx, nil, repository.GetPVZFilter{Page: 1, Limit: 10}); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("List() without user error = %v, want %v", err, ErrUnauthorized)
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+)
+
+// ReceptionService ведет приемки товаров. Все операции доступны только сотруднику ПВЗ
+type ReceptionService struct {
+	receptions ReceptionRepository
+	products   ProductRepository
+}
+
+func NewReceptionService(receptions ReceptionRepository, products ProductRepository) *ReceptionService {
+	return &ReceptionService{receptions: receptions, products: products}
+}
+
+// Open открывает приемку, если версия ПВЗ совпадает с pvzVersion.
+// У ПВЗ может быть только одна открытая приемка
+func (s *ReceptionService) Open(ctx context.Context, actor *models.Claims, pvzID string, pvzVersion int) (*models.Reception, error) {
+	if err := requireRole(actor, models.Employee); err != nil {
+		return nil, err
+	}
+
+	hasOpen, err := s.receptions.HasOpenReception(ctx, pvzID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check open reception: %w", err)
+	}
+	if hasOpen {
+		return nil, ErrReceptionAlreadyOpen
+	}
+
+	reception := &models.Reception{
+		ID:       uuid.New(),
+		DateTime: time.Now().UTC(),
+		PvzID:    pvzID,
+		Status:   models.StatusInProgress,
+		Version:  1,
+	}
+
+	if err := s.receptions.Create(ctx, reception, pvzVersion); err != nil {
+		return nil, versionError(err, "failed to create reception")
+	}
+	return reception, nil
+}
+
+// AddProduct добавляет товар в открытую приемку ПВЗ, если ее версия совпадает с receptionVersion.
+// Возвращает новую версию приемки
+func (s *ReceptionService) AddProduct(ctx context.Context, actor *models.Claims, pvzID, productType string, receptionVersion int) (*models.Product, int, error) {
+	if err := requireRole(actor, models.Employee); err != nil {
+		return nil, 0, err
+	}
+
+	reception, err := s.openReception(ctx, pvzID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	product := &models.Product{
+		ID:          uuid.New(),
+		DateTime:    time.Now().UTC(),
+		Type:        productType,
+		ReceptionID: reception.ID.String(),
+	}
+
+	version, err := s.products.Create(ctx, product, receptionVersion)
+	if err != nil {
+		return nil, 0, versionError(err, "failed to create product")
+	}
+	return product, version, nil
+}
+
+// DeleteLastProduct удаляет последний добавленный товар из открытой приемки (LIFO).
+// Возвращает новую версию приемки
+func (s *ReceptionService) DeleteLastProduct(ctx context.Context, actor *models.Claims, pvzID string, receptionVersion int) (int, error) {
+	if err := requireRole(actor, models.Employee); err != nil {
+		return 0, err
+	}
+
+	reception, err := s.openReception(ctx, pvzID)
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := s.products.DeleteLastFromReception(ctx, reception.ID.String(), receptionVersion)
+	if err != nil {
+		return 0, versionError(err, "failed to delete product")
+	}
+	return version, nil
+}
+
+// CloseLast закрывает открытую приемку ПВЗ, если ее версия совпадает с receptionVersion
+func (s *ReceptionService) CloseLast(ctx context.Context, actor *models.Claims, pvzID string, receptionVersion int) (*models.Reception, error) {
+	if err := requireRole(actor, models.Employee); err != nil {
+		return nil, err
+	}
+
+	reception, err := s.receptions.CloseLastReception(ctx, pvzID, receptionVersion)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoOpenReception) {
+			return nil, ErrNoOpenReception
+		}
+		return nil, versionError(err, "failed to close reception")
+	}
+	return reception, nil
+}
+
+// versionError переводит ошибки проверки версии в ошибки сервиса
+func versionError(err error, msg string) error {
+	switch {
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return ErrVersionMismatch
+	case errors.Is(err, repository.ErrPVZNotFound):
+		return ErrPVZNotFound
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func (s *ReceptionService) openReception(ctx context.Context, pvzID string) (*models.Reception, error) {
+	reception, err := s.receptions.GetLastOpenReception(ctx, pvzID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoOpenReception) {
+			return nil, ErrNoOpenReception
+		}
+		return nil, fmt.Errorf("failed to get open reception: %w", err)
+	}
+	return reception, nil
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/internal/repository/memory"
+)
+
+var (
+	employee  = &models.Claims{UserID: uuid.NewString(), Role: models.Employee}
+	moderator = &models.Claims{UserID: uuid.NewString(), Role: models.Moderator}
+)
+
+func newReceptionFixture(t *testing.T) (*memory.Store, *ReceptionService, string) {
+	t.Helper()
+
+	store := memory.NewStore()
+	pvz := &models.PVZ{ID: uuid.New(), City: "Москва", RegistrationDate: time.Now().UTC(), Version: 1}
+	if err := store.PVZ().Create(context.Background(), pvz); err != nil {
+		t.Fatalf("failed to create pvz: %v", err)
+	}
+
+	return store, NewReceptionService(store.Receptions(), store.Products()), pvz.ID.String()
+}
+
+func TestReceptionServiceOnlyOneOpenReception(t *testing.T) {
+	ctx := context.Background()
+	_, svc, pvzID := newReceptionFixture(t)
+
+	if _, err := svc.Open(ctx, employee, pvzID, repository.AnyVersion); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := svc.Open(ctx, employee, pvzID, repository.AnyVersion); !errors.Is(err, ErrReceptionAlreadyOpen) {
+		t.Fatalf("second Open() error = %v, want %v", err, ErrReceptionAlreadyOpen)
+	}
+
+	if _, err := svc.CloseLast(ctx, employee, pvzID, repository.AnyVersion); err != nil {
+		t.Fatalf("CloseLast() error = %v", err)
+	}
+	if _, err := svc.Open(ctx, employee, pvzID, repository.AnyVersion); err != nil {
+		t.Fatalf("Open() after close error = %v", err)
+	}
+}
+
+func TestReceptionServiceProductsRequireOpenReception(t *testing.T) {
+	ctx := context.Background()
+	store, svc, pvzID := newReceptionFixture(t)
+
+	if _, _, err := svc.AddProduct(ctx, employee, pvzID, "обувь", repository.AnyVersion); !errors.Is(err, ErrNoOpenReception) {
+		t.Fatalf("AddProduct() without reception error = %v, want %v", err, ErrNoOpenReception)
+	}
+
+	reception, err := svc.Open(ctx, employee, pvzID, repository.AnyVersion)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	for _, productType := range []string{"электроника", "одежда", "обувь"} {
+		if _, _, err := svc.AddProduct(ctx, employee, pvzID, productType, repository.AnyVersion); err != nil {
+			t.Fatalf("AddProduct(%s) error = %v", productType, err)
+		}
+	}
+
+	if _, err := svc.DeleteLastProduct(ctx, employee, pvzID, repository.AnyVersion); err != nil {
+		t.Fatalf("DeleteLastProduct() error = %v", err)
+	}
+
+	products := store.Products().Products(reception.ID.String())
+	if len(products) != 2 || products[1].Type != "одежда" {
+		t.Fatalf("expected last product to be removed, got %+v", products)
+	}
+
+	if _, err := svc.CloseLast(ctx, employee, pvzID, repository.AnyVersion); err != nil {
+		t.Fatalf("CloseLast() error = %v", err)
+	}
+
+	if _, _, err := svc.AddProduct(ctx, employee, pvzID, "обувь", repository.AnyVersion); !errors.Is(err, ErrNoOpenReception) {
+		t.Errorf("AddProduct() after close error = %v, want %v", err, ErrNoOpenReception)
+	}
+	if _, err := svc.DeleteLastProduct(ctx, employee, pvzID, repository.AnyVersion); !errors.Is(err, ErrNoOpenReception) {
+		t.Errorf("DeleteLastProduct() after close error = %v, want %v", err, ErrNoOpenReception)
+	}
+	if _, err := svc.CloseLast(ctx, employee, pvzID, repository.AnyVersion); !errors.Is(err, ErrNoOpenReception) {
+		t.Errorf("CloseLast() without reception error = %v, want %v", err, ErrNoOpenReception)
+	}
+}
+
+func TestReceptionServiceRoles(t *testing.T) {
+	ctx := context.Background()
+	_, svc, pvzID := newReceptionFixture(t)
+
+	tests := []struct {
+		name  string
+		actor *models.Claims
+		want  error
+	}{
+		{name: "moderator", actor: moderator, want: ErrForbidden},
+		{name: "anonymous", actor: nil, want: ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Open(ctx, tt.actor, pvzID, repository.AnyVersion); !errors.Is(err, tt.want) {
+				t.Errorf("Open() error = %v, want %v", err, tt.want)
+			}
+			if _, _, err := svc.AddProduct(ctx, tt.actor, pvzID, "обувь", repository.AnyVersion); !errors.Is(err, tt.want) {
+				t.Errorf("AddProduct() error = %v, want %v", err, tt.want)
+			}
+			if _, err := svc.DeleteLastProduct(ctx, tt.actor, pvzID, repository.AnyVersion); !errors.Is(err, tt.want) {
+				t.Errorf("DeleteLastProduct() error = %v, want %v", err, tt.want)
+			}
+			if _, err := svc.CloseLast(ctx, tt.actor, pvzID, repository.AnyVersion); !errors.Is(err, tt.want) {
+				t.Errorf("CloseLast() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceptionServiceVersions(t *testing.T) {
+	ctx := context.Background()
+	store, svc, pvzID := newReceptionFixture(t)
+
+	if _, err := svc.Open(ctx, employee, pvzID, 2); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("Open() with stale pvz version error = %v, want %v", err, ErrVersionMismatch)
+	}
+	reception, err := svc.Open(ctx, employee, pvzID, 1)
+	if err != nil || reception.Version != 1 {
+		t.Fatalf("Open() = %+v, %v, want reception version 1", reception, err)
+	}
+
+	// Товары защищены версией приемки и не меняют версию ПВЗ
+	_, version, err := svc.AddProduct(ctx, employee, pvzID, "обувь", reception.Version)
+	if err != nil || version != 2 {
+		t.Fatalf("AddProduct() = %d, %v, want version 2", version, err)
+	}
+	if _, _, err := svc.AddProduct(ctx, employee, pvzID, "обувь", reception.Version); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("AddProduct() with stale version error = %v, want %v", err, ErrVersionMismatch)
+	}
+	if version, err = svc.DeleteLastProduct(ctx, employee, pvzID, version); err != nil || version != 3 {
+		t.Fatalf("DeleteLastProduct() = %d, %v, want version 3", version, err)
+	}
+	if pvz := pvzVersion(t, store, pvzID); pvz != 2 {
+		t.Errorf("pvz version after products = %d, want 2", pvz)
+	}
+
+	if _, err := svc.CloseLast(ctx, employee, pvzID, 2); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("CloseLast() with stale version error = %v, want %v", err, ErrVersionMismatch)
+	}
+	closed, err := svc.CloseLast(ctx, employee, pvzID, version)
+	if err != nil || closed.Version != 4 {
+		t.Fatalf("CloseLast() = %+v, %v, want reception version 4", closed, err)
+	}
+	if pvz := pvzVersion(t, store, pvzID); pvz != 3 {
+		t.Errorf("pvz version after close = %d, want 3", pvz)
+	}
+
+	if _, err := svc.Open(ctx, employee, uuid.NewString(), repository.AnyVersion); !errors.Is(err, ErrPVZNotFound) {
+		t.Errorf("Open() for unknown pvz error = %v, want %v", err, ErrPVZNotFound)
+	}
+}
+
+func pvzVersion(t *testing.T, store *memory.Store, pvzID string) int {
+	t.Helper()
+
+	list, err := store.PVZ().GetPVZ(context.Background(), repository.GetPVZFilter{Page: 1, Limit: 30})
+	if err != nil {
+		t.Fatalf("GetPVZ() error = %v", err)
+	}
+	for _, item := range list {
+		if item.PVZ.ID.String() == pvzID {
+			return item.PVZ.Version
+		}
+	}
+	t.Fatalf("pvz %s not found", pvzID)
+	return 0
+}
@@ -0,0 +1,120 @@
+// Package service содержит бизнес-правила ПВЗ, приемок и авторизации.
+// Сервисы зависят только от интерфейсов хранилищ, поэтому их можно тестировать
+// на in-memory реализациях из internal/repository/memory без Postgres и Redis
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/pkg/mailer"
+)
+
+var (
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrReceptionAlreadyOpen = errors.New("pvz already has an open reception")
+	ErrNoOpenReception      = errors.New("no open reception found")
+	ErrPVZNotFound          = errors.New("pvz not found")
+	ErrEmailTaken           = errors.New("email already registered")
+	ErrInvalidCredentials   = errors.New("invalid credentials")
+	ErrAccountLocked        = errors.New("account temporarily locked")
+	ErrEmailNotVerified     = errors.New("email not verified")
+
+	// ErrInvalidToken - токена из письма нет, он истек или уже использован
+	ErrInvalidToken = errors.New("invalid or expired token")
+
+	// ErrVersionMismatch - ПВЗ или приемка изменились после того, как клиент получил их версию
+	ErrVersionMismatch = errors.New("version mismatch")
+
+	// ErrUnknownUser и ErrWrongPassword различаются только для метрик,
+	// клиенту оба отдаются как ErrInvalidCredentials
+	ErrUnknownUser   = fmt.Errorf("%w: unknown user", ErrInvalidCredentials)
+	ErrWrongPassword = fmt.Errorf("%w: wrong password", ErrInvalidCredentials)
+)
+
+type PVZRepository interface {
+	Create(ctx context.Context, pvz *models.PVZ) error
+	GetPVZ(ctx context.Context, filter repository.GetPVZFilter) ([]repository.PVZandReceptions, error)
+}
+
+// Изменяющие методы ReceptionRepository и ProductRepository проверяют ожидаемую версию
+// (repository.AnyVersion - без проверки) и увеличивают ее. Открытие приемки защищено версией ПВЗ,
+// товары и закрытие - версией приемки. При несовпадении возвращается repository.ErrVersionMismatch
+type ReceptionRepository interface {
+	HasOpenReception(ctx context.Context, pvzID string) (bool, error)
+	Create(ctx context.Context, reception *models.Reception, pvzVersion int) error
+	// GetLastOpenReception и CloseLastReception возвращают repository.ErrNoOpenReception, если открытой приемки нет
+	GetLastOpenReception(ctx context.Context, pvzID string) (*models.Reception, error)
+	CloseLastReception(ctx context.Context, pvzID string, receptionVersion int) (*models.Reception, error)
+}
+
+// Методы ProductRepository возвращают новую версию приемки
+type ProductRepository interface {
+	Create(ctx context.Context, product *models.Product, receptionVersion int) (int, error)
+	DeleteLastFromReception(ctx context.Context, receptionID string, receptionVersion int) (int, error)
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	// GetByEmail возвращает repository.ErrUserNotFound, если пользователя нет
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	CreateToken(ctx context.Context, token *models.UserToken) error
+	// VerifyEmail и ResetPassword гасят токен по хешу, repository.ErrTokenNotFound - токен
+	// не найден, истек или уже использован
+	VerifyEmail(ctx context.Context, tokenHash string) (*models.User, error)
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (*models.User, error)
+}
+
+// LockedError - вход заблокирован после серии неудачных попыток, errors.Is(err, ErrAccountLocked)
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrAccountLocked, e.RetryAfter)
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+// LoginGuard считает неудачные входы по паре email и IP клиента и блокирует вход
+// с этого адреса, обычно это ratelimit.Lockout
+type LoginGuard interface {
+	// Locked возвращает оставшееся время блокировки, 0 - вход разрешен
+	Locked(ctx context.Context, email, ip string) (time.Duration, error)
+	Fail(ctx context.Context, email, ip string) (time.Duration, error)
+	// Reset снимает блокировку email только с адреса ip, ResetAll - со всех адресов
+	Reset(ctx context.Context, email, ip string) error
+	ResetAll(ctx context.Context, email string) error
+}
+
+// TokenStore хранит единственный действующий токен пользователя
+type TokenStore interface {
+	Set(ctx context.Context, userID string, token string) error
+	Invalidate(ctx context.Context, userID string) error
+}
+
+// Mailer отправляет письма, обычно это реализация из pkg/mailer
+type Mailer interface {
+	Send(ctx context.Context, msg mailer.Message) error
+}
+
+// requireRole проверяет, что запрос сделан пользователем с одной из ролей
+func requireRole(actor *models.Claims, roles ...models.Role) error {
+	if actor == nil {
+		return ErrUnauthorized
+	}
+	for _, role := range roles {
+		if actor.Role == role {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
@@ -1,3 +1,5 @@
+//go:build integration
+
 package test
 
 import (
@@ -7,72 +9,80 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 
-	"github.com/kosttiik/pvz-service/internal/handlers"
-	"github.com/kosttiik/pvz-service/internal/middleware"
+	"github.com/kosttiik/pvz-service/internal/app"
+	"github.com/kosttiik/pvz-service/internal/config"
 	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/ratelimit"
+	"github.com/kosttiik/pvz-service/internal/testutils"
 	"github.com/kosttiik/pvz-service/internal/utils"
 	"github.com/kosttiik/pvz-service/pkg/database"
 	"github.com/kosttiik/pvz-service/pkg/logger"
-	"github.com/kosttiik/pvz-service/pkg/redis"
 )
 
 func init() {
-	os.Setenv("DB_HOST", "localhost")
-	os.Setenv("DB_PORT", "5432")
-	os.Setenv("DB_USER", "postgres")
-	os.Setenv("DB_PASSWORD", "postgres")
-	os.Setenv("DB_NAME", "pvz_db")
-	os.Setenv("REDIS_HOST", "localhost")
-	os.Setenv("JWT_SECRET", "test_secret")
-
 	if err := logger.Init(); err != nil {
 		panic(err)
 	}
 }
 
 func TestReceptionWorkflow(t *testing.T) {
-	if err := database.Connect(); err != nil {
-		t.Fatalf("Failed to connect to database: %v", err)
-	}
-	if err := redis.Connect(); err != nil {
-		t.Fatalf("Failed to connect to redis: %v", err)
-	}
-	defer redis.Close()
 	defer logger.Close()
 
-	utils.Migrate()
+	cfg := config.Default()
+	cfg.Postgres = testutils.TestPostgresConfig()
+	cfg.Auth.JWTSecret = testutils.TestJWTSecret
+	// Сценарий добавляет товары быстрее, чем позволяет квота кладовщика
+	cfg.RateLimit.Quota = ratelimit.QuotaConfig{}
+
+	application, err := app.New(context.Background(), cfg, logger.Log)
+	if err != nil {
+		t.Fatalf("Failed to start application: %v", err)
+	}
+	defer application.Close()
+
+	// Запросы идут через полный стек маршрутов и middleware приложения
+	h := application.Handler()
+
+	db, err := database.Open(cfg.Postgres)
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
 
 	// Очищаем таблицы перед тестом
-	_, err := database.DB.Exec(context.Background(), "TRUNCATE pvz, reception, product CASCADE")
+	_, err = db.Exec(context.Background(), "TRUNCATE pvz, reception, product CASCADE")
 	if err != nil {
 		t.Fatalf("Failed to cleanup tables: %v", err)
 	}
 
 	t.Log("Getting moderator token...")
-	moderatorToken := getModeratorToken(t)
+	moderatorToken := getModeratorToken(t, h)
 
 	t.Log("Creating PVZ...")
-	pvz := createPVZ(t, moderatorToken)
+	pvz := createPVZ(t, h, moderatorToken)
 	t.Logf("Created PVZ with ID: %s", pvz.ID)
 
 	t.Log("Getting employee token...")
-	employeeToken := getEmployeeToken(t)
+	employeeToken := getEmployeeToken(t, h)
+
+	// Приемка открывается по ETag ПВЗ, дальше каждое изменение передает ETag приемки из предыдущего ответа
+	etag := utils.ETag(pvz.Version)
 
 	t.Log("Creating reception...")
-	reception := createReception(t, employeeToken, pvz.ID.String())
+	reception, etag := createReception(t, h, employeeToken, pvz.ID.String(), etag)
 	t.Logf("Created reception with ID: %s", reception.ID)
 
 	t.Log("Adding products...")
 	for i := range 50 {
-		product := addProduct(t, employeeToken, pvz.ID.String())
+		var product models.Product
+		product, etag = addProduct(t, h, employeeToken, pvz.ID.String(), etag)
 		t.Logf("Added product %d with ID: %s", i+1, product.ID)
 	}
 
 	t.Log("Closing reception...")
-	closedReception := closeReception(t, employeeToken, pvz.ID.String())
+	closedReception, _ := closeReception(t, h, employeeToken, pvz.ID.String(), etag)
 	t.Logf("Closed reception with status: %s", closedReception.Status)
 
 	// Сверяем статус приёмки
@@ -81,14 +91,14 @@ func TestReceptionWorkflow(t *testing.T) {
 	}
 }
 
-func getEmployeeToken(t *testing.T) string {
+func getEmployeeToken(t *testing.T, h http.Handler) string {
 	body := map[string]string{"role": "employee"}
 	jsonBody, _ := json.Marshal(body)
 
 	req := httptest.NewRequest(http.MethodPost, "/dummyLogin", bytes.NewBuffer(jsonBody))
 	w := httptest.NewRecorder()
 
-	handlers.DummyLoginHandler(w, req)
+	h.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("Failed to get token, status: %d", w.Code)
@@ -104,14 +114,14 @@ func getEmployeeToken(t *testing.T) string {
 	return response.Token
 }
 
-func getModeratorToken(t *testing.T) string {
+func getModeratorToken(t *testing.T, h http.Handler) string {
 	body := map[string]string{"role": "moderator"}
 	jsonBody, _ := json.Marshal(body)
 
 	req := httptest.NewRequest(http.MethodPost, "/dummyLogin", bytes.NewBuffer(jsonBody))
 	w := httptest.NewRecorder()
 
-	handlers.DummyLoginHandler(w, req)
+	h.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("Failed to get moderator token, status: %d", w.Code)
@@ -127,7 +137,7 @@ func getModeratorToken(t *testing.T) string {
 	return response.Token
 }
 
-func createPVZ(t *testing.T, token string) models.PVZ {
+func createPVZ(t *testing.T, h http.Handler, token string) models.PVZ {
 	body := map[string]string{"city": "Москва"}
 	jsonBody, _ := json.Marshal(body)
 
@@ -135,7 +145,7 @@ func createPVZ(t *testing.T, token string) models.PVZ {
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	w := httptest.NewRecorder()
 
-	middleware.AuthMiddleware(middleware.RoleMiddleware("moderator")(handlers.CreatePVZHandler))(w, req)
+	h.ServeHTTP(w, req)
 
 	if w.Code != http.StatusCreated {
 		t.Fatalf("Failed to create PVZ, status: %d", w.Code)
@@ -149,15 +159,16 @@ func createPVZ(t *testing.T, token string) models.PVZ {
 	return pvz
 }
 
-func createReception(t *testing.T, token string, pvzID string) models.Reception {
+func createReception(t *testing.T, h http.Handler, token string, pvzID string, etag string) (models.Reception, string) {
 	body := map[string]string{"pvzId": pvzID}
 	jsonBody, _ := json.Marshal(body)
 
 	req := httptest.NewRequest(http.MethodPost, "/receptions", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("If-Match", etag)
 	w := httptest.NewRecorder()
 
-	middleware.AuthMiddleware(middleware.RoleMiddleware("employee")(handlers.CreateReceptionHandler))(w, req)
+	h.ServeHTTP(w, req)
 
 	if w.Code != http.StatusCreated {
 		t.Fatalf("Failed to create reception, status: %d", w.Code)
@@ -168,10 +179,10 @@ func createReception(t *testing.T, token string, pvzID string) models.Reception
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	return reception
+	return reception, w.Header().Get("ETag")
 }
 
-func addProduct(t *testing.T, token string, pvzID string) models.Product {
+func addProduct(t *testing.T, h http.Handler, token string, pvzID string, etag string) (models.Product, string) {
 	body := map[string]string{
 		"type":  "электроника",
 		"pvzId": pvzID,
@@ -180,15 +191,10 @@ func addProduct(t *testing.T, token string, pvzID string) models.Product {
 
 	req := httptest.NewRequest(http.MethodPost, "/products", bytes.NewBuffer(jsonBody))
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("If-Match", etag)
 	w := httptest.NewRecorder()
 
-	middleware.AuthMiddleware(
-		middleware.RoleMiddleware("employee")(
-			func(w http.ResponseWriter, r *http.Request) {
-				handlers.AddProductHandler(w, r)
-			},
-		),
-	)(w, req)
+	h.ServeHTTP(w, req)
 
 	if w.Code != http.StatusCreated {
 		var errResp struct {
@@ -206,15 +212,16 @@ func addProduct(t *testing.T, token string, pvzID string) models.Product {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	return product
+	return product, w.Header().Get("ETag")
 }
 
-func closeReception(t *testing.T, token string, pvzID string) models.Reception {
+func closeReception(t *testing.T, h http.Handler, token string, pvzID string, etag string) (models.Reception, string) {
 	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/pvz/%s/close_last_reception", pvzID), nil)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("If-Match", etag)
 	w := httptest.NewRecorder()
 
-	middleware.AuthMiddleware(middleware.RoleMiddleware("employee")(handlers.CloseReceptionHandler))(w, req)
+	h.ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("Failed to close reception, status: %d", w.Code)
@@ -225,5 +232,5 @@ func closeReception(t *testing.T, token string, pvzID string) models.Reception {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
 
-	return reception
+	return reception, w.Header().Get("ETag")
 }
@@ -1,29 +1,28 @@
 package testutils
 
 import (
-	"os"
-
 	"github.com/kosttiik/pvz-service/pkg/database"
 	"github.com/kosttiik/pvz-service/pkg/logger"
 	"github.com/kosttiik/pvz-service/pkg/redis"
 	"go.uber.org/zap"
 )
 
-func SetupTestEnvironment() func() {
-	setupEnvVars()
+const TestJWTSecret = "test_secret"
 
+func SetupTestEnvironment() func() {
 	if err := logger.Init(); err != nil {
 		panic(err)
 	}
+	logger.SetLevel("debug")
 	log := logger.Log
 	log.Info("Test logger initialized")
 
-	if err := database.Connect(); err != nil {
+	if err := database.Connect(TestPostgresConfig()); err != nil {
 		log.Fatal("Failed to connect to test database", zap.Error(err))
 	}
 	log.Info("Connected to test database")
 
-	if err := redis.Connect(); err != nil {
+	if err := redis.Connect(redis.DefaultConfig()); err != nil {
 		log.Fatal("Failed to connect to test redis", zap.Error(err))
 	}
 	log.Info("Connected to test redis")
@@ -35,19 +34,9 @@ func SetupTestEnvironment() func() {
 	}
 }
 
-func setupEnvVars() {
-	testEnv := map[string]string{
-		"JWT_SECRET":  "test_secret",
-		"DB_HOST":     "localhost",
-		"DB_PORT":     "5432",
-		"DB_USER":     "postgres",
-		"DB_PASSWORD": "postgres",
-		"DB_NAME":     "pvz_db",
-		"REDIS_HOST":  "localhost",
-		"LOG_LEVEL":   "debug",
-	}
-
-	for k, v := range testEnv {
-		os.Setenv(k, v)
-	}
+// TestPostgresConfig - локальная база из compose.yaml
+func TestPostgresConfig() database.Config {
+	config := database.DefaultConfig()
+	config.Password = "postgres"
+	return config
 }
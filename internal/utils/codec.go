@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/pkg/msgpack"
+	"github.com/munnerz/goautoneg"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeMsgpack  = msgpack.ContentType
+)
+
+// MaxBodySize - предел тела, которое читает Decode, тот же, что у импорта ПВЗ
+const MaxBodySize = 10 << 20
+
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// ProtoMarshaler реализуют типы, у которых есть сообщение в proto/pvz.proto
+type ProtoMarshaler interface {
+	MarshalProto() ([]byte, error)
+}
+
+type ProtoUnmarshaler interface {
+	UnmarshalProto(data []byte) error
+}
+
+// VaryAccept отмечает, что ответ зависит от Accept. Хендлеры с условными запросами
+// ставят его до ответа 304, поэтому повторно заголовок не добавляется
+func VaryAccept(h http.Header) {
+	if !slices.Contains(h.Values("Vary"), "Accept") {
+		h.Add("Vary", "Accept")
+	}
+}
+
+// Write кодирует ответ в формат из заголовка Accept. Без Accept отвечаем JSON,
+// protobuf доступен только для типов с ProtoMarshaler
+func Write(w http.ResponseWriter, r *http.Request, data any, status int) {
+	alternatives := []string{ContentTypeJSON, ContentTypeMsgpack}
+	protoData, hasProto := data.(ProtoMarshaler)
+	if hasProto {
+		alternatives = append(alternatives, ContentTypeProtobuf)
+	}
+
+	contentType := ContentTypeJSON
+	if accept := r.Header.Get("Accept"); accept != "" {
+		contentType = goautoneg.Negotiate(accept, alternatives)
+	}
+
+	VaryAccept(w.Header())
+
+	var body []byte
+	var err error
+	switch contentType {
+	case ContentTypeJSON:
+		WriteJSON(w, data, status)
+		return
+	case ContentTypeMsgpack:
+		body, err = msgpack.Marshal(data)
+	case ContentTypeProtobuf:
+		body, err = protoData.MarshalProto()
+	default:
+		WriteProblem(w, r, dto.CodeNotAcceptable, "Not acceptable")
+		return
+	}
+
+	if err != nil {
+		WriteProblem(w, r, dto.CodeInternal, "Failed to encode response")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// Decode разбирает тело запроса по Content-Type. Без Content-Type считаем тело JSON
+func Decode(r *http.Request, v any) error {
+	mediaType := ContentTypeJSON
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return ErrUnsupportedMediaType
+		}
+		mediaType = parsed
+	}
+
+	// ResponseWriter здесь нет, поэтому соединение после превышения не закрывается,
+	// но больше MaxBodySize не читается ни в одном формате
+	body := http.MaxBytesReader(nil, r.Body, MaxBodySize)
+
+	switch mediaType {
+	case ContentTypeJSON:
+		return json.NewDecoder(body).Decode(v)
+	case ContentTypeMsgpack:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		return msgpack.Unmarshal(data, v)
+	case ContentTypeProtobuf:
+		target, ok := v.(ProtoUnmarshaler)
+		if !ok {
+			return ErrUnsupportedMediaType
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		return target.UnmarshalProto(data)
+	default:
+		return ErrUnsupportedMediaType
+	}
+}
+
+// WriteDecodeError отвечает 415 на неизвестный Content-Type, 413 на слишком большое тело
+// и 400 на невалидное
+func WriteDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		WriteProblem(w, r, dto.CodeUnsupportedMediaType, "Unsupported media type")
+		return
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		WriteProblem(w, r, dto.CodePayloadTooLarge, "Request body is too large")
+		return
+	}
+	WriteProblem(w, r, dto.CodeInvalidRequest, "Invalid request")
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/pkg/msgpack"
+	pvz_v1 "github.com/kosttiik/pvz-service/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWrite(t *testing.T) {
+	pvz := models.PVZ{
+		ID:               uuid.New(),
+		RegistrationDate: time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC),
+		City:             "Казань",
+		Version:          3,
+	}
+
+	tests := []struct {
+		name            string
+		accept          string
+		data            any
+		wantStatus      int
+		wantContentType string
+	}{
+		{"No accept", "", pvz, http.StatusCreated, ContentTypeJSON},
+		{"Any", "*/*", pvz, http.StatusCreated, ContentTypeJSON},
+		{"Protobuf", ContentTypeProtobuf, pvz, http.StatusCreated, ContentTypeProtobuf},
+		{"Msgpack", ContentTypeMsgpack, pvz, http.StatusCreated, ContentTypeMsgpack},
+		{"Quality", "application/json;q=0.5, application/msgpack", pvz, http.StatusCreated, ContentTypeMsgpack},
+		{"Protobuf without message", ContentTypeProtobuf, map[string]string{"a": "b"}, http.StatusNotAcceptable, ContentTypeProblem},
+		{"Unknown", "text/html", pvz, http.StatusNotAcceptable, ContentTypeProblem},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/pvz", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			Write(w, req, tt.data, http.StatusCreated)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Write() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			var got models.PVZ
+			switch tt.wantContentType {
+			case ContentTypeJSON:
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatalf("Failed to decode JSON: %v", err)
+				}
+			case ContentTypeMsgpack:
+				if err := msgpack.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatalf("Failed to decode msgpack: %v", err)
+				}
+			case ContentTypeProtobuf:
+				var msg pvz_v1.PVZ
+				if err := proto.Unmarshal(w.Body.Bytes(), &msg); err != nil {
+					t.Fatalf("Failed to decode protobuf: %v", err)
+				}
+				got = models.PVZ{
+					ID:               uuid.MustParse(msg.GetId()),
+					RegistrationDate: msg.GetRegistrationDate().AsTime(),
+					City:             msg.GetCity(),
+					Version:          int(msg.GetVersion()),
+				}
+			}
+			if got != pvz {
+				t.Errorf("Decoded %+v, want %+v", got, pvz)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	want := dto.LoginRequest{Email: "user@example.com", Password: "secret"}
+
+	jsonBody, _ := json.Marshal(want)
+	msgpackBody, _ := msgpack.Marshal(want)
+	protoBody, _ := proto.Marshal(&pvz_v1.LoginRequest{Email: want.Email, Password: want.Password})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantErr     bool
+	}{
+		{"Default JSON", "", jsonBody, false},
+		{"JSON with charset", "application/json; charset=utf-8", jsonBody, false},
+		{"Msgpack", ContentTypeMsgpack, msgpackBody, false},
+		{"Protobuf", ContentTypeProtobuf, protoBody, false},
+		{"Unsupported", "text/plain", jsonBody, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var got dto.LoginRequest
+			err := Decode(req, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != want {
+				t.Errorf("Decode() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecode_ProtobufWithoutMessage(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(""))
+	req.Header.Set("Content-Type", ContentTypeProtobuf)
+
+	var v map[string]any
+	err := Decode(req, &v)
+
+	w := httptest.NewRecorder()
+	WriteDecodeError(w, req, err)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("WriteDecodeError() status = %v, want %v", w.Code, http.StatusUnsupportedMediaType)
+	}
+}
+
+func TestDecode_TooLarge(t *testing.T) {
+	// JSON читается потоком, поэтому тело должно оставаться валидным до предела
+	body := []byte(`{"email":"` + strings.Repeat("a", MaxBodySize) + `"}`)
+
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeMsgpack, ContentTypeProtobuf} {
+		t.Run(contentType, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+
+			var got dto.LoginRequest
+			err := Decode(req, &got)
+
+			w := httptest.NewRecorder()
+			WriteDecodeError(w, req, err)
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("WriteDecodeError(%v) status = %v, want %v", err, w.Code, http.StatusRequestEntityTooLarge)
+			}
+		})
+	}
+}
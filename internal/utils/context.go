@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/kosttiik/pvz-service/internal/models"
 )
@@ -22,3 +23,39 @@ func GetUserFromContext(ctx context.Context) *models.Claims {
 
 	return claims
 }
+
+const requestIDContextKey contextKey = "requestID"
+
+func SetRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestID возвращает ID запроса из контекста, а если его там нет - из заголовка X-Request-ID
+func RequestID(r *http.Request) string {
+	if requestID, ok := r.Context().Value(requestIDContextKey).(string); ok {
+		return requestID
+	}
+	return r.Header.Get("X-Request-ID")
+}
+
+const shutdownContextKey contextKey = "shutdown"
+
+// SetShutdownContext кладет контекст, который отменяется в начале остановки сервера
+func SetShutdownContext(ctx context.Context, shutdown context.Context) context.Context {
+	return context.WithValue(ctx, shutdownContextKey, shutdown)
+}
+
+// StreamContext возвращает контекст для долгоживущих соединений (SSE, WebSocket).
+// Он отменяется по завершении запроса или в начале остановки сервера, потому что
+// http.Server.Shutdown сам такие соединения не закрывает и ждал бы их до таймаута
+func StreamContext(r *http.Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(r.Context())
+	if shutdown, ok := r.Context().Value(shutdownContextKey).(context.Context); ok {
+		stop := context.AfterFunc(shutdown, cancel)
+		return ctx, func() {
+			stop()
+			cancel()
+		}
+	}
+	return ctx, cancel
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrPreconditionFailed - If-Match не может совпасть ни с одной версией: слабый или нечисловой тег
+var ErrPreconditionFailed = errors.New("If-Match does not match any version")
+
+// ETag - сильный тег версии ресурса
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// IfMatchVersion возвращает версию из If-Match. Без заголовка и для "*" версия не проверяется, тогда 0.
+// Поддерживается один тег: списком клиенты, которые видели ресурс, не пользуются
+func IfMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	// Для If-Match теги сравниваются строго, слабый тег не совпадает никогда
+	tag, ok := strings.CutPrefix(value, `"`)
+	tag, ok2 := strings.CutSuffix(tag, `"`)
+	if !ok || !ok2 {
+		return 0, ErrPreconditionFailed
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, ErrPreconditionFailed
+	}
+	return version, nil
+}
+
+// NotModified проверяет If-None-Match слабым сравнением, как требует RFC 9110
+func NotModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
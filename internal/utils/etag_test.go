@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int
+		wantErr error
+	}{
+		{header: "", want: 0},
+		{header: "*", want: 0},
+		{header: `"3"`, want: 3},
+		{header: ` "12" `, want: 12},
+		{header: `W/"3"`, wantErr: ErrPreconditionFailed},
+		{header: `"abc"`, wantErr: ErrPreconditionFailed},
+		{header: `3`, wantErr: ErrPreconditionFailed},
+		{header: `"1", "2"`, wantErr: ErrPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/receptions", nil)
+		if tt.header != "" {
+			req.Header.Set("If-Match", tt.header)
+		}
+
+		got, err := IfMatchVersion(req)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("IfMatchVersion(%q) = %d, %v, want %d, %v", tt.header, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := `W/"abc"`
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: `W/"abc"`, want: true},
+		{header: `"abc"`, want: true},
+		{header: `"old", W/"abc"`, want: true},
+		{header: `"old"`, want: false},
+		{header: "*", want: true},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/pvz", nil)
+		if tt.header != "" {
+			req.Header.Set("If-None-Match", tt.header)
+		}
+		if got := NotModified(req, etag); got != tt.want {
+			t.Errorf("NotModified(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
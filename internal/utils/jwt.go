@@ -2,32 +2,44 @@ package utils
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/kosttiik/pvz-service/internal/models"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+const defaultJWTTTL = 24 * time.Hour
 
-func GenerateJWT(userID string, role string) (string, error) {
+// JWT выпускает и проверяет токены одним ключом. У каждого экземпляра приложения свой JWT
+type JWT struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewJWT(secret string, ttl time.Duration) *JWT {
+	if ttl <= 0 {
+		ttl = defaultJWTTTL
+	}
+	return &JWT{secret: []byte(secret), ttl: ttl}
+}
+
+func (j *JWT) Generate(userID string, role string) (string, error) {
 	claims := &models.Claims{
 		UserID: userID,
 		Role:   models.Role(role),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
+			ExpiresAt: time.Now().Add(j.ttl).Unix(),
 			Issuer:    "pvz-service",
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString(j.secret)
 }
 
-func ParseJWT(tokenString string) (*models.Claims, error) {
+func (j *JWT) Parse(tokenString string) (*models.Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &models.Claims{}, func(token *jwt.Token) (any, error) {
-		return jwtSecret, nil
+		return j.secret, nil
 	})
 
 	if err != nil || !token.Valid {
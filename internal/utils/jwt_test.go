@@ -1,19 +1,13 @@
 package utils
 
 import (
-	"os"
 	"testing"
 	"time"
 
 	"github.com/kosttiik/pvz-service/internal/models"
 )
 
-func TestMain(m *testing.M) {
-	os.Setenv("JWT_SECRET", "test_secret")
-	code := m.Run()
-	os.Unsetenv("JWT_SECRET")
-	os.Exit(code)
-}
+var testJWT = NewJWT("test_secret", 0)
 
 func TestGenerateAndParseJWT(t *testing.T) {
 	tests := []struct {
@@ -50,7 +44,7 @@ func TestGenerateAndParseJWT(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, err := GenerateJWT(tt.userID, tt.role)
+			token, err := testJWT.Generate(tt.userID, tt.role)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GenerateJWT() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -60,7 +54,7 @@ func TestGenerateAndParseJWT(t *testing.T) {
 				return
 			}
 
-			claims, err := ParseJWT(token)
+			claims, err := testJWT.Parse(token)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseJWT() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -84,12 +78,12 @@ func TestGenerateAndParseJWT(t *testing.T) {
 		userID := "test"
 		role := "employee"
 
-		token, err := GenerateJWT(userID, role)
+		token, err := testJWT.Generate(userID, role)
 		if err != nil {
 			t.Fatalf("GenerateJWT() failed: %v", err)
 		}
 
-		claims, err := ParseJWT(token)
+		claims, err := testJWT.Parse(token)
 		if err != nil {
 			t.Fatalf("ParseJWT() failed: %v", err)
 		}
@@ -151,7 +145,7 @@ func TestParseJWT_Invalid(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := ParseJWT(tt.token)
+			_, err := testJWT.Parse(tt.token)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseJWT() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -162,12 +156,12 @@ func TestParseJWT_Invalid(t *testing.T) {
 func TestGenerateJWT_WithCustomExpiration(t *testing.T) {
 	userID := "test-user"
 	role := "employee"
-	token, err := GenerateJWT(userID, role)
+	token, err := testJWT.Generate(userID, role)
 	if err != nil {
 		t.Fatalf("GenerateJWT() error = %v", err)
 	}
 
-	claims, err := ParseJWT(token)
+	claims, err := testJWT.Parse(token)
 	if err != nil {
 		t.Fatalf("ParseJWT() error = %v", err)
 	}
@@ -177,3 +171,14 @@ func TestGenerateJWT_WithCustomExpiration(t *testing.T) {
 		t.Error("Token expiration is less than 23 hours")
 	}
 }
+
+func TestParseJWT_OtherSecret(t *testing.T) {
+	token, err := testJWT.Generate("test-user", "employee")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if _, err := NewJWT("other_secret", 0).Parse(token); err == nil {
+		t.Error("Parse() should reject token signed with another secret")
+	}
+}
@@ -2,20 +2,23 @@ package utils
 
 import (
 	"context"
+	"fmt"
 
-	"github.com/kosttiik/pvz-service/pkg/database"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kosttiik/pvz-service/pkg/logger"
 	"go.uber.org/zap"
 )
 
-func Migrate() {
-	log := logger.Log
-	connection := database.DB
-	ctx := context.Background()
+// SchemaVersion - версия схемы, которую ожидает код. Увеличивается при каждом изменении миграций
+const SchemaVersion = 4
 
-	tx, err := connection.Begin(ctx)
+// Migrate применяет схему в одной транзакции и записывает SchemaVersion
+func Migrate(ctx context.Context, db *pgxpool.Pool) error {
+	log := logger.FromContext(ctx)
+
+	tx, err := db.Begin(ctx)
 	if err != nil {
-		log.Fatal("Failed to start migration transaction", zap.Error(err))
+		return fmt.Errorf("failed to start migration transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
@@ -35,6 +38,14 @@ CREATE TABLE IF NOT EXISTS reception (
 	status VARCHAR(50) NOT NULL
 );
 
+ALTER TABLE reception ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP;
+
+-- Версии для оптимистичной блокировки (ETag и If-Match)
+ALTER TABLE pvz ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE reception ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_reception_date_time ON reception(date_time);
+
 CREATE TABLE IF NOT EXISTS product (
 	id UUID PRIMARY KEY,
 	date_time TIMESTAMP NOT NULL DEFAULT now(),
@@ -52,14 +63,99 @@ CREATE TABLE IF NOT EXISTS users (
 );
 
 CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+
+-- Пользователи, заведенные до подтверждения почты, считаются подтвержденными:
+-- значение по умолчанию заполняет существующие строки и сразу снимается
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE users ALTER COLUMN email_verified_at DROP DEFAULT;
+
+-- Одноразовые токены из писем, хранится только sha256 от токена
+CREATE TABLE IF NOT EXISTS user_token (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	purpose VARCHAR(50) NOT NULL,
+	token_hash CHAR(64) UNIQUE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	used_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_token_user ON user_token(user_id, purpose);
+
+CREATE TABLE IF NOT EXISTS outbox (
+	id UUID PRIMARY KEY,
+	event_type VARCHAR(100) NOT NULL,
+	aggregate_id UUID NOT NULL,
+	pvz_id UUID NOT NULL,
+	payload JSONB NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+	created_at TIMESTAMP NOT NULL DEFAULT now(),
+	published_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_outbox_published ON outbox(published_at) WHERE status = 'published';
+
+CREATE TABLE IF NOT EXISTS webhook_subscription (
+	id UUID PRIMARY KEY,
+	url TEXT NOT NULL,
+	event_types TEXT[] NOT NULL,
+	secret VARCHAR(255) NOT NULL,
+	created_by VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+	id UUID PRIMARY KEY,
+	subscription_id UUID NOT NULL REFERENCES webhook_subscription(id) ON DELETE CASCADE,
+	event_id UUID NOT NULL,
+	event_type VARCHAR(100) NOT NULL,
+	payload JSONB NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	response_status INT,
+	last_error TEXT,
+	next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+	created_at TIMESTAMP NOT NULL DEFAULT now(),
+	delivered_at TIMESTAMP,
+	UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_pending ON webhook_delivery(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_subscription ON webhook_delivery(subscription_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INT PRIMARY KEY,
+	applied_at TIMESTAMP NOT NULL DEFAULT now()
+);
 `
 	if _, err := tx.Exec(ctx, sql); err != nil {
-		log.Fatal("Failed to execute migrations", zap.Error(err))
+		return fmt.Errorf("failed to execute migrations: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT (version) DO NOTHING",
+		SchemaVersion); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		log.Fatal("Failed to commit migrations", zap.Error(err))
+		return fmt.Errorf("failed to commit migrations: %w", err)
 	}
 
-	log.Info("Database migration completed successfully")
+	log.Info("Database migration completed successfully", zap.Int("version", SchemaVersion))
+	return nil
+}
+
+// MigrationVersion возвращает последнюю примененную версию схемы, 0 если миграций не было
+func MigrationVersion(ctx context.Context, db *pgxpool.Pool) (int, error) {
+	var version int
+	err := db.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get migration version: %w", err)
+	}
+	return version, nil
 }
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// NewOneTimeToken возвращает случайный токен для ссылки в письме и его хеш для хранения.
+// 256 бит энтропии, поэтому хватает быстрого sha256 без соли
+func NewOneTimeToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashOneTimeToken(token), nil
+}
+
+func HashOneTimeToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestNewOneTimeToken(t *testing.T) {
+	token, hash, err := NewOneTimeToken()
+	if err != nil {
+		t.Fatalf("NewOneTimeToken() error = %v", err)
+	}
+
+	if len(token) != 43 {
+		t.Errorf("got token length %d, want 43", len(token))
+	}
+	if len(hash) != 64 {
+		t.Errorf("got hash length %d, want 64", len(hash))
+	}
+	if HashOneTimeToken(token) != hash {
+		t.Error("hash of token does not match returned hash")
+	}
+
+	other, _, err := NewOneTimeToken()
+	if err != nil {
+		t.Fatalf("NewOneTimeToken() error = %v", err)
+	}
+	if other == token {
+		t.Error("expected different tokens")
+	}
+}
@@ -2,15 +2,24 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/validation"
 )
 
-func WriteError(w http.ResponseWriter, message string, status int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(dto.ErrorResponse{Message: message})
+const ContentTypeProblem = "application/problem+json"
+
+// WriteProblem отвечает ошибкой в формате RFC 7807. Статус и заголовок берутся из кода,
+// detail - описание конкретного случая, instance - ID запроса
+func WriteProblem(w http.ResponseWriter, r *http.Request, code dto.ErrorCode, detail string, fieldErrors ...dto.FieldError) {
+	problem := dto.NewProblem(code, detail, fieldErrors...)
+	problem.Instance = RequestID(r)
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
 }
 
 func WriteJSON(w http.ResponseWriter, data any, status int) {
@@ -18,3 +27,18 @@ func WriteJSON(w http.ResponseWriter, data any, status int) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
+
+// WriteValidationError отвечает validation_failed со всеми ошибками полей из validation.Errors
+func WriteValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		WriteProblem(w, r, dto.CodeInternal, "Failed to validate request")
+		return
+	}
+	WriteProblem(w, r, dto.CodeValidationFailed, "Request validation failed", errs...)
+}
+
+// WriteFieldError отвечает validation_failed с одной ошибкой поля
+func WriteFieldError(w http.ResponseWriter, r *http.Request, field, code, message string) {
+	WriteProblem(w, r, dto.CodeValidationFailed, message, dto.FieldError{Field: field, Code: code, Message: message})
+}
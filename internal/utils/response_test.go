@@ -10,76 +10,128 @@ import (
 	"github.com/kosttiik/pvz-service/internal/dto"
 )
 
-func TestWriteError(t *testing.T) {
+func TestWriteProblem(t *testing.T) {
 	tests := []struct {
-		name       string
-		message    string
-		status     int
-		wantStatus int
+		name        string
+		code        dto.ErrorCode
+		detail      string
+		fieldErrors []dto.FieldError
+		requestID   string
+		wantStatus  int
 	}{
 		{
-			name:       "Bad Request Error",
-			message:    "invalid input",
-			status:     http.StatusBadRequest,
+			name:       "Bad request",
+			code:       dto.CodeInvalidRequest,
+			detail:     "invalid input",
 			wantStatus: http.StatusBadRequest,
 		},
 		{
-			name:       "Internal Server Error",
-			message:    "server error",
-			status:     http.StatusInternalServerError,
+			name:       "Internal server error",
+			code:       dto.CodeInternal,
+			detail:     "server error",
+			requestID:  "req-1",
 			wantStatus: http.StatusInternalServerError,
 		},
 		{
-			name:       "Empty Message",
-			message:    "",
-			status:     http.StatusBadRequest,
-			wantStatus: http.StatusBadRequest,
+			name:       "Not found",
+			code:       dto.CodePVZNotFound,
+			detail:     "PVZ not found",
+			wantStatus: http.StatusNotFound,
 		},
 		{
-			name:       "Unicode message",
-			message:    "ошибка",
-			status:     http.StatusBadRequest,
+			name:   "Validation errors",
+			code:   dto.CodeValidationFailed,
+			detail: "Validation failed",
+			fieldErrors: []dto.FieldError{
+				{Field: "email", Code: dto.FieldRequired, Message: "Email is required"},
+				{Field: "password", Code: dto.FieldRequired, Message: "Password is required"},
+			},
 			wantStatus: http.StatusBadRequest,
 		},
 		{
-			name:       "Special characters",
-			message:    "error: !@#$%^&*()",
-			status:     http.StatusBadRequest,
+			name:       "Unicode detail",
+			code:       dto.CodeInvalidRequest,
+			detail:     "ошибка",
 			wantStatus: http.StatusBadRequest,
 		},
 		{
 			name:       "JSON special chars",
-			message:    `test "quotes" and \backslashes\`,
-			status:     http.StatusBadRequest,
+			code:       dto.CodeInvalidRequest,
+			detail:     `test "quotes" and \backslashes\`,
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name:       "Unknown code",
+			code:       dto.ErrorCode("something_new"),
+			detail:     "unknown",
+			wantStatus: http.StatusInternalServerError,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/pvz", nil)
+			if tt.requestID != "" {
+				r = r.WithContext(SetRequestID(r.Context(), tt.requestID))
+			}
 			w := httptest.NewRecorder()
-			WriteError(w, tt.message, tt.status)
+
+			WriteProblem(w, r, tt.code, tt.detail, tt.fieldErrors...)
 
 			if w.Code != tt.wantStatus {
-				t.Errorf("WriteError() status = %v, want %v", w.Code, tt.wantStatus)
+				t.Errorf("WriteProblem() status = %v, want %v", w.Code, tt.wantStatus)
 			}
 
-			if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
-				t.Errorf("Content-Type = %v, want application/json", contentType)
+			if contentType := w.Header().Get("Content-Type"); contentType != ContentTypeProblem {
+				t.Errorf("Content-Type = %v, want %v", contentType, ContentTypeProblem)
 			}
 
-			var gotErr dto.ErrorResponse
-			if err := json.NewDecoder(w.Body).Decode(&gotErr); err != nil {
-				t.Errorf("Failed to decode error response: %v", err)
+			var got dto.Problem
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
 			}
 
-			if gotErr.Message != tt.message {
-				t.Errorf("WriteError() message = %v, want %v", gotErr.Message, tt.message)
+			if got.Code != tt.code || got.Status != tt.wantStatus || got.Detail != tt.detail {
+				t.Errorf("WriteProblem() = %+v", got)
+			}
+			if got.Title == "" || got.Type == "" {
+				t.Error("Title and type should be set")
+			}
+			if got.Instance != tt.requestID {
+				t.Errorf("Instance = %q, want %q", got.Instance, tt.requestID)
+			}
+			if got.Message != tt.detail {
+				t.Errorf("Message = %q, want %q", got.Message, tt.detail)
+			}
+			if len(got.Errors) != len(tt.fieldErrors) {
+				t.Errorf("Got %d field errors, want %d", len(got.Errors), len(tt.fieldErrors))
 			}
 		})
 	}
 }
 
+func TestWriteFieldError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/pvz", nil)
+	r.Header.Set("X-Request-ID", "from-header")
+	w := httptest.NewRecorder()
+
+	WriteFieldError(w, r, "limit", dto.FieldOutOfRange, "Invalid limit")
+
+	var got dto.Problem
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if got.Code != dto.CodeValidationFailed || w.Code != http.StatusBadRequest {
+		t.Errorf("Unexpected problem %+v with status %d", got, w.Code)
+	}
+	if len(got.Errors) != 1 || got.Errors[0].Field != "limit" || got.Errors[0].Code != dto.FieldOutOfRange {
+		t.Errorf("Unexpected field errors: %+v", got.Errors)
+	}
+	if got.Instance != "from-header" {
+		t.Errorf("Instance = %q, want request ID from header", got.Instance)
+	}
+}
+
 func TestWriteJSON(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -110,7 +162,9 @@ func TestWriteJSON(t *testing.T) {
 		},
 		{
 			name: "Error response",
-			data: dto.ErrorResponse{
+			data: struct {
+				Message string `json:"message"`
+			}{
 				Message: "error occurred",
 			},
 			status:     http.StatusBadRequest,
@@ -0,0 +1,22 @@
+package validation
+
+import "github.com/kosttiik/pvz-service/internal/models"
+
+// Перечисления из моделей, доступные в правиле enum
+func init() {
+	RegisterEnum("role", keys(models.ValidRoles)...)
+	RegisterEnum("city", keys(models.AllowedCities)...)
+	RegisterEnum("productType", keys(models.ValidProduct)...)
+	RegisterEnum("receptionStatus", keys(models.ValidReceptionStatuses)...)
+	RegisterEnum("webhookEvent", keys(models.WebhookEventTypes)...)
+}
+
+func keys[K ~string](m map[K]bool) []string {
+	values := make([]string, 0, len(m))
+	for key, ok := range m {
+		if ok {
+			values = append(values, string(key))
+		}
+	}
+	return values
+}
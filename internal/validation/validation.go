@@ -0,0 +1,239 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/dto"
+)
+
+// Правила задаются тегом validate через запятую:
+//
+//	required     - значение не пустое (для слайсов - хотя бы один элемент)
+//	omitempty    - пустое значение не проверяется остальными правилами
+//	email        - адрес вида user@example.com
+//	password     - политика паролей: от 8 символов, буквы и цифры, не длиннее 72 байт (ограничение bcrypt)
+//	uuid         - строка в формате UUID
+//	enum=<name>  - значение из перечисления, зарегистрированного через RegisterEnum
+//	min=<n>      - минимальная длина строки в символах или число элементов слайса
+//	max=<n>      - максимальная длина строки в символах или число элементов слайса
+//	<name>       - правило Rule, переданное в Struct, например проверка, зависящая от конфигурации
+//
+// Для слайсов email, uuid и enum применяются к каждому элементу, ошибка получает имя поля вида eventTypes[1].
+// Имя поля в ошибке берется из json тега, поэтому совпадает с тем, что прислал клиент.
+// Пакет не зависит от транспорта: ошибки одинаково отдаются в HTTP и могут быть переведены в статус gRPC
+
+const (
+	minPasswordLength = 8
+	maxPasswordBytes  = 72
+)
+
+// Errors - все ошибки полей, найденные при проверке
+type Errors []dto.FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fieldErr := range e {
+		parts[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+var (
+	enumsMu sync.RWMutex
+	enums   = make(map[string]map[string]bool)
+)
+
+// Rule - правило для строк, которое зависит от настроек вызывающего и поэтому
+// не регистрируется глобально. Текст ошибки Check становится сообщением об ошибке поля
+type Rule struct {
+	Name  string
+	Check func(value string) error
+}
+
+// RegisterEnum регистрирует допустимые значения для правила enum=<name>
+func RegisterEnum(name string, values ...string) {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+
+	enumsMu.Lock()
+	defer enumsMu.Unlock()
+	enums[name] = set
+}
+
+func enumContains(name, value string) bool {
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+	set, ok := enums[name]
+	if !ok {
+		panic(fmt.Sprintf("validation: enum %q is not registered", name))
+	}
+	return set[value]
+}
+
+// Struct проверяет поля структуры по тегам validate и возвращает Errors со всеми ошибками.
+// rules дополняют встроенные правила на время одного вызова
+func Struct(v any, rules ...Rule) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: expected struct, got %T", v))
+	}
+
+	custom := make(map[string]func(string) error, len(rules))
+	for _, rule := range rules {
+		custom[rule.Name] = rule.Check
+	}
+
+	var errs Errors
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		rules, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		errs = append(errs, check(fieldName(field), value.Field(i), rules, custom)...)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Var проверяет отдельное значение, например параметр пути или запроса
+func Var(field string, value any, rules string) error {
+	if errs := check(field, reflect.ValueOf(value), rules, nil); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func check(field string, value reflect.Value, rules string, custom map[string]func(string) error) Errors {
+	if !value.IsValid() || value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+		if strings.Contains(","+rules+",", ",required,") {
+			return Errors{{Field: field, Code: dto.FieldRequired, Message: field + " is required"}}
+		}
+		return nil
+	}
+
+	var errs Errors
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "", "required", "omitempty":
+		case "min", "max":
+			if fieldErr, failed := checkLength(field, value, name, param); failed {
+				errs = append(errs, fieldErr)
+			}
+		case "email", "password", "uuid", "enum":
+			if value.Kind() == reflect.Slice {
+				for i := 0; i < value.Len(); i++ {
+					item := fmt.Sprintf("%s[%d]", field, i)
+					if fieldErr, failed := checkString(item, value.Index(i).String(), name, param); failed {
+						errs = append(errs, fieldErr)
+					}
+				}
+				continue
+			}
+			if fieldErr, failed := checkString(field, value.String(), name, param); failed {
+				errs = append(errs, fieldErr)
+			}
+		default:
+			checkCustom, ok := custom[name]
+			if !ok {
+				panic(fmt.Sprintf("validation: unknown rule %q", rule))
+			}
+			if err := checkCustom(value.String()); err != nil {
+				errs = append(errs, dto.FieldError{Field: field, Code: dto.FieldInvalid, Message: err.Error()})
+			}
+		}
+	}
+	return errs
+}
+
+func checkLength(field string, value reflect.Value, rule, param string) (dto.FieldError, bool) {
+	limit, err := strconv.Atoi(param)
+	if err != nil {
+		panic(fmt.Sprintf("validation: invalid %s parameter %q", rule, param))
+	}
+
+	length := value.Len()
+	if value.Kind() == reflect.String {
+		length = utf8.RuneCountInString(value.String())
+	}
+
+	if rule == "min" && length < limit {
+		return dto.FieldError{Field: field, Code: dto.FieldTooShort, Message: fmt.Sprintf("%s must be at least %d characters", field, limit)}, true
+	}
+	if rule == "max" && length > limit {
+		return dto.FieldError{Field: field, Code: dto.FieldTooLong, Message: fmt.Sprintf("%s must be at most %d characters", field, limit)}, true
+	}
+	return dto.FieldError{}, false
+}
+
+func checkString(field, value, rule, param string) (dto.FieldError, bool) {
+	switch rule {
+	case "email":
+		if !isEmail(value) {
+			return dto.FieldError{Field: field, Code: dto.FieldInvalidFormat, Message: field + " must be a valid email address"}, true
+		}
+	case "password":
+		if !isStrongPassword(value) {
+			return dto.FieldError{Field: field, Code: dto.FieldWeakPassword, Message: fmt.Sprintf(
+				"%s must be %d to %d bytes long and contain letters and digits", field, minPasswordLength, maxPasswordBytes)}, true
+		}
+	case "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			return dto.FieldError{Field: field, Code: dto.FieldInvalidFormat, Message: field + " must be a valid UUID"}, true
+		}
+	case "enum":
+		if !enumContains(param, value) {
+			return dto.FieldError{Field: field, Code: dto.FieldNotAllowed, Message: field + " has unsupported value " + strconv.Quote(value)}, true
+		}
+	}
+	return dto.FieldError{}, false
+}
+
+func isEmail(value string) bool {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value {
+		return false
+	}
+	_, domain, _ := strings.Cut(value, "@")
+	return strings.Contains(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
+func isStrongPassword(value string) bool {
+	if utf8.RuneCountInString(value) < minPasswordLength || len(value) > maxPasswordBytes {
+		return false
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range value {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	return hasLetter && hasDigit
+}
@@ -0,0 +1,177 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/dto"
+	"github.com/kosttiik/pvz-service/internal/models"
+)
+
+type testRequest struct {
+	Email    string             `json:"email" validate:"required,email"`
+	Password string             `json:"password" validate:"required,password"`
+	City     string             `json:"city" validate:"required,enum=city"`
+	PvzID    string             `json:"pvzId" validate:"omitempty,uuid"`
+	Events   []models.EventType `json:"events" validate:"required,enum=webhookEvent"`
+	Secret   string             `json:"secret" validate:"omitempty,min=16,max=64"`
+	Ignored  string
+}
+
+func validRequest() testRequest {
+	return testRequest{
+		Email:    "user@example.com",
+		Password: "password123",
+		City:     "Казань",
+		PvzID:    uuid.NewString(),
+		Events:   []models.EventType{models.EventProductAdded},
+	}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*testRequest)
+		want   map[string]string
+	}{
+		{"Valid", func(*testRequest) {}, nil},
+		{"Optional fields empty", func(r *testRequest) { r.PvzID = "" }, nil},
+		{
+			name: "All required missing",
+			modify: func(r *testRequest) {
+				*r = testRequest{}
+			},
+			want: map[string]string{
+				"email":    dto.FieldRequired,
+				"password": dto.FieldRequired,
+				"city":     dto.FieldRequired,
+				"events":   dto.FieldRequired,
+			},
+		},
+		{
+			name: "Every rule fails",
+			modify: func(r *testRequest) {
+				r.Email = "not-an-email"
+				r.Password = "short1"
+				r.City = "Новосибирск"
+				r.PvzID = "123"
+				r.Events = []models.EventType{models.EventProductAdded, "pvz.exploded"}
+				r.Secret = "tiny"
+			},
+			want: map[string]string{
+				"email":     dto.FieldInvalidFormat,
+				"password":  dto.FieldWeakPassword,
+				"city":      dto.FieldNotAllowed,
+				"pvzId":     dto.FieldInvalidFormat,
+				"events[1]": dto.FieldNotAllowed,
+				"secret":    dto.FieldTooShort,
+			},
+		},
+		{"Password without digits", func(r *testRequest) { r.Password = "onlyletters" }, map[string]string{"password": dto.FieldWeakPassword}},
+		{"Password too long for bcrypt", func(r *testRequest) { r.Password = "a1" + string(make([]byte, 80)) }, map[string]string{"password": dto.FieldWeakPassword}},
+		{"Email with display name", func(r *testRequest) { r.Email = "User <user@example.com>" }, map[string]string{"email": dto.FieldInvalidFormat}},
+		{"Email without domain zone", func(r *testRequest) { r.Email = "user@localhost" }, map[string]string{"email": dto.FieldInvalidFormat}},
+		{"Secret too long", func(r *testRequest) { r.Secret = string(make([]rune, 65)) }, map[string]string{"secret": dto.FieldTooLong}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			tt.modify(&req)
+
+			err := Struct(&req)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Struct() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Struct() error = %v, want Errors", err)
+			}
+
+			got := make(map[string]string)
+			for _, fieldErr := range errs {
+				got[fieldErr.Field] = fieldErr.Code
+				if fieldErr.Message == "" {
+					t.Errorf("Field %s has empty message", fieldErr.Field)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("Got errors %v, want %v", got, tt.want)
+			}
+			for field, code := range tt.want {
+				if got[field] != code {
+					t.Errorf("Field %s code = %q, want %q", field, got[field], code)
+				}
+			}
+		})
+	}
+}
+
+func TestDTOs(t *testing.T) {
+	if err := Struct(dto.RegisterRequest{Email: "a@b.ru", Password: "password123", Role: "employee"}); err != nil {
+		t.Errorf("Valid RegisterRequest rejected: %v", err)
+	}
+	if err := Struct(dto.AddProductRequest{Type: "обувь", PvzID: uuid.NewString()}); err != nil {
+		t.Errorf("Valid AddProductRequest rejected: %v", err)
+	}
+
+	err := Struct(dto.RegisterRequest{Email: "a@b", Password: "123", Role: "admin"})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Errorf("Expected 3 field errors, got %v", err)
+	}
+}
+
+func TestVar(t *testing.T) {
+	if err := Var("pvzId", uuid.NewString(), "required,uuid"); err != nil {
+		t.Errorf("Var() error = %v", err)
+	}
+
+	err := Var("pvzId", "", "required,uuid")
+	var errs Errors
+	if !errors.As(err, &errs) || errs[0].Code != dto.FieldRequired {
+		t.Errorf("Var() error = %v, want required", err)
+	}
+}
+
+func TestCustomRule(t *testing.T) {
+	type request struct {
+		Name  string `json:"name" validate:"required,palindrome"`
+		Email string `json:"email" validate:"required,email"`
+	}
+	palindrome := Rule{Name: "palindrome", Check: func(value string) error {
+		for i := 0; i < len(value)/2; i++ {
+			if value[i] != value[len(value)-1-i] {
+				return errors.New("name must be a palindrome")
+			}
+		}
+		return nil
+	}}
+
+	if err := Struct(request{Name: "abba", Email: "a@b.ru"}, palindrome); err != nil {
+		t.Errorf("Struct() error = %v", err)
+	}
+
+	err := Struct(request{Name: "abc", Email: "a@b"}, palindrome)
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Expected 2 field errors, got %v", err)
+	}
+	if errs[0].Field != "name" || errs[0].Code != dto.FieldInvalid || errs[0].Message != "name must be a palindrome" {
+		t.Errorf("Unexpected custom rule error: %+v", errs[0])
+	}
+}
+
+func TestUnknownRulePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on unknown rule")
+		}
+	}()
+	Var("field", "value", "palindrome")
+}
@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// newClient создает http клиент доставок. Вне тестового режима адрес проверяется
+// при каждом подключении, уже после резолва имени: так не проходят имена,
+// указывающие на внутренние адреса, и подмена DNS после проверки подписки
+func newClient(config Config) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout, KeepAlive: 30 * time.Second}
+	if !config.TestMode {
+		dialer.Control = checkDialAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Через прокси подключение шло бы к прокси, и проверка адреса потеряла бы смысл
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: config.Timeout, Transport: transport}
+}
+
+func checkDialAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid webhook address %q: %w", address, err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || forbiddenIP(ip) {
+		return fmt.Errorf("webhook url must not point to a private address")
+	}
+	return nil
+}
+
+// forbiddenIP - адреса, на которые вебхуки не отправляются: loopback, частные
+// сети, link-local (включая метаданные облака 169.254.169.254) и неопределенный адрес
+func forbiddenIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
@@ -0,0 +1,278 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/outbox"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Store - хранилище доставок, которое использует Dispatcher
+type Store interface {
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]repository.WebhookTask, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID, responseStatus int) error
+	MarkFailed(ctx context.Context, id uuid.UUID, responseStatus *int, lastErr string, retryAfter time.Duration) error
+	MarkDead(ctx context.Context, id uuid.UUID, responseStatus *int, lastErr string) error
+	RecordDelivery(ctx context.Context, d *models.WebhookDelivery) error
+}
+
+type Config struct {
+	PollInterval time.Duration `yaml:"pollInterval"`
+	BatchSize    int           `yaml:"batchSize"`
+	Lease        time.Duration `yaml:"lease"`
+	MaxAttempts  int           `yaml:"maxAttempts"`
+	BaseBackoff  time.Duration `yaml:"baseBackoff"`
+	MaxBackoff   time.Duration `yaml:"maxBackoff"`
+	Timeout      time.Duration `yaml:"timeout"`
+	// TestMode разрешает http:// и локальные адреса, например httptest.Server
+	TestMode bool `yaml:"testMode"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Second,
+		BatchSize:    50,
+		Lease:        time.Minute,
+		MaxAttempts:  8,
+		BaseBackoff:  5 * time.Second,
+		MaxBackoff:   time.Hour,
+		Timeout:      10 * time.Second,
+	}
+}
+
+// ValidateURL проверяет адрес подписки. Вне тестового режима принимаются
+// только https-адреса, не указывающие на localhost. Имена, которые резолвятся
+// во внутренние адреса, отсекаются при подключении (см. newClient)
+func ValidateURL(raw string, testMode bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("invalid webhook url")
+	}
+
+	if testMode {
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("webhook url must use http or https")
+		}
+		return nil
+	}
+
+	if u.Scheme != "https" {
+		return fmt.Errorf("webhook url must use https")
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return fmt.Errorf("webhook url must not point to localhost")
+	}
+	if ip := net.ParseIP(host); ip != nil && forbiddenIP(ip) {
+		return fmt.Errorf("webhook url must not point to a private address")
+	}
+
+	return nil
+}
+
+// Dispatcher отправляет поставленные в очередь вебхуки подписчикам
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	config Config
+}
+
+func NewDispatcher(store Store, config Config) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: newClient(config),
+		config: config,
+	}
+}
+
+// WithClient подменяет http клиент, например на клиент httptest.Server
+func (d *Dispatcher) WithClient(client *http.Client) *Dispatcher {
+	d.client = client
+	return d
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	log.Info("Webhook dispatcher started",
+		zap.Duration("pollInterval", d.config.PollInterval),
+		zap.Bool("testMode", d.config.TestMode))
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := d.ProcessBatch(ctx)
+			if err != nil {
+				log.Error("Failed to process webhook batch", zap.Error(err))
+				break
+			}
+			if n < d.config.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) ProcessBatch(ctx context.Context) (int, error) {
+	tasks, err := d.store.ClaimDeliveries(ctx, d.config.BatchSize, d.config.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, task := range tasks {
+		d.deliver(ctx, task)
+	}
+
+	return len(tasks), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, task repository.WebhookTask) {
+	log := logger.FromContext(ctx)
+	delivery := task.Delivery
+
+	status, sendErr := d.Send(ctx, task.Subscription, delivery)
+	if sendErr == nil {
+		if err := d.store.MarkDelivered(ctx, delivery.ID, status); err != nil {
+			log.Error("Failed to mark webhook delivered",
+				zap.String("id", delivery.ID.String()),
+				zap.Error(err))
+		}
+		return
+	}
+
+	var responseStatus *int
+	if status != 0 {
+		responseStatus = &status
+	}
+
+	attempt := delivery.Attempts + 1
+	if attempt >= d.config.MaxAttempts {
+		log.Error("Webhook delivery exhausted retries",
+			zap.String("id", delivery.ID.String()),
+			zap.String("subscriptionId", delivery.SubscriptionID.String()),
+			zap.Int("attempts", attempt),
+			zap.Error(sendErr))
+
+		if err := d.store.MarkDead(ctx, delivery.ID, responseStatus, sendErr.Error()); err != nil {
+			log.Error("Failed to mark webhook dead",
+				zap.String("id", delivery.ID.String()),
+				zap.Error(err))
+		}
+		return
+	}
+
+	retryAfter := outbox.Backoff(attempt, d.config.BaseBackoff, d.config.MaxBackoff)
+	log.Warn("Webhook delivery failed, will retry",
+		zap.String("id", delivery.ID.String()),
+		zap.String("subscriptionId", delivery.SubscriptionID.String()),
+		zap.Int("attempt", attempt),
+		zap.Duration("retryAfter", retryAfter),
+		zap.Error(sendErr))
+
+	if err := d.store.MarkFailed(ctx, delivery.ID, responseStatus, sendErr.Error(), retryAfter); err != nil {
+		log.Error("Failed to mark webhook failed",
+			zap.String("id", delivery.ID.String()),
+			zap.Error(err))
+	}
+}
+
+// Send выполняет одну попытку доставки и возвращает код ответа подписчика.
+// Успехом считается любой 2xx
+func (d *Dispatcher) Send(ctx context.Context, sub models.WebhookSubscription, delivery models.WebhookDelivery) (int, error) {
+	if err := ValidateURL(sub.URL, d.config.TestMode); err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pvz-service-webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SendTest синхронно отправляет подписчику тестовое событие и пишет результат в журнал доставок
+func (d *Dispatcher) SendTest(ctx context.Context, sub models.WebhookSubscription) (*models.WebhookDelivery, error) {
+	event := models.Event{
+		ID:          uuid.New(),
+		Type:        models.EventWebhookTest,
+		AggregateID: sub.ID,
+		Payload:     []byte(`{}`),
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	payload, err := NewPayload(event)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &models.WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: sub.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        payload,
+		Attempts:       1,
+		CreatedAt:      event.CreatedAt,
+	}
+
+	status, sendErr := d.Send(ctx, sub, *delivery)
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+	if sendErr != nil {
+		msg := sendErr.Error()
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = &msg
+	} else {
+		now := time.Now().UTC()
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &now
+	}
+
+	if err := d.store.RecordDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,314 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+	"github.com/kosttiik/pvz-service/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	logger.Close()
+	os.Exit(code)
+}
+
+type fakeStore struct {
+	mu        sync.Mutex
+	tasks     []repository.WebhookTask
+	delivered map[uuid.UUID]int
+	failed    map[uuid.UUID]time.Duration
+	dead      map[uuid.UUID]bool
+	recorded  []models.WebhookDelivery
+}
+
+func newFakeStore(tasks ...repository.WebhookTask) *fakeStore {
+	return &fakeStore{
+		tasks:     tasks,
+		delivered: make(map[uuid.UUID]int),
+		failed:    make(map[uuid.UUID]time.Duration),
+		dead:      make(map[uuid.UUID]bool),
+	}
+}
+
+func (s *fakeStore) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]repository.WebhookTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	claimed := s.tasks
+	s.tasks = nil
+	return claimed, nil
+}
+
+func (s *fakeStore) MarkDelivered(ctx context.Context, id uuid.UUID, responseStatus int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delivered[id] = responseStatus
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(ctx context.Context, id uuid.UUID, responseStatus *int, lastErr string, retryAfter time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed[id] = retryAfter
+	return nil
+}
+
+func (s *fakeStore) MarkDead(ctx context.Context, id uuid.UUID, responseStatus *int, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dead[id] = true
+	return nil
+}
+
+func (s *fakeStore) RecordDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recorded = append(s.recorded, *d)
+	return nil
+}
+
+func testConfig() Config {
+	config := DefaultConfig()
+	config.TestMode = true
+	config.MaxAttempts = 3
+	return config
+}
+
+func newTask(url string, attempts int) repository.WebhookTask {
+	event := models.Event{
+		ID:          uuid.New(),
+		Type:        models.EventProductAdded,
+		AggregateID: uuid.New(),
+		PvzID:       uuid.New().String(),
+		Payload:     []byte(`{"type":"электроника"}`),
+		CreatedAt:   time.Now(),
+	}
+	payload, _ := NewPayload(event)
+
+	sub := models.WebhookSubscription{
+		ID:         uuid.New(),
+		URL:        url,
+		EventTypes: []models.EventType{models.EventProductAdded},
+		Secret:     "super-secret-value",
+	}
+
+	return repository.WebhookTask{
+		Delivery: models.WebhookDelivery{
+			ID:             uuid.New(),
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         models.DeliveryPending,
+			Attempts:       attempts,
+		},
+		Subscription: sub,
+	}
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	var gotBody []byte
+	var gotHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeader = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	task := newTask(server.URL, 0)
+	store := newFakeStore(task)
+
+	if _, err := NewDispatcher(store, testConfig()).ProcessBatch(context.Background()); err != nil {
+		t.Fatalf("ProcessBatch() error = %v", err)
+	}
+
+	if status, ok := store.delivered[task.Delivery.ID]; !ok || status != http.StatusOK {
+		t.Fatalf("Delivery should be marked delivered with 200, got %v", store.delivered)
+	}
+
+	if got := gotHeader.Get(HeaderEvent); got != string(models.EventProductAdded) {
+		t.Errorf("%s = %q, want %q", HeaderEvent, got, models.EventProductAdded)
+	}
+
+	timestamp, err := strconv.ParseInt(gotHeader.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("Invalid timestamp header: %v", err)
+	}
+	if !Verify(task.Subscription.Secret, timestamp, gotBody, gotHeader.Get(HeaderSignature)) {
+		t.Error("Signature does not match payload")
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := testConfig()
+
+	t.Run("Retry with backoff", func(t *testing.T) {
+		task := newTask(server.URL, 1)
+		store := newFakeStore(task)
+
+		if _, err := NewDispatcher(store, config).ProcessBatch(context.Background()); err != nil {
+			t.Fatalf("ProcessBatch() error = %v", err)
+		}
+
+		retryAfter, ok := store.failed[task.Delivery.ID]
+		if !ok {
+			t.Fatal("Delivery should be marked failed")
+		}
+		if want := 2 * config.BaseBackoff; retryAfter != want {
+			t.Errorf("retryAfter = %v, want %v", retryAfter, want)
+		}
+	})
+
+	t.Run("Dead after max attempts", func(t *testing.T) {
+		task := newTask(server.URL, config.MaxAttempts-1)
+		store := newFakeStore(task)
+
+		if _, err := NewDispatcher(store, config).ProcessBatch(context.Background()); err != nil {
+			t.Fatalf("ProcessBatch() error = %v", err)
+		}
+
+		if !store.dead[task.Delivery.ID] {
+			t.Error("Delivery should be marked dead")
+		}
+	})
+}
+
+func TestDispatcherSendTest(t *testing.T) {
+	var gotEvent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEvent = r.Header.Get(HeaderEvent)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := newFakeStore()
+	sub := newTask(server.URL, 0).Subscription
+
+	delivery, err := NewDispatcher(store, testConfig()).SendTest(context.Background(), sub)
+	if err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+
+	if delivery.Status != models.DeliveryDelivered {
+		t.Errorf("Status = %v, want %v", delivery.Status, models.DeliveryDelivered)
+	}
+	if gotEvent != string(models.EventWebhookTest) {
+		t.Errorf("Got event %q, want %q", gotEvent, models.EventWebhookTest)
+	}
+	if len(store.recorded) != 1 {
+		t.Errorf("Test delivery should be recorded in log, got %d", len(store.recorded))
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		testMode bool
+		wantErr  bool
+	}{
+		{"Https", "https://partner.example.com/hooks", false, false},
+		{"Http outside test mode", "http://partner.example.com/hooks", false, true},
+		{"Localhost outside test mode", "https://localhost/hooks", false, true},
+		{"Private IP outside test mode", "https://10.0.0.1/hooks", false, true},
+		{"Link-local IP outside test mode", "https://169.254.169.254/latest/meta-data", false, true},
+		{"Http in test mode", "http://127.0.0.1:8081/hooks", true, false},
+		{"Relative url", "/hooks", true, true},
+		{"Unsupported scheme", "ftp://example.com", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateURL(tt.url, tt.testMode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDispatcherRejectsPrivateAddressOnConnect(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	config := testConfig()
+	config.TestMode = false
+
+	// Адрес сервера - 127.0.0.1, как у имени, которое резолвится в loopback
+	resp, err := newClient(config).Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("Request to loopback address should fail")
+	}
+	if called {
+		t.Error("Server should not receive the request")
+	}
+}
+
+func TestCheckDialAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+		{"127.0.0.1:443", true},
+		{"10.1.2.3:443", true},
+		{"172.16.0.1:443", true},
+		{"192.168.1.1:443", true},
+		{"169.254.169.254:80", true},
+		{"0.0.0.0:443", true},
+		{"[::1]:443", true},
+		{"[fe80::1]:443", true},
+		{"[fd00::1]:443", true},
+		{"[::ffff:127.0.0.1]:443", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := checkDialAddress("tcp", tt.address, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkDialAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	signature := Sign("secret", 1700000000, body)
+
+	if !Verify("secret", 1700000000, body, signature) {
+		t.Error("Verify() should accept own signature")
+	}
+	if Verify("other", 1700000000, body, signature) {
+		t.Error("Verify() should reject signature with another secret")
+	}
+	if Verify("secret", 1700000001, body, signature) {
+		t.Error("Verify() should reject signature with another timestamp")
+	}
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Заголовки исходящего вебхука
+const (
+	HeaderSignature = "X-PVZ-Signature"
+	HeaderTimestamp = "X-PVZ-Timestamp"
+	HeaderEvent     = "X-PVZ-Event"
+	HeaderDelivery  = "X-PVZ-Delivery"
+
+	signaturePrefix = "sha256="
+)
+
+// Sign считает HMAC-SHA256 от "<timestamp>.<body>", чтобы получатель
+// мог проверить и подлинность, и свежесть запроса
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись за постоянное время
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	expected := Sign(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
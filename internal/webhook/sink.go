@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kosttiik/pvz-service/internal/models"
+	"github.com/kosttiik/pvz-service/internal/repository"
+)
+
+// Тело запроса, которое получает подписчик
+type Payload struct {
+	ID        uuid.UUID        `json:"id"`
+	Type      models.EventType `json:"type"`
+	PvzID     string           `json:"pvzId"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Data      json.RawMessage  `json:"data"`
+}
+
+func NewPayload(event models.Event) ([]byte, error) {
+	data, err := json.Marshal(Payload{
+		ID:        event.ID,
+		Type:      event.Type,
+		PvzID:     event.PvzID,
+		CreatedAt: event.CreatedAt.UTC(),
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	return data, nil
+}
+
+// Sink принимает события из outbox и ставит их в очередь доставки подписчикам
+type Sink struct {
+	repo *repository.WebhookRepository
+}
+
+func NewSink(repo *repository.WebhookRepository) *Sink {
+	return &Sink{repo: repo}
+}
+
+func (s *Sink) Publish(ctx context.Context, event models.Event) error {
+	if !models.WebhookEventTypes[event.Type] {
+		return nil
+	}
+
+	subs, err := s.repo.FindByEventType(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	payload, err := NewPayload(event)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.EnqueueDeliveries(ctx, event, payload, subs)
+}
@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	pvzListPrefix = "pvz:list:"
+	// Хэш всех диапазонов дат, по которым есть страницы: id -> "start|end|lastUsed"
+	pvzListRangesKey = pvzListPrefix + "ranges"
+)
+
+// Удаляет диапазон, только если его не успели использовать заново
+var forgetRangeScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) == ARGV[2] then
+	return redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// Outcome - чем закончилось обращение к кэшу
+type Outcome string
+
+const (
+	OutcomeHit  Outcome = "hit"
+	OutcomeMiss Outcome = "miss"
+	// OutcomeShared - промах, страницу загрузил параллельный запрос
+	OutcomeShared Outcome = "shared"
+	// OutcomeBypass - Redis недоступен, страница загружена мимо кэша
+	OutcomeBypass Outcome = "bypass"
+)
+
+// PVZListConfig - настройки кэша списка ПВЗ
+type PVZListConfig struct {
+	// TTL - время жизни страницы, 0 отключает кэш. Страницы сбрасываются событиями,
+	// TTL только ограничивает память и время жизни страницы, если событие потерялось
+	TTL time.Duration `yaml:"ttl"`
+}
+
+func DefaultPVZListConfig() PVZListConfig {
+	return PVZListConfig{TTL: 5 * time.Minute}
+}
+
+// DateRange - диапазон дат приемок из фильтра списка ПВЗ, nil - граница не задана
+type DateRange struct {
+	Start *time.Time
+	End   *time.Time
+}
+
+// Bounded сообщает, задана ли хотя бы одна граница. В список без границ попадают все ПВЗ
+func (r DateRange) Bounded() bool {
+	return r.Start != nil || r.End != nil
+}
+
+// Contains проверяет дату так же, как фильтр в запросе: границы включаются
+func (r DateRange) Contains(t time.Time) bool {
+	if r.Start != nil && t.Before(*r.Start) {
+		return false
+	}
+	if r.End != nil && t.After(*r.End) {
+		return false
+	}
+	return true
+}
+
+// encode не зависит от часового пояса, поэтому одинаковые моменты дают один диапазон
+func (r DateRange) encode() string {
+	return encodeBound(r.Start) + "|" + encodeBound(r.End)
+}
+
+func (r DateRange) id() string {
+	hash := sha256.Sum256([]byte(r.encode()))
+	return hex.EncodeToString(hash[:12])
+}
+
+func encodeBound(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func decodeBound(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	nanos, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	t := time.Unix(0, nanos).UTC()
+	return &t, nil
+}
+
+// decodeRangeEntry разбирает значение из хэша диапазонов
+func decodeRangeEntry(value string) (DateRange, time.Time, error) {
+	parts := strings.Split(value, "|")
+	if len(parts) != 3 {
+		return DateRange{}, time.Time{}, fmt.Errorf("malformed range %q", value)
+	}
+
+	start, err := decodeBound(parts[0])
+	if err != nil {
+		return DateRange{}, time.Time{}, err
+	}
+	end, err := decodeBound(parts[1])
+	if err != nil {
+		return DateRange{}, time.Time{}, err
+	}
+	lastUsed, err := decodeBound(parts[2])
+	if err != nil || lastUsed == nil {
+		return DateRange{}, time.Time{}, fmt.Errorf("malformed range %q", value)
+	}
+
+	return DateRange{Start: start, End: end}, *lastUsed, nil
+}
+
+// PVZListCache - read-through кэш страниц GET /pvz. Страницы одного диапазона дат
+// делят счетчик поколения, и инвалидация просто увеличивает его. Страницы старого
+// поколения, в том числе дописанные запросами, начавшимися до изменения, больше
+// не читаются и истекают по TTL
+type PVZListCache struct {
+	redis  *redis.Client
+	ttl    time.Duration
+	flight singleflight.Group
+	now    func() time.Time
+}
+
+func NewPVZListCache(redisClient *redis.Client, config PVZListConfig) *PVZListCache {
+	if redisClient == nil {
+		panic("redis client cannot be nil")
+	}
+	return &PVZListCache{redis: redisClient, ttl: config.TTL, now: time.Now}
+}
+
+type pvzListFetch struct {
+	data    []byte
+	outcome Outcome
+}
+
+// Get возвращает страницу из кэша или загружает ее через load и кладет в кэш.
+// Одновременные промахи по одной странице загружаются один раз. Ошибки Redis
+// не ломают запрос: страница загружается напрямую с OutcomeBypass
+func (c *PVZListCache) Get(ctx context.Context, rng DateRange, page, limit int, load func(ctx context.Context) ([]byte, error)) ([]byte, Outcome, error) {
+	rangeID := rng.id()
+
+	var leader bool
+	result, err, _ := c.flight.Do(fmt.Sprintf("%s:%d:%d", rangeID, page, limit), func() (any, error) {
+		leader = true
+		// Загрузку делят несколько запросов, отмена первого не должна ломать остальные
+		return c.fetch(context.WithoutCancel(ctx), rng, rangeID, page, limit, load)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	fetched := result.(pvzListFetch)
+	if !leader && fetched.outcome != OutcomeHit {
+		return fetched.data, OutcomeShared, nil
+	}
+	return fetched.data, fetched.outcome, nil
+}
+
+func (c *PVZListCache) fetch(ctx context.Context, rng DateRange, rangeID string, page, limit int, load func(ctx context.Context) ([]byte, error)) (pvzListFetch, error) {
+	log := logger.FromContext(ctx)
+
+	// Диапазон регистрируется до чтения поколения: тогда событие, пришедшее во время
+	// загрузки, увидит диапазон и сменит поколение, и устаревшая страница не прочитается
+	pipe := c.redis.Pipeline()
+	pipe.HSet(ctx, pvzListRangesKey, rangeID, rng.encode()+"|"+strconv.FormatInt(c.now().UnixNano(), 10))
+	genCmd := pipe.Get(ctx, pvzListGenKey(rangeID))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		log.Warn("PVZ list cache unavailable", zap.Error(err))
+		return c.bypass(ctx, load)
+	}
+
+	gen := genCmd.Val()
+	if gen == "" {
+		gen = "0"
+	}
+	key := fmt.Sprintf("%s%s:%s:%d:%d", pvzListPrefix, rangeID, gen, page, limit)
+
+	data, err := c.redis.Get(ctx, key).Bytes()
+	if err == nil {
+		return pvzListFetch{data: data, outcome: OutcomeHit}, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		log.Warn("PVZ list cache unavailable", zap.Error(err))
+		return c.bypass(ctx, load)
+	}
+
+	data, err = load(ctx)
+	if err != nil {
+		return pvzListFetch{}, err
+	}
+
+	pipe = c.redis.Pipeline()
+	pipe.Set(ctx, key, data, c.ttl)
+	// Поколение живет дольше страниц, иначе сброс счетчика вернул бы к жизни старые страницы
+	pipe.Expire(ctx, pvzListGenKey(rangeID), 2*c.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Warn("Failed to store PVZ list page", zap.Error(err))
+	}
+
+	return pvzListFetch{data: data, outcome: OutcomeMiss}, nil
+}
+
+func (c *PVZListCache) bypass(ctx context.Context, load func(ctx context.Context) ([]byte, error)) (pvzListFetch, error) {
+	data, err := load(ctx)
+	if err != nil {
+		return pvzListFetch{}, err
+	}
+	return pvzListFetch{data: data, outcome: OutcomeBypass}, nil
+}
+
+// Invalidate сбрасывает страницы всех диапазонов, для которых match вернул true,
+// и возвращает их число. Заодно забывает диапазоны, к которым не обращались дольше TTL
+func (c *PVZListCache) Invalidate(ctx context.Context, match func(DateRange) bool) (int, error) {
+	ranges, err := c.redis.HGetAll(ctx, pvzListRangesKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list cached ranges: %w", err)
+	}
+
+	now := c.now()
+	invalidated := 0
+	pipe := c.redis.Pipeline()
+	for rangeID, value := range ranges {
+		rng, lastUsed, err := decodeRangeEntry(value)
+		switch {
+		case err == nil && match(rng):
+			pipe.Incr(ctx, pvzListGenKey(rangeID))
+			pipe.Expire(ctx, pvzListGenKey(rangeID), 2*c.ttl)
+			invalidated++
+		case err != nil || now.Sub(lastUsed) > c.ttl:
+			forgetRangeScript.Eval(ctx, pipe, []string{pvzListRangesKey}, rangeID, value)
+		}
+	}
+	if pipe.Len() == 0 {
+		return 0, nil
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to invalidate cached ranges: %w", err)
+	}
+	return invalidated, nil
+}
+
+func pvzListGenKey(rangeID string) string {
+	return pvzListPrefix + "gen:" + rangeID
+}
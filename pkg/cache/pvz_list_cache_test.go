@@ -0,0 +1,122 @@
+//go:build integration
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kosttiik/pvz-service/pkg/redis"
+)
+
+func TestPVZListCache(t *testing.T) {
+	ctx := context.Background()
+	if err := redis.Client.Del(ctx, pvzListRangesKey).Err(); err != nil {
+		t.Fatalf("failed to cleanup ranges: %v", err)
+	}
+
+	c := NewPVZListCache(redis.Client, PVZListConfig{TTL: time.Minute})
+
+	start := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	bounded := DateRange{Start: &start, End: &end}
+	// Тот же момент в другом часовом поясе - тот же диапазон
+	moscow := start.In(time.FixedZone("MSK", 3*60*60))
+	sameBounded := DateRange{Start: &moscow, End: &end}
+
+	var loads atomic.Int32
+	load := func(data string) func(ctx context.Context) ([]byte, error) {
+		return func(ctx context.Context) ([]byte, error) {
+			loads.Add(1)
+			time.Sleep(50 * time.Millisecond)
+			return []byte(data), nil
+		}
+	}
+
+	t.Run("Miss then hit", func(t *testing.T) {
+		loads.Store(0)
+		_, outcome, err := c.Get(ctx, bounded, 1, 10, load("v1"))
+		if err != nil || outcome != OutcomeMiss {
+			t.Fatalf("got outcome %q, err %v, want miss", outcome, err)
+		}
+
+		data, outcome, err := c.Get(ctx, sameBounded, 1, 10, load("v2"))
+		if err != nil || outcome != OutcomeHit || string(data) != "v1" {
+			t.Fatalf("got %q with outcome %q, err %v, want cached v1", data, outcome, err)
+		}
+		if loads.Load() != 1 {
+			t.Errorf("got %d loads, want 1", loads.Load())
+		}
+	})
+
+	t.Run("Concurrent misses load once", func(t *testing.T) {
+		loads.Store(0)
+		var wg sync.WaitGroup
+		outcomes := make([]Outcome, 5)
+		for i := range outcomes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, outcomes[i], _ = c.Get(ctx, bounded, 2, 10, load("page2"))
+			}()
+		}
+		wg.Wait()
+
+		if loads.Load() != 1 {
+			t.Errorf("got %d loads, want 1", loads.Load())
+		}
+		misses := 0
+		for _, outcome := range outcomes {
+			if outcome == OutcomeMiss {
+				misses++
+			}
+		}
+		if misses != 1 {
+			t.Errorf("got outcomes %v, want exactly one miss", outcomes)
+		}
+	})
+
+	t.Run("Invalidate matching ranges only", func(t *testing.T) {
+		unbounded := DateRange{}
+		if _, _, err := c.Get(ctx, unbounded, 1, 10, load("all")); err != nil {
+			t.Fatalf("failed to fill unbounded page: %v", err)
+		}
+
+		n, err := c.Invalidate(ctx, func(rng DateRange) bool { return rng.Bounded() })
+		if err != nil {
+			t.Fatalf("failed to invalidate: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("got %d invalidated ranges, want 1", n)
+		}
+
+		data, outcome, _ := c.Get(ctx, bounded, 1, 10, load("v2"))
+		if outcome != OutcomeMiss || string(data) != "v2" {
+			t.Errorf("got %q with outcome %q, want reloaded v2", data, outcome)
+		}
+		_, outcome, _ = c.Get(ctx, unbounded, 1, 10, load("all2"))
+		if outcome != OutcomeHit {
+			t.Errorf("got outcome %q for untouched range, want hit", outcome)
+		}
+	})
+
+	t.Run("Forget unused ranges", func(t *testing.T) {
+		c.now = func() time.Time { return time.Now().Add(time.Hour) }
+		defer func() { c.now = time.Now }()
+
+		if _, err := c.Invalidate(ctx, func(DateRange) bool { return false }); err != nil {
+			t.Fatalf("failed to invalidate: %v", err)
+		}
+
+		ranges, err := redis.Client.HLen(ctx, pvzListRangesKey).Result()
+		if err != nil {
+			t.Fatalf("failed to count ranges: %v", err)
+		}
+		if ranges != 0 {
+			t.Errorf("got %d ranges, want 0", ranges)
+		}
+	})
+}
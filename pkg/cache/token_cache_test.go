@@ -1,3 +1,5 @@
+//go:build integration
+
 package cache
 
 import (
@@ -9,8 +11,7 @@ import (
 )
 
 func TestMain(m *testing.M) {
-	os.Setenv("REDIS_HOST", "localhost")
-	if err := redis.Connect(); err != nil {
+	if err := redis.Connect(redis.DefaultConfig()); err != nil {
 		panic(err)
 	}
 	code := m.Run()
@@ -1,18 +1,16 @@
+//go:build integration
+
 package database
 
 import (
-	"os"
 	"testing"
 )
 
 func TestConnect(t *testing.T) {
-	os.Setenv("DB_HOST", "localhost")
-	os.Setenv("DB_PORT", "5432")
-	os.Setenv("DB_USER", "postgres")
-	os.Setenv("DB_PASSWORD", "postgres")
-	os.Setenv("DB_NAME", "pvz_db")
+	config := DefaultConfig()
+	config.Password = "postgres"
 
-	if err := Connect(); err != nil {
+	if err := Connect(config); err != nil {
 		t.Errorf("Connect() error = %v", err)
 	}
 
@@ -20,8 +18,24 @@ func TestConnect(t *testing.T) {
 		t.Error("DB should not be nil after successful connection")
 	}
 
-	os.Setenv("DB_PORT", "1234")
-	if err := Connect(); err == nil {
+	config.Port = 1234
+	if err := Connect(config); err == nil {
 		t.Error("Connect() should fail with invalid port")
 	}
 }
+
+func TestConfigDSN(t *testing.T) {
+	config := Config{
+		Host:     "db",
+		Port:     5432,
+		User:     "postgres",
+		Password: "p@ss/word",
+		Name:     "pvz_db",
+		SSLMode:  "disable",
+	}
+
+	want := "postgres://postgres:p%40ss%2Fword@db:5432/pvz_db?sslmode=disable"
+	if got := config.DSN(); got != want {
+		t.Errorf("DSN() = %v, want %v", got, want)
+	}
+}
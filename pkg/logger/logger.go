@@ -1,7 +1,7 @@
 package logger
 
 import (
-	"os"
+	"context"
 	"sync"
 
 	"go.uber.org/zap"
@@ -9,22 +9,16 @@ import (
 )
 
 var (
-	Log  *zap.Logger
-	once sync.Once
+	Log   *zap.Logger
+	once  sync.Once
+	level = zap.NewAtomicLevelAt(zap.InfoLevel)
 )
 
 func Init() error {
 	var err error
 	once.Do(func() {
 		config := zap.NewProductionConfig()
-
-		levelStr := os.Getenv("LOG_LEVEL")
-		if levelStr != "" {
-			level, parseErr := zapcore.ParseLevel(levelStr)
-			if parseErr == nil {
-				config.Level = zap.NewAtomicLevelAt(level)
-			}
-		}
+		config.Level = level
 
 		config.OutputPaths = []string{"stdout"}
 		config.EncoderConfig.TimeKey = "timestamp"
@@ -38,8 +32,37 @@ func Init() error {
 	return err
 }
 
+// SetLevel меняет уровень логирования на лету, в том числе для уже созданных логгеров
+func SetLevel(levelStr string) error {
+	parsed, err := zapcore.ParseLevel(levelStr)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(parsed)
+	return nil
+}
+
 func Close() {
 	if Log != nil {
 		Log.Sync()
 	}
 }
+
+type contextKey struct{}
+
+// WithContext кладет в контекст логгер запроса, обычно с request_id и trace_id
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext возвращает логгер запроса, а вне запроса - глобальный Log.
+// До Init отдает Nop, чтобы код с логированием работал в unit тестах
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return l
+	}
+	if Log == nil {
+		return zap.NewNop()
+	}
+	return Log
+}
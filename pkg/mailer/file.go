@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// File дописывает письма в файл в читаемом виде, без MIME кодирования.
+// Для локальной разработки и тестов: ссылку из письма можно взять прямо из файла
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (f *File) Send(_ context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
+
+// Log пишет в лог только получателя и тему: в теле одноразовые ссылки, а логи
+// читает больше людей, чем почту. Ссылки для разработки удобнее брать у транспорта file
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (Log) Send(ctx context.Context, msg Message) error {
+	logger.FromContext(ctx).Info("Mail sent to log",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.Int("bodySize", len(msg.Body)))
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package mailer отправляет письма сервиса. Транспорт выбирается конфигурацией:
+// SMTP для окружений с почтой, файл или лог без тела писем для локальной разработки и тестов
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+)
+
+const (
+	TransportSMTP = "smtp"
+	TransportFile = "file"
+	TransportLog  = "log"
+)
+
+// Message - текстовое письмо одному получателю
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Config struct {
+	// Transport - smtp, file или log
+	Transport string `yaml:"transport"`
+	// From - адрес отправителя
+	From string     `yaml:"from"`
+	SMTP SMTPConfig `yaml:"smtp"`
+	// File - файл, в который дописываются письма транспорта file
+	File string `yaml:"file"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Transport: TransportLog,
+		From:      "noreply@pvz-service.local",
+		SMTP: SMTPConfig{
+			Port:    587,
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// New создает Mailer выбранного транспорта
+func New(config Config) (Mailer, error) {
+	switch config.Transport {
+	case TransportSMTP:
+		return NewSMTP(config.SMTP, config.From), nil
+	case TransportFile:
+		return NewFile(config.File), nil
+	case TransportLog:
+		return NewLog(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", config.Transport)
+	}
+}
+
+// encode собирает письмо в формате RFC 5322. Тема кодируется по RFC 2047,
+// тело - quoted-printable, чтобы кириллица проходила через любой сервер
+func (m Message) encode(from string, date time.Time) ([]byte, error) {
+	for _, header := range []string{from, m.To, m.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, fmt.Errorf("mail header contains a line break")
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(m.Body, "\n", "\r\n"))); err != nil {
+		return nil, fmt.Errorf("failed to encode mail body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode mail body: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,203 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var testMessage = Message{
+	To:      "user@example.com",
+	Subject: "Подтверждение почты",
+	Body:    "Ссылка: https://example.com/verify?token=abc\nСпасибо",
+}
+
+func TestMessageEncode(t *testing.T) {
+	data, err := testMessage.encode("noreply@example.com", time.Now())
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("failed to parse encoded message: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != testMessage.Subject {
+		t.Errorf("got subject %q, %v, want %q", subject, err, testMessage.Subject)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if got := strings.ReplaceAll(string(body), "\r\n", "\n"); got != testMessage.Body {
+		t.Errorf("got body %q, want %q", got, testMessage.Body)
+	}
+
+	injected := testMessage
+	injected.Subject = "Hello\r\nBcc: victim@example.com"
+	if _, err := injected.encode("noreply@example.com", time.Now()); err == nil {
+		t.Error("expected error for header with line break")
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	mailer, err := New(Config{Transport: TransportFile, File: path})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	for range 2 {
+		if err := mailer.Send(context.Background(), testMessage); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read mail file: %v", err)
+	}
+	if n := strings.Count(string(data), "https://example.com/verify?token=abc"); n != 2 {
+		t.Errorf("got %d messages with link in file, want 2", n)
+	}
+}
+
+func TestNewUnknownTransport(t *testing.T) {
+	if _, err := New(Config{Transport: "pigeon"}); err == nil {
+		t.Error("expected error for unknown transport")
+	}
+}
+
+func TestLogOmitsBody(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	ctx := logger.WithContext(context.Background(), zap.New(core))
+
+	if err := NewLog().Send(ctx, testMessage); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+	for key, value := range entries[0].ContextMap() {
+		if s, ok := value.(string); ok && strings.Contains(s, "token=") {
+			t.Errorf("log field %s leaks the link: %q", key, s)
+		}
+	}
+}
+
+// recordMailer запоминает отправленные письма
+type recordMailer struct {
+	sent chan Message
+}
+
+func (m *recordMailer) Send(_ context.Context, msg Message) error {
+	m.sent <- msg
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	target := &recordMailer{sent: make(chan Message, 3)}
+	queue := NewQueue(target, 2)
+
+	// Без воркера письма копятся в очереди, лишнее отклоняется сразу
+	for range 2 {
+		if err := queue.Send(context.Background(), testMessage); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if err := queue.Send(context.Background(), testMessage); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Send to full queue error = %v, want %v", err, ErrQueueFull)
+	}
+
+	// Остановленный воркер досылает то, что осталось в очереди
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	queue.Run(ctx)
+	if len(target.sent) != 2 {
+		t.Errorf("sent %d messages, want 2", len(target.sent))
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go serveSMTP(t, listener, received)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	config := SMTPConfig{Host: host, Timeout: 5 * time.Second}
+	config.Port, _ = net.LookupPort("tcp", port)
+
+	if err := NewSMTP(config, "noreply@example.com").Send(context.Background(), testMessage); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	select {
+	case data := <-received:
+		if !strings.Contains(data, "To: user@example.com") {
+			t.Errorf("unexpected message data %q", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("smtp server did not receive the message")
+	}
+}
+
+// serveSMTP - минимальный SMTP сервер на одно письмо
+func serveSMTP(t *testing.T, listener net.Listener, received chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+		case "EHLO", "HELO", "MAIL", "RCPT":
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 Go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				t.Errorf("failed to read data: %v", err)
+				return
+			}
+			received <- string(data)
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Unknown command")
+		}
+	}
+}
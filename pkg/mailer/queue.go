@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kosttiik/pvz-service/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultQueueSize - сколько писем ждет отправки, прежде чем Send начнет отказывать
+const DefaultQueueSize = 100
+
+var ErrQueueFull = errors.New("mail queue is full")
+
+// Queue отправляет письма в фоне: Send только ставит письмо в очередь, поэтому
+// время ответа не зависит от почтового сервера и не выдает, ушло ли письмо
+type Queue struct {
+	mailer Mailer
+	queue  chan Message
+}
+
+func NewQueue(mailer Mailer, size int) *Queue {
+	return &Queue{mailer: mailer, queue: make(chan Message, size)}
+}
+
+// Send ставит письмо в очередь и не ждет отправки
+func (q *Queue) Send(_ context.Context, msg Message) error {
+	select {
+	case q.queue <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run отправляет письма до отмены ctx. Письма, оставшиеся в очереди, отправляются перед выходом
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		select {
+		case msg := <-q.queue:
+			q.send(ctx, msg)
+		case <-ctx.Done():
+			q.drain(context.WithoutCancel(ctx))
+			return
+		}
+	}
+}
+
+func (q *Queue) drain(ctx context.Context) {
+	for {
+		select {
+		case msg := <-q.queue:
+			q.send(ctx, msg)
+		default:
+			return
+		}
+	}
+}
+
+func (q *Queue) send(ctx context.Context, msg Message) {
+	if err := q.mailer.Send(ctx, msg); err != nil {
+		logger.FromContext(ctx).Warn("Failed to send mail",
+			zap.String("subject", msg.Subject),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,78 @@
+// Package msgpack - MessagePack с теми же именами полей, что и в JSON.
+// Кодирование выполняет github.com/vmihailenco/msgpack/v5 по json тегам
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const ContentType = "application/msgpack"
+
+var ErrInvalid = errors.New("msgpack: invalid data")
+
+// uuid.UUID кодируется строкой, как в JSON, а не 16 байтами bin. Время декодируется
+// в UTC, как и в JSON с суффиксом Z; кроме timestamp принимается строка RFC 3339
+func init() {
+	msgpack.Register(time.Time{}, nil, func(d *msgpack.Decoder, v reflect.Value) error {
+		tm, err := d.DecodeTime()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm.UTC()))
+		return nil
+	})
+
+	msgpack.Register(uuid.UUID{},
+		func(e *msgpack.Encoder, v reflect.Value) error {
+			return e.EncodeString(v.Interface().(uuid.UUID).String())
+		},
+		func(d *msgpack.Decoder, v reflect.Value) error {
+			s, err := d.DecodeString()
+			if err != nil {
+				return err
+			}
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(id))
+			return nil
+		})
+}
+
+// Marshal кодирует значение в MessagePack. Имена полей и omitempty берутся из json тегов,
+// time.Time кодируется расширением timestamp, []byte - типом bin
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.SetSortMapKeys(true)
+	enc.UseCompactInts(true)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal декодирует MessagePack в v. Данные после первого значения считаются ошибкой
+func Unmarshal(data []byte, v any) error {
+	reader := bytes.NewReader(data)
+	dec := msgpack.NewDecoder(reader)
+	dec.SetCustomStructTag("json")
+
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if reader.Len() != 0 {
+		return fmt.Errorf("%w: trailing data", ErrInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type testPayload struct {
+	ID       uuid.UUID         `json:"id"`
+	DateTime time.Time         `json:"dateTime"`
+	City     string            `json:"city"`
+	Count    int64             `json:"count"`
+	Big      uint64            `json:"big"`
+	Ratio    float64           `json:"ratio"`
+	Closed   bool              `json:"closed"`
+	Note     *string           `json:"note"`
+	Tags     []string          `json:"tags"`
+	Extra    map[string]string `json:"extra"`
+	Counts   map[int]int       `json:"counts"`
+	Raw      []byte            `json:"raw"`
+	Skipped  string            `json:"-"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	want := testPayload{
+		ID:       uuid.New(),
+		DateTime: time.Date(2025, 4, 1, 12, 30, 0, 123456789, time.UTC),
+		City:     "Санкт-Петербург",
+		Count:    math.MaxInt64,
+		Big:      math.MaxUint64,
+		Ratio:    0.1,
+		Closed:   true,
+		Tags:     []string{"электроника", strings.Repeat("x", 300)},
+		Extra:    map[string]string{"a": "b"},
+		Counts:   map[int]int{1: 2, -3: 4},
+		Raw:      []byte{0x00, 0xff},
+	}
+
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var got testPayload
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestMarshalEncoding(t *testing.T) {
+	id := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+
+	tests := []struct {
+		name  string
+		value any
+		want  []byte
+	}{
+		{"Nil", nil, []byte{0xc0}},
+		{"True", true, []byte{0xc3}},
+		{"Positive fixint", 5, []byte{0x05}},
+		{"Negative fixint", -1, []byte{0xff}},
+		{"Int8", -100, []byte{0xd0, 0x9c}},
+		{"Int16", 1000, []byte{0xcd, 0x03, 0xe8}},
+		{"Fixstr", "ab", []byte{0xa2, 'a', 'b'}},
+		{"Bin", []byte{1, 2}, []byte{0xc4, 0x02, 0x01, 0x02}},
+		{"Fixarray", []int{1, 2}, []byte{0x92, 0x01, 0x02}},
+		{"Sorted map", map[string]string{"b": "2", "a": "1"}, []byte{0x82, 0xa1, 'a', 0xa1, '1', 0xa1, 'b', 0xa1, '2'}},
+		{"Json tag", struct {
+			Name  string `json:"name"`
+			Empty string `json:"empty,omitempty"`
+		}{Name: "x"}, []byte{0x81, 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'x'}},
+		{"UUID as string", id, append([]byte{0xd9, 36}, id.String()...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Marshal() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalIntegers(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want int64
+	}{
+		{[]byte{0xd0, 0x80}, -128},
+		{[]byte{0xd1, 0xff, 0xfe}, -2},
+		{[]byte{0xd2, 0x80, 0x00, 0x00, 0x00}, math.MinInt32},
+		{[]byte{0xcc, 0xff}, 255},
+		{[]byte{0xcd, 0x01, 0x00}, 256},
+	}
+
+	for _, tt := range tests {
+		var got int64
+		if err := Unmarshal(tt.data, &got); err != nil {
+			t.Fatalf("Unmarshal(% x) error = %v", tt.data, err)
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(% x) = %d, want %d", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalTimestamp(t *testing.T) {
+	// {"at": timestamp 32}, ext -1 с секундами Unix
+	data := []byte{0x81, 0xa2, 'a', 't', 0xd6, 0xff, 0x67, 0xeb, 0xdb, 0x80}
+
+	var got struct {
+		At time.Time `json:"at"`
+	}
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := time.Unix(0x67ebdb80, 0).UTC(); got.At != want {
+		t.Errorf("Unmarshal() = %v, want %v", got.At, want)
+	}
+}
+
+func TestUnmarshalTimeString(t *testing.T) {
+	data, _ := Marshal(map[string]string{"at": "2025-04-01T15:30:00+03:00"})
+
+	var got struct {
+		At time.Time `json:"at"`
+	}
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := time.Date(2025, 4, 1, 12, 30, 0, 0, time.UTC); got.At != want {
+		t.Errorf("Unmarshal() = %v, want %v", got.At, want)
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	tests := map[string][]byte{
+		"Empty":         {},
+		"Truncated str": {0xa5, 'a'},
+		"Huge array":    {0xdd, 0xff, 0xff, 0xff, 0xff},
+		"Trailing data": {0xc0, 0xc0},
+		"Invalid uuid":  {0xa3, 'a', 'b', 'c'},
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var v any
+			if name == "Invalid uuid" {
+				v = &uuid.UUID{}
+			}
+			if err := Unmarshal(data, v); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Unmarshal() error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
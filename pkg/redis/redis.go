@@ -3,10 +3,11 @@ package redis
 import (
 	"context"
 	"fmt"
-	"os"
+	"net"
 	"strconv"
 	"time"
 
+	"github.com/kosttiik/pvz-service/pkg/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -15,39 +16,53 @@ var (
 )
 
 type Config struct {
-	Host     string
-	Port     string
-	Password string
-	DB       int
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
 }
 
-func DefaultConfig() *Config {
-	return &Config{
-		Host:     getEnvString("REDIS_HOST", "localhost"),
-		Port:     getEnvString("REDIS_PORT", "6379"),
-		Password: getEnvString("REDIS_PASSWORD", ""),
-		DB:       getEnvInt("REDIS_DB", 0),
+func DefaultConfig() Config {
+	return Config{
+		Host: "localhost",
+		Port: 6379,
 	}
 }
 
-func Connect() error {
-	config := DefaultConfig()
+func (c Config) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// Connect создает клиента и сохраняет его в Client
+func Connect(config Config) error {
+	client, err := Open(config)
+	if err != nil {
+		return err
+	}
+
+	Client = client
+	fmt.Println("Connected to the Redis")
+	return nil
+}
 
-	Client = redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", config.Host, config.Port),
+// Open создает и проверяет клиента, не трогая глобальный Client
+func Open(config Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.Addr(),
 		Password: config.Password,
 		DB:       config.DB,
 	})
+	client.AddHook(tracing.NewRedisHook())
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := Client.Ping(ctx).Err(); err != nil {
-		return fmt.Errorf("failed to connect to redis: %w", err)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
-	fmt.Println("Connected to the Redis")
-	return nil
+	return client, nil
 }
 
 func Close() error {
@@ -57,22 +72,6 @@ func Close() error {
 	return nil
 }
 
-func getEnvString(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
-		}
-	}
-	return defaultValue
-}
-
 func Set(ctx context.Context, key string, value any, expiration time.Duration) error {
 	return Client.Set(ctx, key, value, expiration).Err()
 }
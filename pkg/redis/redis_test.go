@@ -1,19 +1,16 @@
+//go:build integration
+
 package redis
 
 import (
 	"context"
-	"os"
 	"testing"
 	"time"
 )
 
 func TestConnect(t *testing.T) {
-	// переменные ОС для тестов
-	os.Setenv("REDIS_HOST", "localhost")
-	os.Setenv("REDIS_PORT", "6379")
-
 	// Проверяем успешность соединения
-	if err := Connect(); err != nil {
+	if err := Connect(DefaultConfig()); err != nil {
 		t.Errorf("Connect() error = %v", err)
 	}
 	defer Close()
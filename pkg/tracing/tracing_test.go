@@ -0,0 +1,157 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSQLOperation(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{sql: "SELECT * FROM pvz", want: "SELECT"},
+		{sql: "\n\t insert into reception VALUES ($1)", want: "INSERT"},
+		{sql: "WITH last AS (SELECT 1) DELETE FROM product", want: "WITH"},
+		{sql: "   ", want: "QUERY"},
+	}
+
+	for _, tt := range tests {
+		if got := sqlOperation(tt.sql); got != tt.want {
+			t.Errorf("sqlOperation(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+// newRecorder подменяет глобальный TracerProvider на провайдер с экспортером в память
+func newRecorder(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestPgxTracer(t *testing.T) {
+	exporter := newRecorder(t)
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+
+	tracer := NewPgxTracer()
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "select id from pvz"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 3")})
+
+	failedCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "INSERT INTO pvz VALUES ($1)"})
+	tracer.TraceQueryEnd(failedCtx, nil, pgx.TraceQueryEndData{Err: errors.New("duplicate key")})
+
+	noRowsCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(noRowsCtx, nil, pgx.TraceQueryEndData{Err: pgx.ErrNoRows})
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("Got %d spans, want 4", len(spans))
+	}
+
+	query := spans[0]
+	if query.Name != "postgres SELECT" || query.SpanKind != trace.SpanKindClient {
+		t.Errorf("Unexpected span %q of kind %v", query.Name, query.SpanKind)
+	}
+	if query.Parent.SpanID() != parent.SpanContext().SpanID() || query.SpanContext.TraceID() != parent.SpanContext().TraceID() {
+		t.Error("Query span should be a child of the span from context")
+	}
+
+	attrs := attributes(query)
+	if got := attrs[semconv.DBSystemKey].AsString(); got != "postgresql" {
+		t.Errorf("db.system = %q, want postgresql", got)
+	}
+	if got := attrs[semconv.DBQueryTextKey].AsString(); got != "select id from pvz" {
+		t.Errorf("db.query.text = %q", got)
+	}
+	if got := attrs["db.rows_affected"].AsInt64(); got != 3 {
+		t.Errorf("db.rows_affected = %d, want 3", got)
+	}
+
+	if failed := spans[1]; failed.Name != "postgres INSERT" || failed.Status.Code != codes.Error {
+		t.Errorf("Failed query span %q has status %v, want error", failed.Name, failed.Status.Code)
+	}
+	if noRows := spans[2]; noRows.Status.Code == codes.Error {
+		t.Error("pgx.ErrNoRows should not mark span as error")
+	}
+}
+
+func TestRedisHook(t *testing.T) {
+	exporter := newRecorder(t)
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+
+	hook := NewRedisHook()
+	var nextCtx context.Context
+	process := hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		nextCtx = ctx
+		return redis.Nil
+	})
+	if err := process(ctx, redis.NewStringCmd(ctx, "get", "key")); err != redis.Nil {
+		t.Fatalf("ProcessHook() error = %v, want redis.Nil", err)
+	}
+
+	pipeline := hook.ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error {
+		return errors.New("connection reset")
+	})
+	cmds := []redis.Cmder{redis.NewStatusCmd(ctx, "set", "a", "1"), redis.NewIntCmd(ctx, "incr", "b")}
+	if err := pipeline(ctx, cmds); err == nil {
+		t.Fatal("ProcessPipelineHook() should return error from next")
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("Got %d spans, want 3", len(spans))
+	}
+
+	get := spans[0]
+	if get.Name != "redis get" || get.Status.Code == codes.Error {
+		t.Errorf("Unexpected span %q with status %v", get.Name, get.Status.Code)
+	}
+	if get.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Command span should be a child of the span from context")
+	}
+	if trace.SpanContextFromContext(nextCtx).SpanID() != get.SpanContext.SpanID() {
+		t.Error("Next hook should receive context with command span")
+	}
+	if got := attributes(get)[semconv.DBSystemKey].AsString(); got != "redis" {
+		t.Errorf("db.system = %q, want redis", got)
+	}
+
+	pipe := spans[1]
+	if pipe.Name != "redis pipeline" || pipe.Status.Code != codes.Error {
+		t.Errorf("Unexpected span %q with status %v", pipe.Name, pipe.Status.Code)
+	}
+	if got := attributes(pipe)["db.redis.pipeline_length"].AsInt64(); got != 2 {
+		t.Errorf("db.redis.pipeline_length = %d, want 2", got)
+	}
+}
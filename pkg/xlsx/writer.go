@@ -0,0 +1,159 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+const (
+	contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+	rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+	workbookRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+	workbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+	sheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+	sheetFooter = `</sheetData></worksheet>`
+
+	// Ограничение Excel на длину значения ячейки
+	maxCellLength = 32767
+)
+
+// Writer пишет книгу с одним листом потоково: строки сразу уходят в zip,
+// поэтому память не зависит от размера выгрузки. Все ячейки пишутся как inline строки
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	static := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", fmt.Sprintf(workbookXML, escape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+	}
+
+	for _, part := range static {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sheet: %w", err)
+	}
+
+	sheet := bufio.NewWriter(f)
+	if _, err := sheet.WriteString(sheetHeader); err != nil {
+		return nil, fmt.Errorf("failed to write sheet header: %w", err)
+	}
+
+	return &Writer{zip: zw, sheet: sheet}, nil
+}
+
+func (w *Writer) WriteRow(values []string) error {
+	w.row++
+
+	var b strings.Builder
+	b.WriteString(`<row r="`)
+	b.WriteString(strconv.Itoa(w.row))
+	b.WriteString(`">`)
+	for i, value := range values {
+		b.WriteString(`<c r="`)
+		b.WriteString(columnName(i))
+		b.WriteString(strconv.Itoa(w.row))
+		b.WriteString(`" t="inlineStr"><is><t xml:space="preserve">`)
+		b.WriteString(escape(truncate(value)))
+		b.WriteString(`</t></is></c>`)
+	}
+	b.WriteString(`</row>`)
+
+	if _, err := w.sheet.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write row: %w", err)
+	}
+	return nil
+}
+
+// Flush выталкивает буферизованные строки в нижележащий writer
+func (w *Writer) Flush() error {
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zip.Flush()
+}
+
+// Close дописывает лист и центральный каталог zip. Без Close файл невалиден
+func (w *Writer) Close() error {
+	if _, err := w.sheet.WriteString(sheetFooter); err != nil {
+		return fmt.Errorf("failed to write sheet footer: %w", err)
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return fmt.Errorf("failed to flush sheet: %w", err)
+	}
+	if err := w.zip.Close(); err != nil {
+		return fmt.Errorf("failed to close workbook: %w", err)
+	}
+	return nil
+}
+
+// columnName переводит индекс колонки с нуля в буквенное имя: 0 -> A, 26 -> AA
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func truncate(s string) string {
+	if utf8.RuneCountInString(s) <= maxCellLength {
+		return s
+	}
+	return string([]rune(s)[:maxCellLength])
+}
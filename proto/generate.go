@@ -0,0 +1,3 @@
+package pvz_v1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative pvz.proto